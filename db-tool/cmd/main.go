@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/vignesh-goutham/artemis/pkg/dynamodb"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+const usage = `Usage: db-tool [flags] <command>
+
+Commands:
+  bootstrap   Create the table, its indexes and TTL, then apply all migrations
+  migrate     Apply pending schema migrations to an existing table
+  status      Show the table's schema version and pending migrations
+  breaker     Show the state of the loss circuit breaker
+  windows     Show the current allocation window and the window history
+  clear-breaker
+              Clear a tripped circuit breaker so the trading bot buys again
+
+Flags:
+`
+
+func main() {
+	region := flag.String("region", getEnvOrDefault("DYNAMODB_REGION", "us-east-1"), "AWS region")
+	tableName := flag.String("table", getEnvOrDefault("TABLE_NAME", "artemis-data"), "DynamoDB table name")
+	endpoint := flag.String("endpoint", os.Getenv("DYNAMODB_ENDPOINT"), "DynamoDB endpoint override, e.g. http://localhost:8000")
+	clearedBy := flag.String("by", getEnvOrDefault("USER", "db-tool"), "Name recorded as clearing the circuit breaker")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dbService, err := dynamodb.NewServiceWithOptions(dynamodb.Options{
+		Region:          *region,
+		TableName:       *tableName,
+		Endpoint:        *endpoint,
+		AccessKeyID:     os.Getenv("DYNAMODB_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("DYNAMODB_SECRET_ACCESS_KEY"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create DynamoDB service: %v", err)
+	}
+
+	ctx := context.Background()
+
+	switch flag.Arg(0) {
+	case "bootstrap":
+		err = bootstrap(ctx, dbService)
+	case "migrate":
+		err = migrate(ctx, dbService)
+	case "status":
+		err = status(ctx, dbService)
+	case "breaker":
+		err = showBreaker(ctx, dbService)
+	case "clear-breaker":
+		err = clearBreaker(ctx, dbService, *clearedBy)
+	case "windows":
+		err = showWindows(ctx, dbService)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatalf("%s failed: %v", flag.Arg(0), err)
+	}
+}
+
+// bootstrap sets up the table from scratch or completes a partial setup
+func bootstrap(ctx context.Context, dbService *dynamodb.Service) error {
+	err := dbService.EnsureTable(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Table %s is ready", dbService.TableName())
+
+	return migrate(ctx, dbService)
+}
+
+// migrate applies pending schema migrations
+func migrate(ctx context.Context, dbService *dynamodb.Service) error {
+	applied, err := dbService.Migrate(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		log.Printf("Schema is up to date at version %d", dynamodb.LatestSchemaVersion())
+		return nil
+	}
+	log.Printf("Applied %d migrations, schema is at version %d", len(applied), applied[len(applied)-1].Version)
+	return nil
+}
+
+// status prints the schema version and the migrations still to apply
+func status(ctx context.Context, dbService *dynamodb.Service) error {
+	current, err := dbService.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Table:          %s\n", dbService.TableName())
+	fmt.Printf("Schema version: %d (latest %d)\n", current, dynamodb.LatestSchemaVersion())
+
+	pending := dynamodb.PendingMigrations(current)
+	if len(pending) == 0 {
+		fmt.Println("No pending migrations")
+		return nil
+	}
+
+	fmt.Println("Pending migrations:")
+	for _, migration := range pending {
+		fmt.Printf("  %d: %s\n", migration.Version, migration.Description)
+	}
+	return nil
+}
+
+// showBreaker prints the state of the loss circuit breaker
+func showBreaker(ctx context.Context, dbService *dynamodb.Service) error {
+	breaker, err := dbService.LoadCircuitBreaker(ctx)
+	if err != nil {
+		return err
+	}
+	if breaker == nil {
+		fmt.Println("Circuit breaker has not been evaluated yet")
+		return nil
+	}
+
+	if breaker.Tripped {
+		fmt.Printf("State:       TRIPPED since %s\n", breaker.TrippedAt.Format(time.RFC3339))
+		fmt.Printf("Rule:        %s\n", breaker.Rule)
+		fmt.Printf("Reason:      %s\n", breaker.Reason)
+	} else {
+		fmt.Println("State:       OK")
+	}
+	fmt.Printf("Peak equity: $%.2f (%s)\n", breaker.PeakEquity, breaker.PeakAt.Format(time.RFC3339))
+	fmt.Printf("Last equity: $%.2f\n", breaker.LastEquity)
+	if breaker.ClearedBy != "" {
+		fmt.Printf("Last clear:  %s at %s\n", breaker.ClearedBy, breaker.ClearedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// clearBreaker clears a tripped circuit breaker so buys resume on the next run
+func clearBreaker(ctx context.Context, dbService *dynamodb.Service, clearedBy string) error {
+	breaker, err := dbService.LoadCircuitBreaker(ctx)
+	if err != nil {
+		return err
+	}
+	if breaker == nil || !breaker.Tripped {
+		log.Println("Circuit breaker is not tripped, nothing to clear")
+		return nil
+	}
+
+	breaker.Clear(clearedBy, time.Now())
+	if err := dbService.SaveCircuitBreaker(ctx, breaker); err != nil {
+		return err
+	}
+
+	log.Printf("Circuit breaker cleared by %s, buys resume on the next run", clearedBy)
+	return nil
+}
+
+// showWindows prints the accounting of the current allocation window and of
+// every closed window in the history
+func showWindows(ctx context.Context, dbService *dynamodb.Service) error {
+	_, current, err := dbService.LoadAllData(ctx)
+	if err != nil {
+		return err
+	}
+	history, err := dbService.LoadAllocationWindowHistory(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-10s  %-10s  %-7s  %12s  %12s  %12s  %12s  %10s\n",
+		"START", "END", "SLOTS", "BUDGET", "DEPLOYED", "RETURNED", "REMAINING", "LEFTOVER")
+	for _, window := range history {
+		printWindow(window)
+	}
+	if current == nil {
+		fmt.Println("No current allocation window")
+		return nil
+	}
+	printWindow(*current)
+	fmt.Println("(last row is the current window)")
+	return nil
+}
+
+// printWindow prints one row of the window table
+func printWindow(window types.AllocationWindow) {
+	fmt.Printf("%-10s  %-10s  %3d/%-3d  %12.2f  %12.2f  %12.2f  %12.2f  %10.2f\n",
+		window.ID(), window.WindowEndDate.UTC().Format("2006-01-02"),
+		window.SlotsUsed, window.TotalSignalsInWindow, window.AccountValue,
+		window.CapitalDeployed, window.CapitalReturned, window.RemainingBudget, window.LeftoverCash)
+}
+
+// getEnvOrDefault gets an environment variable or returns a default value
+func getEnvOrDefault(key, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -15,6 +19,7 @@ import (
 	"github.com/vignesh-goutham/artemis/discord-bot/internal"
 	"github.com/vignesh-goutham/artemis/pkg/discord"
 	"github.com/vignesh-goutham/artemis/pkg/dynamodb"
+	"github.com/vignesh-goutham/artemis/pkg/store"
 	"github.com/vignesh-goutham/artemis/pkg/types"
 )
 
@@ -26,6 +31,13 @@ type DiscordInteraction struct {
 	Data      *DiscordInteractionData `json:"data,omitempty"`
 	GuildID   string                  `json:"guild_id,omitempty"`
 	ChannelID string                  `json:"channel_id,omitempty"`
+	Message   *DiscordMessage         `json:"message,omitempty"`
+}
+
+// DiscordMessage represents the message a component interaction came from
+type DiscordMessage struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
 }
 
 // DiscordMember represents a Discord guild member
@@ -47,9 +59,17 @@ type DiscordInteractionData struct {
 	CustomID    string                         `json:"custom_id,omitempty"`
 	Components  []DiscordComponent             `json:"components,omitempty"`
 	Values      []string                       `json:"values,omitempty"`
+	Options     []DiscordCommandOption         `json:"options,omitempty"`
 	ModalSubmit *DiscordModalSubmitInteraction `json:"modal_submit,omitempty"`
 }
 
+// DiscordCommandOption represents an option passed to a slash command
+type DiscordCommandOption struct {
+	Name  string      `json:"name"`
+	Type  int         `json:"type"`
+	Value interface{} `json:"value,omitempty"`
+}
+
 // DiscordComponent represents a Discord component
 type DiscordComponent struct {
 	Type        int                `json:"type"`
@@ -58,7 +78,7 @@ type DiscordComponent struct {
 	Style       int                `json:"style,omitempty"`
 	Components  []DiscordComponent `json:"components,omitempty"`
 	Value       string             `json:"value,omitempty"`
-	Required    bool               `json:"required,omitempty"`
+	Required    *bool              `json:"required,omitempty"` // Discord treats an unset text input as required
 	MinLength   int                `json:"min_length,omitempty"`
 	MaxLength   int                `json:"max_length,omitempty"`
 	Placeholder string             `json:"placeholder,omitempty"`
@@ -83,6 +103,20 @@ type DiscordResponseData struct {
 	Flags      int                `json:"flags,omitempty"`
 }
 
+// DiscordUpdateMessageData replaces the content and components of the message
+// a component interaction came from. Components are always sent so an empty
+// list removes the buttons.
+type DiscordUpdateMessageData struct {
+	Content    string             `json:"content"`
+	Components []DiscordComponent `json:"components"`
+}
+
+// DiscordUpdateMessageResponse represents an update message response
+type DiscordUpdateMessageResponse struct {
+	Type int                      `json:"type"`
+	Data DiscordUpdateMessageData `json:"data"`
+}
+
 // DiscordModal represents a Discord modal
 type DiscordModal struct {
 	CustomID   string             `json:"custom_id"`
@@ -131,9 +165,18 @@ const (
 	ResponseFlagEphemeral = 64
 )
 
+// botStore is what the Discord bot needs from its storage backend
+type botStore interface {
+	store.SignalStore
+	store.ControlStore
+}
+
+// recentModeChanges is how many trading mode changes /tradingmode shows
+const recentModeChanges = 5
+
 var (
 	config    *internal.Config
-	dbService *dynamodb.Service
+	dbService botStore
 )
 
 func init() {
@@ -143,9 +186,33 @@ func init() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	dbService, err = dynamodb.NewService(config.DynamoDBRegion, config.TableName)
+	dbService, err = newStore(config)
 	if err != nil {
-		log.Fatalf("Failed to create DynamoDB service: %v", err)
+		log.Fatalf("Failed to create store: %v", err)
+	}
+}
+
+// newStore creates the storage backend selected by the configuration
+func newStore(config *internal.Config) (botStore, error) {
+	switch config.StoreBackend {
+	case "", store.BackendDynamoDB:
+		dbService, err := dynamodb.NewServiceWithOptions(dynamodb.Options{
+			Region:          config.DynamoDBRegion,
+			TableName:       config.TableName,
+			Endpoint:        config.DynamoDBEndpoint,
+			AccessKeyID:     config.DynamoDBAccessKeyID,
+			SecretAccessKey: config.DynamoDBSecretAccessKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create DynamoDB service: %w", err)
+		}
+		return dbService, nil
+	case store.BackendMemory:
+		return store.NewMemoryStore(), nil
+	case store.BackendFile:
+		return store.NewFileStore(config.StoreFilePath)
+	default:
+		return nil, fmt.Errorf("unknown store backend: %s", config.StoreBackend)
 	}
 }
 
@@ -237,6 +304,10 @@ func handler(ctx context.Context, req events.LambdaFunctionURLRequest) (events.L
 		// Handle modal submissions
 		return handleModalSubmit(ctx, &interaction)
 
+	case InteractionTypeMessageComponent:
+		// Handle button clicks
+		return handleMessageComponent(ctx, &interaction)
+
 	default:
 		log.Printf("Unhandled interaction type: %d", interaction.Type)
 		return events.LambdaFunctionURLResponse{
@@ -263,6 +334,10 @@ func handleApplicationCommand(ctx context.Context, interaction *DiscordInteracti
 	switch interaction.Data.Name {
 	case "addsignal":
 		return handleAddSignalCommand(ctx, interaction)
+	case "cancelsignal":
+		return handleCancelSignalCommand(ctx, interaction)
+	case "tradingmode":
+		return handleTradingModeCommand(ctx, interaction)
 	default:
 		return events.LambdaFunctionURLResponse{
 			StatusCode: http.StatusBadRequest,
@@ -288,7 +363,7 @@ func handleAddSignalCommand(ctx context.Context, interaction *DiscordInteraction
 						CustomID:    "ticker",
 						Label:       "Stock Ticker",
 						Style:       TextInputStyleShort,
-						Required:    true,
+						Required:    boolPtr(true),
 						MinLength:   1,
 						MaxLength:   10,
 						Placeholder: "e.g., AAPL",
@@ -303,13 +378,41 @@ func handleAddSignalCommand(ctx context.Context, interaction *DiscordInteraction
 						CustomID:    "sell_date",
 						Label:       "Sell Date (YYYY-MM-DD)",
 						Style:       TextInputStyleShort,
-						Required:    true,
+						Required:    boolPtr(true),
 						MinLength:   10,
 						MaxLength:   10,
 						Placeholder: "2024-02-15",
 					},
 				},
 			},
+			{
+				Type: ComponentTypeActionRow,
+				Components: []DiscordComponent{
+					{
+						Type:        ComponentTypeTextInput,
+						CustomID:    "conviction",
+						Label:       fmt.Sprintf("Conviction (%d-%d, optional)", types.MinConviction, types.MaxConviction),
+						Style:       TextInputStyleShort,
+						Required:    boolPtr(false),
+						MaxLength:   1,
+						Placeholder: strconv.Itoa(types.DefaultConviction),
+					},
+				},
+			},
+			{
+				Type: ComponentTypeActionRow,
+				Components: []DiscordComponent{
+					{
+						Type:        ComponentTypeTextInput,
+						CustomID:    "priority",
+						Label:       fmt.Sprintf("Priority (%d-%d, optional)", types.MinPriority, types.MaxPriority),
+						Style:       TextInputStyleShort,
+						Required:    boolPtr(false),
+						MaxLength:   1,
+						Placeholder: strconv.Itoa(types.MinPriority),
+					},
+				},
+			},
 		},
 	}
 
@@ -321,6 +424,202 @@ func handleAddSignalCommand(ctx context.Context, interaction *DiscordInteraction
 	return createResponse(response)
 }
 
+func handleCancelSignalCommand(ctx context.Context, interaction *DiscordInteraction) (events.LambdaFunctionURLResponse, error) {
+	if !isTradingAdmin(interaction) {
+		log.Printf("Refused /cancelsignal from %s, who is not a trading admin", interactionUsername(interaction))
+		return createMessageResponse("❌ Error: You are not allowed to use /cancelsignal")
+	}
+
+	signalUUID, err := uuid.Parse(commandOption(interaction, "uuid"))
+	if err != nil {
+		return createMessageResponse("❌ Error: Invalid signal UUID")
+	}
+
+	reason := commandOption(interaction, "reason")
+	if reason == "" {
+		reason = "No reason given"
+	}
+
+	signal, err := dbService.GetSignal(ctx, signalUUID)
+	if err != nil {
+		log.Printf("Failed to load signal %s: %v", signalUUID, err)
+		return createMessageResponse("❌ Error: Failed to load signal. Please try again.")
+	}
+	if signal == nil {
+		return createMessageResponse(fmt.Sprintf("❌ Error: Signal %s not found", signalUUID))
+	}
+
+	previousStatus := signal.Status
+	err = signal.Transition(types.SignalStatusCancelled, fmt.Sprintf("Cancelled by %s: %s", interactionUsername(interaction), reason))
+	if errors.Is(err, types.ErrInvalidTransition) {
+		return createMessageResponse(fmt.Sprintf("❌ Error: Signal %s is %s and can no longer be cancelled", signalUUID, previousStatus))
+	}
+	if err != nil {
+		return createMessageResponse(fmt.Sprintf("❌ Error: %v", err))
+	}
+
+	err = dbService.TransitionSignal(ctx, signal, previousStatus)
+	if errors.Is(err, store.ErrVersionConflict) {
+		return createMessageResponse("❌ Error: The signal was just changed by the trading bot. Please try again.")
+	}
+	if err != nil {
+		log.Printf("Failed to cancel signal %s: %v", signalUUID, err)
+		return createMessageResponse("❌ Error: Failed to cancel signal. Please try again.")
+	}
+
+	message := fmt.Sprintf("🚫 **Signal Cancelled**\n"+
+		"**Ticker:** %s\n"+
+		"**UUID:** %s\n"+
+		"**Reason:** %s",
+		signal.Ticker, signal.UUID, reason)
+
+	// A cancelled pending signal frees its slot for the waitlist
+	if previousStatus == types.SignalStatusPending && config.AdmissionPolicy != internal.AdmissionPolicyOff {
+		promoted, err := promoteWaitlisted(ctx, fmt.Sprintf("Promoted from the waitlist after %s (%s) was cancelled", signal.Ticker, signal.UUID))
+		if err != nil {
+			// The trading bot promotes it on its next run instead
+			log.Printf("Warning: Failed to promote a waitlisted signal: %v", err)
+		} else if promoted != nil {
+			message += fmt.Sprintf("\n⏫ **%s** (%s) was promoted from the waitlist", promoted.Ticker, promoted.UUID)
+		}
+	}
+
+	return createMessageResponse(message)
+}
+
+// handleTradingModeCommand shows the trading kill switch, or sets it when a
+// mode is given. Only trading admins may use it, and every change is recorded
+// with the user who made it.
+func handleTradingModeCommand(ctx context.Context, interaction *DiscordInteraction) (events.LambdaFunctionURLResponse, error) {
+	if !isTradingAdmin(interaction) {
+		log.Printf("Refused /tradingmode from %s, who is not a trading admin", interactionUsername(interaction))
+		return createMessageResponse("❌ Error: You are not allowed to use /tradingmode")
+	}
+
+	control, err := dbService.LoadTradingControl(ctx)
+	if err != nil {
+		log.Printf("Failed to load trading control: %v", err)
+		return createMessageResponse("❌ Error: Failed to load the trading mode. Please try again.")
+	}
+	if control == nil || control.Mode == "" {
+		control = &types.TradingControl{Mode: types.TradingModeNormal}
+	}
+
+	value := commandOption(interaction, "mode")
+	if value == "" {
+		return createMessageResponse(tradingModeSummary(control))
+	}
+
+	mode, err := types.ParseTradingMode(value)
+	if err != nil {
+		return createMessageResponse(fmt.Sprintf("❌ Error: Unknown trading mode %q. Use normal, sells_only, paused or liquidate_all", value))
+	}
+	if mode == control.Mode {
+		return createMessageResponse(fmt.Sprintf("ℹ️ Trading mode is already %s", mode))
+	}
+
+	reason := commandOption(interaction, "reason")
+	if reason == "" {
+		reason = "No reason given"
+	}
+
+	username := interactionUsername(interaction)
+	previousMode := control.Mode
+	control.SetMode(mode, username, reason, time.Now())
+
+	err = dbService.SaveTradingControl(ctx, control)
+	if errors.Is(err, store.ErrVersionConflict) {
+		return createMessageResponse("❌ Error: The trading mode was just changed by someone else. Please try again.")
+	}
+	if err != nil {
+		log.Printf("Failed to save trading control: %v", err)
+		return createMessageResponse("❌ Error: Failed to save the trading mode. Please try again.")
+	}
+
+	log.Printf("Trading mode changed from %s to %s by %s: %s", previousMode, mode, username, reason)
+
+	// Posted to the channel rather than ephemeral so everyone sees the change
+	return createResponse(DiscordResponse{
+		Type: ResponseTypeChannelMessageWithSource,
+		Data: &DiscordResponseData{
+			Content: fmt.Sprintf("🎛️ **Trading Mode Changed**\n"+
+				"**Mode:** %s → %s\n"+
+				"**By:** %s\n"+
+				"**Reason:** %s\n"+
+				"Takes effect on the next trading bot run",
+				previousMode, mode, username, reason),
+		},
+	})
+}
+
+// tradingModeSummary describes the current trading mode and its latest changes
+func tradingModeSummary(control *types.TradingControl) string {
+	summary := fmt.Sprintf("🎛️ **Trading Mode:** %s", control.Mode)
+	if control.UpdatedBy != "" {
+		summary += fmt.Sprintf("\nSet by %s at %s: %s",
+			control.UpdatedBy, control.UpdatedAt.Format(time.RFC3339), control.Reason)
+	}
+
+	history := control.History
+	if len(history) > recentModeChanges {
+		history = history[len(history)-recentModeChanges:]
+	}
+	if len(history) > 0 {
+		summary += "\n**Recent changes:**"
+		for i := len(history) - 1; i >= 0; i-- {
+			change := history[i]
+			summary += fmt.Sprintf("\n%s: %s → %s by %s (%s)",
+				change.ChangedAt.Format("2006-01-02 15:04"), change.From, change.To, change.ChangedBy, change.Reason)
+		}
+	}
+	return summary
+}
+
+// boolPtr returns a pointer to b, for optional JSON fields
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// commandOption returns the string value of a slash command option, or an empty string
+func commandOption(interaction *DiscordInteraction, name string) string {
+	if interaction.Data == nil {
+		return ""
+	}
+	for _, option := range interaction.Data.Options {
+		if option.Name == name {
+			if value, ok := option.Value.(string); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// isTradingAdmin reports whether the user who sent the interaction is listed
+// in TRADING_ADMIN_USER_IDS
+func isTradingAdmin(interaction *DiscordInteraction) bool {
+	return interaction.Member != nil && config.TradingAdminUserIDs[interaction.Member.User.ID]
+}
+
+// interactionUsername returns the name of the user who sent the interaction
+func interactionUsername(interaction *DiscordInteraction) string {
+	if interaction.Member != nil && interaction.Member.User.Username != "" {
+		return interaction.Member.User.Username
+	}
+	return "unknown user"
+}
+
+// createMessageResponse creates an ephemeral message response
+func createMessageResponse(content string) (events.LambdaFunctionURLResponse, error) {
+	return createResponse(DiscordResponse{
+		Type: ResponseTypeChannelMessageWithSource,
+		Data: &DiscordResponseData{
+			Content: content,
+			Flags:   ResponseFlagEphemeral,
+		},
+	})
+}
+
 func handleModalSubmit(ctx context.Context, interaction *DiscordInteraction) (events.LambdaFunctionURLResponse, error) {
 	if interaction.Data == nil {
 		return events.LambdaFunctionURLResponse{
@@ -358,9 +657,102 @@ func handleModalSubmit(ctx context.Context, interaction *DiscordInteraction) (ev
 	}
 }
 
+func handleMessageComponent(ctx context.Context, interaction *DiscordInteraction) (events.LambdaFunctionURLResponse, error) {
+	if interaction.Data == nil || interaction.Data.CustomID == "" {
+		return events.LambdaFunctionURLResponse{
+			StatusCode: http.StatusBadRequest,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+			Body: `{"error": "No custom_id found"}`,
+		}, nil
+	}
+
+	decision, signalUUID, step, err := types.ParseApprovalCustomID(interaction.Data.CustomID)
+	if err != nil {
+		log.Printf("Unknown component %s: %v", interaction.Data.CustomID, err)
+		return events.LambdaFunctionURLResponse{
+			StatusCode: http.StatusBadRequest,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+			Body: `{"error": "Unknown component"}`,
+		}, nil
+	}
+
+	return handleApprovalDecision(ctx, interaction, signalUUID, step, decision)
+}
+
+// handleApprovalDecision records an Approve or Reject click on the signal. The
+// trading bot only places the order on a later run if it was approved in time.
+func handleApprovalDecision(ctx context.Context, interaction *DiscordInteraction, signalUUID uuid.UUID, step string, decision types.ApprovalDecision) (events.LambdaFunctionURLResponse, error) {
+	if !isTradingAdmin(interaction) {
+		log.Printf("Refused %s decision on signal %s from %s, who is not a trading admin",
+			strings.ToLower(string(decision)), signalUUID, interactionUsername(interaction))
+		return createMessageResponse("❌ Error: You are not allowed to approve or reject orders")
+	}
+
+	signal, err := dbService.GetSignal(ctx, signalUUID)
+	if err != nil {
+		log.Printf("Failed to load signal %s: %v", signalUUID, err)
+		return createMessageResponse("❌ Error: Failed to load signal. Please try again.")
+	}
+	if signal == nil {
+		return createMessageResponse(fmt.Sprintf("❌ Error: Signal %s not found", signalUUID))
+	}
+
+	now := time.Now()
+	approval := signal.Approval
+	if approval == nil || approval.Step != step {
+		return createMessageResponse(fmt.Sprintf("❌ Error: No %s approval is open for signal %s", step, signalUUID))
+	}
+	if !approval.IsOpen(now) {
+		if approval.Decision != types.ApprovalDecisionPending {
+			return createMessageResponse(fmt.Sprintf("❌ Error: This order was already %s by %s",
+				strings.ToLower(string(approval.Decision)), approval.DecidedBy))
+		}
+		return createMessageResponse("❌ Error: The approval deadline has passed, a new request will be posted on the next run")
+	}
+
+	username := interactionUsername(interaction)
+	approval.Decision = decision
+	approval.DecidedBy = username
+	approval.DecidedAt = now
+	signal.UpdatedAt = now
+
+	// Same status, so this is a version checked update of the signal
+	err = dbService.TransitionSignal(ctx, signal, signal.Status)
+	if errors.Is(err, store.ErrVersionConflict) {
+		return createMessageResponse("❌ Error: The signal was just changed by the trading bot. Please try again.")
+	}
+	if err != nil {
+		log.Printf("Failed to record approval decision for signal %s: %v", signalUUID, err)
+		return createMessageResponse("❌ Error: Failed to record the decision. Please try again.")
+	}
+
+	result := fmt.Sprintf("✅ **Approved** by %s", username)
+	if decision == types.ApprovalDecisionRejected {
+		result = fmt.Sprintf("🚫 **Rejected** by %s", username)
+	}
+
+	content := result
+	if interaction.Message != nil && interaction.Message.Content != "" {
+		content = interaction.Message.Content + "\n" + result
+	}
+
+	// Replace the buttons with the decision so nobody decides twice
+	return createResponse(DiscordUpdateMessageResponse{
+		Type: ResponseTypeUpdateMessage,
+		Data: DiscordUpdateMessageData{
+			Content:    content,
+			Components: []DiscordComponent{},
+		},
+	})
+}
+
 func handleSignalModalSubmit(ctx context.Context, interaction *DiscordInteraction) (events.LambdaFunctionURLResponse, error) {
 	// Extract form data from data.components
-	var ticker, sellDateStr string
+	var ticker, sellDateStr, convictionStr, priorityStr string
 
 	if interaction.Data == nil || len(interaction.Data.Components) == 0 {
 		return events.LambdaFunctionURLResponse{
@@ -384,6 +776,10 @@ func handleSignalModalSubmit(ctx context.Context, interaction *DiscordInteractio
 					ticker = subComponent.Value
 				case "sell_date":
 					sellDateStr = subComponent.Value
+				case "conviction":
+					convictionStr = strings.TrimSpace(subComponent.Value)
+				case "priority":
+					priorityStr = strings.TrimSpace(subComponent.Value)
 				}
 			}
 		}
@@ -416,8 +812,28 @@ func handleSignalModalSubmit(ctx context.Context, interaction *DiscordInteractio
 		return createResponse(response)
 	}
 
-	// Set buy date to current date
-	buyDate := time.Now().Truncate(24 * time.Hour) // Truncate to start of day
+	// Conviction is optional and only used by conviction-weighted sizing
+	conviction := 0
+	if convictionStr != "" {
+		conviction, err = strconv.Atoi(convictionStr)
+		if err != nil || conviction < types.MinConviction || conviction > types.MaxConviction {
+			return createMessageResponse(fmt.Sprintf("❌ Error: Conviction must be a whole number from %d to %d",
+				types.MinConviction, types.MaxConviction))
+		}
+	}
+
+	// Priority is optional, higher priorities are funded first when money or slots run short
+	priority := types.MinPriority
+	if priorityStr != "" {
+		priority, err = strconv.Atoi(priorityStr)
+		if err != nil || priority < types.MinPriority || priority > types.MaxPriority {
+			return createMessageResponse(fmt.Sprintf("❌ Error: Priority must be a whole number from %d to %d",
+				types.MinPriority, types.MaxPriority))
+		}
+	}
+
+	// Set buy date to the current New York date
+	buyDate := types.SessionDate(time.Now(), config.MarketLocation)
 
 	// Validate date logic - sell date should be today or in the future
 	if sellDate.Before(buyDate) {
@@ -433,20 +849,28 @@ func handleSignalModalSubmit(ctx context.Context, interaction *DiscordInteractio
 
 	// Create signal
 	signal := types.Signal{
-		UUID:      uuid.New(),
-		Ticker:    ticker,
-		BuyDate:   buyDate,
-		SellDate:  sellDate,
-		Status:    types.SignalStatusPending,
-		NumStocks: 0,
-		BuyPrice:  0,
-		SellPrice: 0,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-
-	// Save to DynamoDB using the shared service
-	err = dbService.SaveSignal(ctx, signal)
+		UUID:       uuid.New(),
+		Ticker:     ticker,
+		BuyDate:    buyDate,
+		SellDate:   sellDate,
+		Conviction: conviction,
+		Priority:   priority,
+		NumStocks:  0,
+		BuyPrice:   0,
+		SellPrice:  0,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	// Admit the signal against the slots left in the allocation window and save it
+	capacity, err := admitNewSignal(ctx, &signal, interactionUsername(interaction))
+	if errors.Is(err, errWindowFull) {
+		return createMessageResponse(fmt.Sprintf("❌ **Signal Not Added**\n"+
+			"The allocation window is full: %s", capacity))
+	}
+	if errors.Is(err, store.ErrVersionConflict) {
+		return createMessageResponse("❌ Error: Other signals were added at the same time. Please try again.")
+	}
 	if err != nil {
 		log.Printf("Failed to save signal: %v", err)
 		response := DiscordResponse{
@@ -460,16 +884,32 @@ func handleSignalModalSubmit(ctx context.Context, interaction *DiscordInteractio
 	}
 
 	// Success response
+	title := "✅ **Signal Added Successfully!**"
+	statusLine := "**Status:** Pending"
+	if signal.Status == types.SignalStatusWaitlisted {
+		capacity.Waitlisted++
+		title = "⏳ **Signal Waitlisted**"
+		statusLine = fmt.Sprintf("**Status:** Waitlisted (position %d), bought once a slot frees up", capacity.Waitlisted)
+	} else {
+		capacity.Committed++
+	}
+	if config.AdmissionPolicy != internal.AdmissionPolicyOff {
+		statusLine += fmt.Sprintf("\n**Slots:** %d of %d remaining in the allocation window", capacity.Remaining(), capacity.Slots)
+	}
+
 	response := DiscordResponse{
 		Type: ResponseTypeChannelMessageWithSource,
 		Data: &DiscordResponseData{
-			Content: fmt.Sprintf("✅ **Signal Added Successfully!**\n"+
+			Content: fmt.Sprintf("%s\n"+
 				"**Ticker:** %s\n"+
 				"**Buy Date:** %s (Today)\n"+
 				"**Sell Date:** %s\n"+
-				"**Status:** Pending\n"+
+				"**Conviction:** %d\n"+
+				"**Priority:** %d\n"+
+				"%s\n"+
 				"**UUID:** %s",
-				ticker, buyDate.Format("2006-01-02"), sellDate.Format("2006-01-02"), signal.UUID.String()),
+				title, ticker, buyDate.Format("2006-01-02"), sellDate.Format("2006-01-02"), signal.EffectiveConviction(),
+				signal.Priority, statusLine, signal.UUID.String()),
 			Flags: ResponseFlagEphemeral,
 		},
 	}
@@ -477,6 +917,104 @@ func handleSignalModalSubmit(ctx context.Context, interaction *DiscordInteractio
 	return createResponse(response)
 }
 
+// maxAdmissionAttempts bounds how often a signal is admitted again after the
+// allocation window changed while its capacity was counted
+const maxAdmissionAttempts = 3
+
+// errWindowFull is returned when the reject admission policy turns a signal away
+var errWindowFull = errors.New("the allocation window is full")
+
+// admitNewSignal saves a new signal as PENDING, or as WAITLISTED when the
+// allocation window has no slot left for it, and returns the capacity it was
+// admitted against. The signal is saved together with a version bump of the
+// window, so a concurrent /addsignal that counted the same free slot fails
+// and counts again instead of overfilling the window.
+func admitNewSignal(ctx context.Context, signal *types.Signal, username string) (types.WindowCapacity, error) {
+	for attempt := 1; ; attempt++ {
+		signal.Status = types.SignalStatusPending
+		signal.StatusReason = fmt.Sprintf("Added by %s", username)
+		if config.AdmissionPolicy == internal.AdmissionPolicyOff {
+			return types.WindowCapacity{}, dbService.SaveSignal(ctx, *signal)
+		}
+
+		capacity, window, err := windowCapacity(ctx)
+		if err != nil {
+			return capacity, fmt.Errorf("failed to check the allocation window's capacity: %w", err)
+		}
+
+		if capacity.Remaining() == 0 {
+			if config.AdmissionPolicy == internal.AdmissionPolicyReject {
+				return capacity, errWindowFull
+			}
+			signal.Status = types.SignalStatusWaitlisted
+			signal.StatusReason = fmt.Sprintf("Waitlisted by %s, the allocation window is full", username)
+		}
+
+		err = dbService.AdmitSignal(ctx, signal, "", window)
+		if errors.Is(err, store.ErrVersionConflict) && attempt < maxAdmissionAttempts {
+			log.Printf("Allocation window changed while admitting signal %s, counting its capacity again", signal.UUID)
+			continue
+		}
+		return capacity, err
+	}
+}
+
+// windowCapacity reads the allocation window and the open signals that will
+// take its slots, and returns the window the capacity was counted from
+func windowCapacity(ctx context.Context) (types.WindowCapacity, *types.AllocationWindow, error) {
+	signals, window, err := dbService.LoadAllData(ctx)
+	if err != nil {
+		return types.WindowCapacity{}, nil, err
+	}
+	today := types.SessionDate(time.Now(), config.MarketLocation)
+	return types.NewWindowCapacity(window, signals, config.MaxSignalsPerWindow, today), window, nil
+}
+
+// promoteWaitlisted promotes the oldest waitlisted signal into a slot freed by
+// a cancellation and returns it, or nil when no slot or no signal is waiting.
+// The promotion is saved with a version bump of the allocation window like a
+// new signal, and counted again if the window changed in the meantime. Signals
+// past their sell date are left for the trading bot to expire, and it promotes
+// any further signals on its next run.
+func promoteWaitlisted(ctx context.Context, reason string) (*types.Signal, error) {
+	for attempt := 1; ; attempt++ {
+		signals, window, err := dbService.LoadAllData(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		today := types.SessionDate(time.Now(), config.MarketLocation)
+		capacity := types.NewWindowCapacity(window, signals, config.MaxSignalsPerWindow, today)
+		if capacity.Free() == 0 {
+			return nil, nil
+		}
+
+		var promoted *types.Signal
+		for _, signal := range types.Waitlist(signals) {
+			if today.Before(signal.SellDate) {
+				promoted = signal
+				break
+			}
+		}
+		if promoted == nil {
+			return nil, nil
+		}
+
+		if err := promoted.Promote(today, reason); err != nil {
+			return nil, err
+		}
+		err = dbService.AdmitSignal(ctx, promoted, types.SignalStatusWaitlisted, window)
+		if errors.Is(err, store.ErrVersionConflict) && attempt < maxAdmissionAttempts {
+			log.Printf("Allocation window changed while promoting signal %s, counting its capacity again", promoted.UUID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return promoted, nil
+	}
+}
+
 func createResponse(response interface{}) (events.LambdaFunctionURLResponse, error) {
 	responseBody, err := json.Marshal(response)
 	if err != nil {
@@ -498,6 +1036,44 @@ func createResponse(response interface{}) (events.LambdaFunctionURLResponse, err
 	}, nil
 }
 
+// serveLocal serves interactions over plain HTTP so the bot can run without Lambda
+func serveLocal(addr string) error {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, `{"error": "invalid body"}`, http.StatusBadRequest)
+			return
+		}
+
+		headers := make(map[string]string, len(r.Header))
+		for key := range r.Header {
+			headers[strings.ToLower(key)] = r.Header.Get(key)
+		}
+
+		response, err := handler(r.Context(), events.LambdaFunctionURLRequest{
+			Headers: headers,
+			Body:    string(body),
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for key, value := range response.Headers {
+			w.Header().Set(key, value)
+		}
+		w.WriteHeader(response.StatusCode)
+		w.Write([]byte(response.Body))
+	})
+
+	log.Printf("Serving Discord interactions on %s", addr)
+	return http.ListenAndServe(addr, nil)
+}
+
 func main() {
+	if config.LocalListenAddr != "" {
+		log.Fatal(serveLocal(config.LocalListenAddr))
+	}
+
 	lambda.Start(handler)
 }
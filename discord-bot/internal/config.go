@@ -2,29 +2,89 @@ package internal
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// Admission policies for a signal added while the allocation window has no slot left
+const (
+	AdmissionPolicyWaitlist = "waitlist" // Save the signal as WAITLISTED until a slot frees up
+	AdmissionPolicyReject   = "reject"   // Do not save the signal
+	AdmissionPolicyOff      = "off"      // Save the signal as PENDING regardless of capacity
 )
 
 // Config holds the application configuration
 type Config struct {
-	// DynamoDB configuration
-	DynamoDBRegion string
-	TableName      string // Unified table name
+	// Storage configuration
+	StoreBackend            string // "dynamodb" (default), "memory" or "file"
+	StoreFilePath           string // JSON file used by the file backend
+	DynamoDBRegion          string
+	TableName               string // Unified table name
+	DynamoDBEndpoint        string // Endpoint override, e.g. DynamoDB Local
+	DynamoDBAccessKeyID     string // Static credentials, used instead of the default chain when set
+	DynamoDBSecretAccessKey string
+
+	// LocalListenAddr serves interactions over plain HTTP instead of Lambda when set
+	LocalListenAddr string
 
 	// Discord configuration
 	DiscordPublicKey string
+
+	// Signal admission
+	MaxSignalsPerWindow int    // Slots of a window the trading bot has not opened yet, must match the trading bot
+	AdmissionPolicy     string // One of the AdmissionPolicy constants, "waitlist" by default
+
+	// MarketLocation is the market timezone, signal dates are its calendar dates
+	MarketLocation *time.Location
+
+	// Discord user IDs allowed to approve orders and use /tradingmode, nobody when empty
+	TradingAdminUserIDs map[string]bool
 }
 
 // LoadConfigFromEnv loads configuration from environment variables
 func LoadConfigFromEnv() (*Config, error) {
 	config := &Config{}
 
-	// DynamoDB configuration
+	// Storage configuration
+	config.StoreBackend = getEnvOrDefault("STORE_BACKEND", "dynamodb")
+	config.StoreFilePath = getEnvOrDefault("STORE_FILE_PATH", "artemis-store.json")
 	config.DynamoDBRegion = getEnvOrDefault("DYNAMODB_REGION", "us-east-1")
 	config.TableName = getEnvOrDefault("TABLE_NAME", "artemis-data")
+	config.DynamoDBEndpoint = getEnvOrDefault("DYNAMODB_ENDPOINT", "")
+	config.DynamoDBAccessKeyID = getEnvOrDefault("DYNAMODB_ACCESS_KEY_ID", "")
+	config.DynamoDBSecretAccessKey = getEnvOrDefault("DYNAMODB_SECRET_ACCESS_KEY", "")
 
 	// Discord configuration
 	config.DiscordPublicKey = getEnvOrFail("DISCORD_PUBLIC_KEY")
+	config.LocalListenAddr = getEnvOrDefault("LOCAL_LISTEN_ADDR", "")
+
+	// Signal admission
+	config.MaxSignalsPerWindow = getEnvAsIntOrDefault("MAX_SIGNALS_PER_WINDOW", 39)
+	config.AdmissionPolicy = getEnvOrDefault("SIGNAL_ADMISSION_POLICY", AdmissionPolicyWaitlist)
+	switch config.AdmissionPolicy {
+	case AdmissionPolicyWaitlist, AdmissionPolicyReject, AdmissionPolicyOff:
+	default:
+		return nil, fmt.Errorf("unknown signal admission policy: %s", config.AdmissionPolicy)
+	}
+
+	// Trading administration
+	config.TradingAdminUserIDs = make(map[string]bool)
+	for _, userID := range strings.Split(getEnvOrDefault("TRADING_ADMIN_USER_IDS", ""), ",") {
+		if userID = strings.TrimSpace(userID); userID != "" {
+			config.TradingAdminUserIDs[userID] = true
+		}
+	}
+
+	location, err := time.LoadLocation(types.MarketTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load market timezone: %w", err)
+	}
+	config.MarketLocation = location
 
 	return config, nil
 }
@@ -38,6 +98,21 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return value
 }
 
+// getEnvAsIntOrDefault gets an environment variable as int or returns a default value
+func getEnvAsIntOrDefault(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: Invalid integer value for %s: %s, using default: %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return intValue
+}
+
 // getEnvOrFail gets an environment variable or fails if not found
 func getEnvOrFail(key string) string {
 	value := os.Getenv(key)
@@ -0,0 +1,86 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// LoadAllocationWindow returns the current allocation window, or nil if none exists
+func (d *Service) LoadAllocationWindow(ctx context.Context) (*types.AllocationWindow, error) {
+	return d.loadAllocationWindow(ctx)
+}
+
+// AdmitSignal writes a signal admitted against the allocation window's capacity
+// and reserves its place by bumping the window's version, in one transaction.
+// Only the version attribute of the window item changes, its accounting is
+// left to the trading bot. A writer that counted the capacity from a stale
+// window gets store.ErrVersionConflict and neither write is applied. A nil
+// window requires that no window has been opened yet. An empty previousStatus
+// adds a new signal, otherwise the signal is transitioned from previousStatus
+// as TransitionSignal does. On success the versions of the signal and the
+// window are incremented.
+func (d *Service) AdmitSignal(ctx context.Context, signal *types.Signal, previousStatus types.SignalStatus, window *types.AllocationWindow) error {
+	if previousStatus != "" {
+		if err := signal.ValidateTransitionFrom(previousStatus); err != nil {
+			return fmt.Errorf("failed to admit signal %s: %w", signal.UUID, err)
+		}
+	}
+
+	transactItems, updated, err := d.signalWriteItems(*signal, previousStatus)
+	if err != nil {
+		return err
+	}
+
+	windowKey := map[string]dynamodbtypes.AttributeValue{
+		"pk": &dynamodbtypes.AttributeValueMemberS{Value: windowPartitionKey},
+		"sk": &dynamodbtypes.AttributeValueMemberS{Value: windowSortKey},
+	}
+	if window == nil {
+		transactItems = append(transactItems, dynamodbtypes.TransactWriteItem{
+			ConditionCheck: &dynamodbtypes.ConditionCheck{
+				TableName:           aws.String(d.tableName),
+				Key:                 windowKey,
+				ConditionExpression: aws.String("attribute_not_exists(pk)"),
+			},
+		})
+	} else {
+		condition, values := windowVersionCondition(window.Version)
+		if values == nil {
+			values = make(map[string]dynamodbtypes.AttributeValue)
+		}
+		values[":next"] = &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(window.Version+1, 10)}
+
+		transactItems = append(transactItems, dynamodbtypes.TransactWriteItem{
+			Update: &dynamodbtypes.Update{
+				TableName:                 aws.String(d.tableName),
+				Key:                       windowKey,
+				UpdateExpression:          aws.String("SET version = :next"),
+				ConditionExpression:       aws.String("attribute_exists(pk) AND " + condition),
+				ExpressionAttributeValues: values,
+			},
+		})
+	}
+
+	err = d.transactWrite(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		if isConditionFailure(err) {
+			return fmt.Errorf("%w: signal %s was not admitted, the allocation window or the signal changed", store.ErrVersionConflict, signal.UUID)
+		}
+		return fmt.Errorf("failed to admit signal %s: %w", signal.UUID, err)
+	}
+
+	signal.Version = updated.Version
+	if window != nil {
+		window.Version++
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+)
+
+const (
+	// batchWriteLimit is the maximum number of requests DynamoDB accepts per BatchWriteItem call
+	batchWriteLimit = 25
+	// maxBatchWriteAttempts bounds how often unprocessed or throttled items and
+	// throttled transactions are retried
+	maxBatchWriteAttempts = 6
+	// batchWriteBaseBackoff is the initial delay between retries, doubled on every attempt
+	batchWriteBaseBackoff = 100 * time.Millisecond
+)
+
+// batchWrite writes requests in chunks, retrying unprocessed and throttled items
+// with exponential backoff. Items that still fail are recorded in failures.
+func (d *Service) batchWrite(ctx context.Context, writeRequests []dynamodbtypes.WriteRequest, failures *store.PartialWriteError) {
+	for i := 0; i < len(writeRequests); i += batchWriteLimit {
+		end := i + batchWriteLimit
+		if end > len(writeRequests) {
+			end = len(writeRequests)
+		}
+
+		pending := writeRequests[i:end]
+		for attempt := 0; len(pending) > 0; attempt++ {
+			if attempt > 0 {
+				if attempt >= maxBatchWriteAttempts || !sleepWithBackoff(ctx, attempt) {
+					break
+				}
+			}
+
+			result, err := d.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]dynamodbtypes.WriteRequest{
+					d.tableName: pending,
+				},
+			})
+			if err != nil {
+				failures.Cause = fmt.Errorf("failed to batch write items: %w", err)
+				if isThrottlingError(err) {
+					continue
+				}
+				break
+			}
+
+			pending = result.UnprocessedItems[d.tableName]
+		}
+
+		for _, request := range pending {
+			// Signal items are keyed by their UUID
+			failures.AddSignal(writeRequestSortKey(request))
+		}
+	}
+}
+
+// sleepWithBackoff waits before the given retry attempt, returning false if the context ended
+func sleepWithBackoff(ctx context.Context, attempt int) bool {
+	backoff := batchWriteBaseBackoff << (attempt - 1)
+	jitter := time.Duration(rand.Int63n(int64(backoff) / 2))
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(backoff + jitter):
+		return true
+	}
+}
+
+// transactWrite runs a transaction, retrying it with backoff while DynamoDB
+// throttles it. A transaction cancelled for any other reason, such as a failed
+// condition, is returned at once. A throttled transaction wrote nothing, so
+// running it again is safe.
+func (d *Service) transactWrite(ctx context.Context, input *dynamodb.TransactWriteItemsInput) error {
+	var err error
+	for attempt := 0; attempt < maxBatchWriteAttempts; attempt++ {
+		if attempt > 0 && !sleepWithBackoff(ctx, attempt) {
+			break
+		}
+
+		_, err = d.client.TransactWriteItems(ctx, input)
+		if err == nil || !isThrottlingError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// isThrottlingError reports whether err is a DynamoDB throttling error worth
+// retrying, including a transaction cancelled because an item was throttled
+func isThrottlingError(err error) bool {
+	var throughputErr *dynamodbtypes.ProvisionedThroughputExceededException
+	var limitErr *dynamodbtypes.RequestLimitExceeded
+	if errors.As(err, &throughputErr) || errors.As(err, &limitErr) {
+		return true
+	}
+
+	var cancelledErr *dynamodbtypes.TransactionCanceledException
+	if errors.As(err, &cancelledErr) {
+		for _, reason := range cancelledErr.CancellationReasons {
+			if aws.ToString(reason.Code) == "ThrottlingError" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// writeRequestSortKey returns the sort key of the item a write request targets
+func writeRequestSortKey(request dynamodbtypes.WriteRequest) string {
+	var key map[string]dynamodbtypes.AttributeValue
+	if request.PutRequest != nil {
+		key = request.PutRequest.Item
+	} else if request.DeleteRequest != nil {
+		key = request.DeleteRequest.Key
+	}
+
+	if sk, ok := key["sk"].(*dynamodbtypes.AttributeValueMemberS); ok {
+		return sk.Value
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package dynamodb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestIsThrottlingError(t *testing.T) {
+	cancelled := func(codes ...string) error {
+		reasons := make([]dynamodbtypes.CancellationReason, len(codes))
+		for i, code := range codes {
+			reasons[i] = dynamodbtypes.CancellationReason{Code: aws.String(code)}
+		}
+		return &dynamodbtypes.TransactionCanceledException{CancellationReasons: reasons}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "provisioned throughput exceeded", err: &dynamodbtypes.ProvisionedThroughputExceededException{}, want: true},
+		{name: "request limit exceeded", err: &dynamodbtypes.RequestLimitExceeded{}, want: true},
+		{name: "wrapped", err: fmt.Errorf("failed to batch write items: %w", &dynamodbtypes.RequestLimitExceeded{}), want: true},
+		{name: "throttled transaction", err: cancelled("None", "ThrottlingError"), want: true},
+		{name: "failed condition", err: cancelled("ConditionalCheckFailed", "None")},
+		{name: "missing table", err: &dynamodbtypes.ResourceNotFoundException{}},
+		{name: "other error", err: errors.New("connection reset")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isThrottlingError(tt.err); got != tt.want {
+				t.Errorf("isThrottlingError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteRequestSortKey(t *testing.T) {
+	key := map[string]dynamodbtypes.AttributeValue{
+		"pk": &dynamodbtypes.AttributeValueMemberS{Value: "SIGNAL#PENDING"},
+		"sk": &dynamodbtypes.AttributeValueMemberS{Value: "signal-1"},
+	}
+
+	tests := []struct {
+		name    string
+		request dynamodbtypes.WriteRequest
+		want    string
+	}{
+		{name: "put", request: dynamodbtypes.WriteRequest{PutRequest: &dynamodbtypes.PutRequest{Item: key}}, want: "signal-1"},
+		{name: "delete", request: dynamodbtypes.WriteRequest{DeleteRequest: &dynamodbtypes.DeleteRequest{Key: key}}, want: "signal-1"},
+		{name: "empty", request: dynamodbtypes.WriteRequest{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := writeRequestSortKey(tt.request); got != tt.want {
+				t.Errorf("writeRequestSortKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
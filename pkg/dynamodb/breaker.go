@@ -0,0 +1,44 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// Primary key of the circuit breaker item
+const (
+	breakerPartitionKey = "BREAKER#TRADING"
+	breakerSortKey      = "STATE"
+)
+
+// LoadCircuitBreaker returns the circuit breaker state, or nil if it was never saved
+func (d *Service) LoadCircuitBreaker(ctx context.Context) (*types.CircuitBreaker, error) {
+	var breaker types.CircuitBreaker
+	version, found, err := d.getSingletonItem(ctx, breakerPartitionKey, breakerSortKey, &breaker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load circuit breaker: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	breaker.Version = version
+	return &breaker, nil
+}
+
+// SaveCircuitBreaker writes the circuit breaker if the stored item is still at
+// the breaker's version. Version 0 means the item must not exist yet.
+func (d *Service) SaveCircuitBreaker(ctx context.Context, breaker *types.CircuitBreaker) error {
+	updated := *breaker
+	updated.Version = breaker.Version + 1
+
+	err := d.putSingletonItem(ctx, breakerPartitionKey, breakerSortKey, types.ItemTypeBreaker, updated, breaker.Version)
+	if err != nil {
+		return fmt.Errorf("failed to save circuit breaker: %w", err)
+	}
+
+	breaker.Version = updated.Version
+	return nil
+}
@@ -0,0 +1,131 @@
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// Primary key of the trading kill switch item
+const (
+	controlPartitionKey = "CONTROL#TRADING"
+	controlSortKey      = "MODE"
+)
+
+// LoadTradingControl returns the trading kill switch, or nil if it was never set
+func (d *Service) LoadTradingControl(ctx context.Context) (*types.TradingControl, error) {
+	var control types.TradingControl
+	version, found, err := d.getSingletonItem(ctx, controlPartitionKey, controlSortKey, &control)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trading control: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	control.Version = version
+	return &control, nil
+}
+
+// SaveTradingControl writes the trading kill switch if the stored item is still
+// at the control's version
+func (d *Service) SaveTradingControl(ctx context.Context, control *types.TradingControl) error {
+	updated := *control
+	updated.Version = control.Version + 1
+
+	err := d.putSingletonItem(ctx, controlPartitionKey, controlSortKey, types.ItemTypeControl, updated, control.Version)
+	if err != nil {
+		return fmt.Errorf("failed to save trading control: %w", err)
+	}
+
+	control.Version = updated.Version
+	return nil
+}
+
+// getSingletonItem reads an item that exists at most once in the table, such as
+// a control item, into value and returns its version. Reads are strongly
+// consistent so a switch that was just flipped is seen right away.
+func (d *Service) getSingletonItem(ctx context.Context, pk, sk string, value interface{}) (int64, bool, error) {
+	result, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(d.tableName),
+		Key: map[string]dynamodbtypes.AttributeValue{
+			"pk": &dynamodbtypes.AttributeValueMemberS{Value: pk},
+			"sk": &dynamodbtypes.AttributeValueMemberS{Value: sk},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get item %s: %w", pk, err)
+	}
+	if result.Item == nil {
+		return 0, false, nil
+	}
+
+	var unifiedItem types.UnifiedItem
+	if err := attributevalue.UnmarshalMap(result.Item, &unifiedItem); err != nil {
+		return 0, false, fmt.Errorf("failed to unmarshal item %s: %w", pk, err)
+	}
+	if err := json.Unmarshal([]byte(unifiedItem.Data), value); err != nil {
+		return 0, false, fmt.Errorf("failed to unmarshal data of item %s: %w", pk, err)
+	}
+
+	return unifiedItem.Version, true, nil
+}
+
+// putSingletonItem writes value as the data of a singleton item at version
+// expected+1, provided the stored item is still at expected. Version 0 means
+// the item must not exist yet.
+func (d *Service) putSingletonItem(ctx context.Context, pk, sk string, itemType types.ItemType, value interface{}, expected int64) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal item data: %w", err)
+	}
+
+	now := time.Now()
+	unifiedItem := types.UnifiedItem{
+		PK:        pk,
+		SK:        sk,
+		Type:      itemType,
+		Data:      string(data),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Version:   expected + 1,
+	}
+
+	item, err := attributevalue.MarshalMap(unifiedItem)
+	if err != nil {
+		return fmt.Errorf("failed to marshal item: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(d.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("version = :expected"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":expected": &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(expected, 10)},
+		},
+	}
+	if expected == 0 {
+		input.ConditionExpression = aws.String("attribute_not_exists(pk)")
+		input.ExpressionAttributeValues = nil
+	}
+
+	_, err = d.client.PutItem(ctx, input)
+	if err != nil {
+		if isConditionFailure(err) {
+			return fmt.Errorf("%w: %s at version %d", store.ErrVersionConflict, pk, expected)
+		}
+		return fmt.Errorf("failed to put item %s: %w", pk, err)
+	}
+
+	return nil
+}
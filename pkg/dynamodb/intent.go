@@ -0,0 +1,100 @@
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// intentPartitionKey is the partition holding transition intents that have not been resolved yet
+const intentPartitionKey = "INTENT#OPEN"
+
+// SaveIntent records a transition intent before its order is placed
+func (d *Service) SaveIntent(ctx context.Context, intent types.TransitionIntent) error {
+	data, err := json.Marshal(intent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal intent: %w", err)
+	}
+
+	unifiedItem := types.UnifiedItem{
+		PK:        intentPartitionKey,
+		SK:        intent.SignalUUID.String(),
+		Type:      types.ItemTypeIntent,
+		Data:      string(data),
+		CreatedAt: intent.CreatedAt,
+		UpdatedAt: time.Now(),
+	}
+
+	item, err := attributevalue.MarshalMap(unifiedItem)
+	if err != nil {
+		return fmt.Errorf("failed to marshal item: %w", err)
+	}
+
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put intent: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteIntent removes the transition intent of a signal once its result has been saved
+func (d *Service) DeleteIntent(ctx context.Context, signalUUID uuid.UUID) error {
+	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(d.tableName),
+		Key: map[string]dynamodbtypes.AttributeValue{
+			"pk": &dynamodbtypes.AttributeValueMemberS{Value: intentPartitionKey},
+			"sk": &dynamodbtypes.AttributeValueMemberS{Value: signalUUID.String()},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete intent: %w", err)
+	}
+
+	return nil
+}
+
+// LoadIntents loads all unresolved transition intents
+func (d *Service) LoadIntents(ctx context.Context) ([]types.TransitionIntent, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(d.tableName),
+		KeyConditionExpression: aws.String("pk = :pk"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":pk": &dynamodbtypes.AttributeValueMemberS{Value: intentPartitionKey},
+		},
+	}
+
+	var intents []types.TransitionIntent
+	paginator := dynamodb.NewQueryPaginator(d.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query intents: %w", err)
+		}
+
+		for _, item := range page.Items {
+			var unifiedItem types.UnifiedItem
+			if err := attributevalue.UnmarshalMap(item, &unifiedItem); err != nil {
+				continue
+			}
+
+			var intent types.TransitionIntent
+			if err := json.Unmarshal([]byte(unifiedItem.Data), &intent); err == nil {
+				intents = append(intents, intent)
+			}
+		}
+	}
+
+	return intents, nil
+}
@@ -0,0 +1,132 @@
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// lockSortKey is the sort key of every lock item
+const lockSortKey = "LOCK"
+
+// lockKey returns the primary key of the named lock
+func lockKey(name string) map[string]dynamodbtypes.AttributeValue {
+	return map[string]dynamodbtypes.AttributeValue{
+		"pk": &dynamodbtypes.AttributeValueMemberS{Value: "LOCK#" + name},
+		"sk": &dynamodbtypes.AttributeValueMemberS{Value: lockSortKey},
+	}
+}
+
+// AcquireLock takes the named lock for owner with a lease of ttl. It succeeds
+// when the lock does not exist, has expired, or is already held by owner.
+func (d *Service) AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) error {
+	now := time.Now()
+	lock := types.RunLock{
+		Name:       name,
+		Owner:      owner,
+		AcquiredAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lock: %w", err)
+	}
+
+	unifiedItem := types.UnifiedItem{
+		PK:        "LOCK#" + name,
+		SK:        lockSortKey,
+		Type:      types.ItemTypeLock,
+		Data:      string(data),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	item, err := attributevalue.MarshalMap(unifiedItem)
+	if err != nil {
+		return fmt.Errorf("failed to marshal item: %w", err)
+	}
+
+	// Lease attributes live at the top level so conditions can use them
+	item["lock_owner"] = &dynamodbtypes.AttributeValueMemberS{Value: owner}
+	item["lock_expires_at"] = &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(lock.ExpiresAt.Unix(), 10)}
+
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(d.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk) OR lock_expires_at < :now OR lock_owner = :owner"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":now":   &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+			":owner": &dynamodbtypes.AttributeValueMemberS{Value: owner},
+		},
+	})
+	if err != nil {
+		var conditionErr *dynamodbtypes.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return store.ErrLockHeld
+		}
+		return fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// RenewLock extends the lease on a lock still held by owner
+func (d *Service) RenewLock(ctx context.Context, name, owner string, ttl time.Duration) error {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	_, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(d.tableName),
+		Key:                 lockKey(name),
+		UpdateExpression:    aws.String("SET lock_expires_at = :expires, updated_at = :updated"),
+		ConditionExpression: aws.String("lock_owner = :owner AND lock_expires_at >= :now"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":expires": &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt.Unix(), 10)},
+			":updated": &dynamodbtypes.AttributeValueMemberS{Value: now.Format(time.RFC3339Nano)},
+			":owner":   &dynamodbtypes.AttributeValueMemberS{Value: owner},
+			":now":     &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+	})
+	if err != nil {
+		var conditionErr *dynamodbtypes.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return store.ErrLockHeld
+		}
+		return fmt.Errorf("failed to renew lock %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// ReleaseLock deletes a lock held by owner. Releasing a lock that has been
+// taken over by another owner is a no-op.
+func (d *Service) ReleaseLock(ctx context.Context, name, owner string) error {
+	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           aws.String(d.tableName),
+		Key:                 lockKey(name),
+		ConditionExpression: aws.String("lock_owner = :owner"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":owner": &dynamodbtypes.AttributeValueMemberS{Value: owner},
+		},
+	})
+	if err != nil {
+		var conditionErr *dynamodbtypes.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return nil
+		}
+		return fmt.Errorf("failed to release lock %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// ErrSchemaVersionConflict is returned when another migration updated the
+// schema version at the same time
+var ErrSchemaVersionConflict = errors.New("schema version was changed concurrently")
+
+// Migration is a versioned change applied to the existing items of the unified table
+type Migration struct {
+	Version     int
+	Description string
+	// Apply returns the rewritten item, or nil if the item does not need to change
+	Apply func(item types.UnifiedItem) (*types.UnifiedItem, error)
+}
+
+// migrations lists every schema migration in version order. New migrations are
+// appended with the next version number and never edited once released.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "add secondary index keys to signal items",
+		Apply:       addSignalIndexKeys,
+	},
+}
+
+// LatestSchemaVersion returns the version the table has once every migration is applied
+func LatestSchemaVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// PendingMigrations returns the migrations newer than the given version
+func PendingMigrations(current int) []Migration {
+	var pending []Migration
+	for _, migration := range migrations {
+		if migration.Version > current {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// schemaKey returns the primary key of the schema version item
+func schemaKey() map[string]dynamodbtypes.AttributeValue {
+	return map[string]dynamodbtypes.AttributeValue{
+		"pk": &dynamodbtypes.AttributeValueMemberS{Value: "SCHEMA#VERSION"},
+		"sk": &dynamodbtypes.AttributeValueMemberS{Value: "CURRENT"},
+	}
+}
+
+// SchemaVersion returns the last migration version applied to the table, or 0
+func (d *Service) SchemaVersion(ctx context.Context) (int, error) {
+	result, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(d.tableName),
+		Key:       schemaKey(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	if result.Item == nil {
+		return 0, nil
+	}
+
+	var unifiedItem types.UnifiedItem
+	if err := attributevalue.UnmarshalMap(result.Item, &unifiedItem); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal schema version item: %w", err)
+	}
+
+	return int(unifiedItem.Version), nil
+}
+
+// Migrate applies every pending migration in order, recording the schema
+// version after each one so an interrupted migration resumes where it stopped.
+// Migrations must be safe to re-run over items they already rewrote.
+func (d *Service) Migrate(ctx context.Context) ([]Migration, error) {
+	current, err := d.SchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range PendingMigrations(current) {
+		log.Printf("Applying migration %d: %s", migration.Version, migration.Description)
+
+		rewritten, err := d.applyMigration(ctx, migration)
+		if err != nil {
+			return applied, fmt.Errorf("migration %d failed: %w", migration.Version, err)
+		}
+
+		err = d.setSchemaVersion(ctx, current, migration)
+		if err != nil {
+			return applied, err
+		}
+
+		log.Printf("Migration %d rewrote %d items", migration.Version, rewritten)
+		current = migration.Version
+		applied = append(applied, migration)
+	}
+
+	return applied, nil
+}
+
+// applyMigration scans the whole table and writes back every item the
+// migration changes. Each write is guarded by the item's version so concurrent
+// updates are not overwritten.
+func (d *Service) applyMigration(ctx context.Context, migration Migration) (int, error) {
+	rewritten := 0
+
+	paginator := dynamodb.NewScanPaginator(d.client, &dynamodb.ScanInput{
+		TableName: aws.String(d.tableName),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return rewritten, fmt.Errorf("failed to scan table: %w", err)
+		}
+
+		for _, rawItem := range page.Items {
+			var unifiedItem types.UnifiedItem
+			if err := attributevalue.UnmarshalMap(rawItem, &unifiedItem); err != nil {
+				return rewritten, fmt.Errorf("failed to unmarshal item: %w", err)
+			}
+
+			updated, err := migration.Apply(unifiedItem)
+			if err != nil {
+				return rewritten, fmt.Errorf("failed to migrate item %s/%s: %w", unifiedItem.PK, unifiedItem.SK, err)
+			}
+			if updated == nil {
+				continue
+			}
+
+			updatedItem, err := attributevalue.MarshalMap(updated)
+			if err != nil {
+				return rewritten, fmt.Errorf("failed to marshal item: %w", err)
+			}
+
+			// Keep attributes the unified item does not model, such as lock leases
+			for name, value := range updatedItem {
+				rawItem[name] = value
+			}
+
+			condition, values := versionCondition(unifiedItem.Version)
+			_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+				TableName:                 aws.String(d.tableName),
+				Item:                      rawItem,
+				ConditionExpression:       aws.String(condition),
+				ExpressionAttributeValues: values,
+			})
+			if err != nil {
+				if isConditionFailure(err) {
+					return rewritten, fmt.Errorf("item %s/%s changed during migration, run it again: %w", unifiedItem.PK, unifiedItem.SK, err)
+				}
+				return rewritten, fmt.Errorf("failed to write item %s/%s: %w", unifiedItem.PK, unifiedItem.SK, err)
+			}
+			rewritten++
+		}
+	}
+
+	return rewritten, nil
+}
+
+// setSchemaVersion records migration as applied, provided the stored version is still previous
+func (d *Service) setSchemaVersion(ctx context.Context, previous int, migration Migration) error {
+	now := time.Now()
+	data, err := json.Marshal(types.SchemaVersion{
+		Version:     migration.Version,
+		Description: migration.Description,
+		AppliedAt:   now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema version: %w", err)
+	}
+
+	item, err := attributevalue.MarshalMap(types.UnifiedItem{
+		PK:        "SCHEMA#VERSION",
+		SK:        "CURRENT",
+		Type:      types.ItemTypeSchema,
+		Data:      string(data),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Version:   int64(migration.Version),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal item: %w", err)
+	}
+
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(d.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(pk) OR version = :previous"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":previous": &dynamodbtypes.AttributeValueMemberN{Value: strconv.Itoa(previous)},
+		},
+	})
+	if err != nil {
+		if isConditionFailure(err) {
+			return fmt.Errorf("%w: expected version %d", ErrSchemaVersionConflict, previous)
+		}
+		return fmt.Errorf("failed to save schema version: %w", err)
+	}
+
+	return nil
+}
+
+// addSignalIndexKeys fills in the ticker, buy_date and sell_date attributes on
+// signal items written before the secondary indexes existed
+func addSignalIndexKeys(item types.UnifiedItem) (*types.UnifiedItem, error) {
+	if item.Type != types.ItemTypeSignal {
+		return nil, nil
+	}
+	if item.Ticker != "" && item.BuyDate != "" && item.SellDate != "" {
+		return nil, nil
+	}
+
+	var signal types.Signal
+	if err := json.Unmarshal([]byte(item.Data), &signal); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal signal: %w", err)
+	}
+
+	item.Ticker = signal.Ticker
+	item.BuyDate = signal.BuyDate.Format("2006-01-02")
+	item.SellDate = signal.SellDate.Format("2006-01-02")
+	return &item, nil
+}
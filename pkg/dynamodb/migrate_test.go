@@ -0,0 +1,86 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+func TestPendingMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		current int
+		want    int
+	}{
+		{name: "new table", current: 0, want: len(migrations)},
+		{name: "up to date", current: LatestSchemaVersion(), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending := PendingMigrations(tt.current)
+
+			if len(pending) != tt.want {
+				t.Fatalf("PendingMigrations(%d) = %d migrations, want %d", tt.current, len(pending), tt.want)
+			}
+			for i, migration := range pending {
+				if migration.Version <= tt.current || (i > 0 && migration.Version <= pending[i-1].Version) {
+					t.Errorf("migration %d has version %d, want versions above %d in order", i, migration.Version, tt.current)
+				}
+			}
+		})
+	}
+}
+
+func TestAddSignalIndexKeys(t *testing.T) {
+	signal := types.Signal{
+		UUID:     uuid.New(),
+		Ticker:   "AAPL",
+		BuyDate:  time.Date(2026, 11, 23, 0, 0, 0, 0, time.UTC),
+		SellDate: time.Date(2026, 12, 10, 0, 0, 0, 0, time.UTC),
+		Status:   types.SignalStatusPending,
+	}
+	data, err := json.Marshal(signal)
+	if err != nil {
+		t.Fatalf("failed to marshal signal: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		item        types.UnifiedItem
+		wantChanged bool
+		wantErr     bool
+	}{
+		{
+			name:        "signal written before the indexes",
+			item:        types.UnifiedItem{PK: "SIGNAL#PENDING", Type: types.ItemTypeSignal, Data: string(data)},
+			wantChanged: true,
+		},
+		{
+			name: "signal with index keys",
+			item: types.UnifiedItem{PK: "SIGNAL#PENDING", Type: types.ItemTypeSignal, Data: string(data),
+				Ticker: "AAPL", BuyDate: "2026-11-23", SellDate: "2026-12-10"},
+		},
+		{name: "other item", item: types.UnifiedItem{PK: "ALLOCATION#CURRENT", Type: types.ItemTypeAllocation, Data: "{}"}},
+		{name: "unreadable signal", item: types.UnifiedItem{PK: "SIGNAL#PENDING", Type: types.ItemTypeSignal, Data: "{"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addSignalIndexKeys(tt.item)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("addSignalIndexKeys() error = %v, want error %v", err, tt.wantErr)
+			}
+			if (got != nil) != tt.wantChanged {
+				t.Fatalf("addSignalIndexKeys() = %+v, want changed %v", got, tt.wantChanged)
+			}
+			if got != nil && (got.Ticker != "AAPL" || got.BuyDate != "2026-11-23" || got.SellDate != "2026-12-10") {
+				t.Errorf("index keys = %s, %s, %s, want AAPL, 2026-11-23, 2026-12-10", got.Ticker, got.BuyDate, got.SellDate)
+			}
+		})
+	}
+}
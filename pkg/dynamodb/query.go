@@ -0,0 +1,91 @@
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// Global secondary indexes on the unified table
+const (
+	// TickerIndexName is keyed by ticker (hash) and buy_date (range)
+	TickerIndexName = "ticker-index"
+	// BuyDateIndexName is keyed by buy_date (hash) and ticker (range)
+	BuyDateIndexName = "buy-date-index"
+	// SellDateIndexName is keyed by sell_date (hash) and ticker (range)
+	SellDateIndexName = "sell-date-index"
+)
+
+// QuerySignalsByTicker returns every signal for a ticker, in buy date order
+func (d *Service) QuerySignalsByTicker(ctx context.Context, ticker string) ([]types.Signal, error) {
+	return d.querySignals(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(d.tableName),
+		IndexName:              aws.String(TickerIndexName),
+		KeyConditionExpression: aws.String("ticker = :ticker"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":ticker": &dynamodbtypes.AttributeValueMemberS{Value: ticker},
+		},
+	})
+}
+
+// QuerySignalsByBuyDate returns every signal with the given buy date
+func (d *Service) QuerySignalsByBuyDate(ctx context.Context, buyDate time.Time) ([]types.Signal, error) {
+	return d.querySignals(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(d.tableName),
+		IndexName:              aws.String(BuyDateIndexName),
+		KeyConditionExpression: aws.String("buy_date = :date"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":date": &dynamodbtypes.AttributeValueMemberS{Value: buyDate.Format("2006-01-02")},
+		},
+	})
+}
+
+// QuerySignalsBySellDate returns every signal with the given sell date
+func (d *Service) QuerySignalsBySellDate(ctx context.Context, sellDate time.Time) ([]types.Signal, error) {
+	return d.querySignals(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(d.tableName),
+		IndexName:              aws.String(SellDateIndexName),
+		KeyConditionExpression: aws.String("sell_date = :date"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":date": &dynamodbtypes.AttributeValueMemberS{Value: sellDate.Format("2006-01-02")},
+		},
+	})
+}
+
+// querySignals runs a query and decodes every signal item across all result pages
+func (d *Service) querySignals(ctx context.Context, input *dynamodb.QueryInput) ([]types.Signal, error) {
+	var signals []types.Signal
+
+	paginator := dynamodb.NewQueryPaginator(d.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query signals: %w", err)
+		}
+
+		for _, item := range page.Items {
+			var unifiedItem types.UnifiedItem
+			err := attributevalue.UnmarshalMap(item, &unifiedItem)
+			if err != nil || unifiedItem.Type != types.ItemTypeSignal {
+				continue
+			}
+
+			var signal types.Signal
+			err = json.Unmarshal([]byte(unifiedItem.Data), &signal)
+			if err == nil {
+				// The item attribute is authoritative for the version
+				signal.Version = unifiedItem.Version
+				signals = append(signals, signal)
+			}
+		}
+	}
+
+	return signals, nil
+}
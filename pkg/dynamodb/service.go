@@ -4,87 +4,191 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/vignesh-goutham/artemis/pkg/store"
 	"github.com/vignesh-goutham/artemis/pkg/types"
 )
 
+// Service implements store.Store
+var _ store.Store = (*Service)(nil)
+
 // Service handles all DynamoDB operations with single table design
 type Service struct {
 	client    *dynamodb.Client
 	tableName string
 }
 
+// Options configures how the service connects to DynamoDB
+type Options struct {
+	Region    string
+	TableName string
+
+	// Endpoint overrides the DynamoDB endpoint, e.g. http://localhost:8000 for DynamoDB Local
+	Endpoint string
+
+	// AccessKeyID and SecretAccessKey replace the default credential chain when both are set
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
 // NewService creates a new DynamoDB service instance
 func NewService(region, tableName string) (*Service, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
+	return NewServiceWithOptions(Options{
+		Region:    region,
+		TableName: tableName,
+	})
+}
+
+// NewServiceWithOptions creates a new DynamoDB service instance with endpoint
+// and credential overrides
+func NewServiceWithOptions(opts Options) (*Service, error) {
+	loadOptions := []func(*config.LoadOptions) error{
+		config.WithRegion(opts.Region),
+	}
+	if opts.AccessKeyID != "" && opts.SecretAccessKey != "" {
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(opts.AccessKeyID, opts.SecretAccessKey, ""),
+		))
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.TODO(), loadOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
-	client := dynamodb.NewFromConfig(cfg)
+	client := dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		if opts.Endpoint != "" {
+			o.BaseEndpoint = aws.String(opts.Endpoint)
+		}
+	})
 
 	return &Service{
 		client:    client,
-		tableName: tableName,
+		tableName: opts.TableName,
 	}, nil
 }
 
-// LoadAllData loads all data from DynamoDB into memory
+// TableName returns the name of the table the service operates on
+func (d *Service) TableName() string {
+	return d.tableName
+}
+
+// LoadAllData loads the active signals and the current allocation window by
+// querying their partitions directly, following pagination
 func (d *Service) LoadAllData(ctx context.Context) ([]types.Signal, *types.AllocationWindow, error) {
-	// Scan the entire table
-	input := &dynamodb.ScanInput{
-		TableName: aws.String(d.tableName),
+	var signals []types.Signal
+	for _, status := range types.ActiveSignalStatuses {
+		statusSignals, err := d.querySignals(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(d.tableName),
+			KeyConditionExpression: aws.String("pk = :pk"),
+			ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+				":pk": &dynamodbtypes.AttributeValueMemberS{Value: signalPartitionKey(status)},
+			},
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load %s signals: %w", status, err)
+		}
+		signals = append(signals, statusSignals...)
 	}
 
-	result, err := d.client.Scan(ctx, input)
+	allocationWindow, err := d.loadAllocationWindow(ctx)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to scan table: %w", err)
+		return nil, nil, err
 	}
 
-	var signals []types.Signal
-	var allocationWindow *types.AllocationWindow
+	return signals, allocationWindow, nil
+}
 
-	for _, item := range result.Items {
-		var unifiedItem types.UnifiedItem
-		err := attributevalue.UnmarshalMap(item, &unifiedItem)
-		if err != nil {
-			continue
-		}
+// loadAllocationWindow loads the current allocation window, returning nil if none exists
+func (d *Service) loadAllocationWindow(ctx context.Context) (*types.AllocationWindow, error) {
+	result, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(d.tableName),
+		Key: map[string]dynamodbtypes.AttributeValue{
+			"pk": &dynamodbtypes.AttributeValueMemberS{Value: windowPartitionKey},
+			"sk": &dynamodbtypes.AttributeValueMemberS{Value: windowSortKey},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allocation window: %w", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
 
-		switch unifiedItem.Type {
-		case types.ItemTypeSignal:
-			var signal types.Signal
-			err := json.Unmarshal([]byte(unifiedItem.Data), &signal)
-			if err == nil {
-				signals = append(signals, signal)
-			}
-		case types.ItemTypeAllocation:
-			var window types.AllocationWindow
-			err := json.Unmarshal([]byte(unifiedItem.Data), &window)
-			if err == nil {
-				allocationWindow = &window
-			}
-		}
+	var unifiedItem types.UnifiedItem
+	if err := attributevalue.UnmarshalMap(result.Item, &unifiedItem); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal allocation window item: %w", err)
 	}
 
-	return signals, allocationWindow, nil
+	var window types.AllocationWindow
+	if err := json.Unmarshal([]byte(unifiedItem.Data), &window); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal allocation window: %w", err)
+	}
+	window.Version = unifiedItem.Version
+
+	return &window, nil
+}
+
+// signalPartitionKey returns the partition key holding signals in the given status
+func signalPartitionKey(status types.SignalStatus) string {
+	return "SIGNAL#" + string(status)
+}
+
+// newSignalItem builds the unified table item for a signal, including its secondary index keys
+func newSignalItem(signal types.Signal) (map[string]dynamodbtypes.AttributeValue, error) {
+	if !signal.Status.IsValid() {
+		return nil, fmt.Errorf("signal %s has unknown status %q", signal.UUID, signal.Status)
+	}
+
+	data, err := json.Marshal(signal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal signal: %w", err)
+	}
+
+	unifiedItem := types.UnifiedItem{
+		PK:        signalPartitionKey(signal.Status),
+		SK:        signal.UUID.String(),
+		Type:      types.ItemTypeSignal,
+		Data:      string(data),
+		CreatedAt: signal.CreatedAt,
+		UpdatedAt: signal.UpdatedAt,
+		Version:   signal.Version,
+		Ticker:    signal.Ticker,
+		BuyDate:   signal.BuyDate.Format("2006-01-02"),
+		SellDate:  signal.SellDate.Format("2006-01-02"),
+	}
+
+	item, err := attributevalue.MarshalMap(unifiedItem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal item: %w", err)
+	}
+
+	return item, nil
 }
 
-// SaveAllData saves all data back to DynamoDB in one batch operation
+// SaveAllData writes signals and deletes signalsToDelete in batch operations,
+// then saves the allocation window with the version check of
+// SaveAllocationWindow. Unprocessed and throttled items are retried with
+// backoff; anything that still cannot be written is reported through a
+// *store.PartialWriteError.
 func (d *Service) SaveAllData(ctx context.Context, signals []types.Signal, signalsToDelete []types.Signal, allocationWindow *types.AllocationWindow) error {
 	var writeRequests []dynamodbtypes.WriteRequest
+	failures := &store.PartialWriteError{}
 
 	// Add delete requests for signals that need to be removed
 	for _, signal := range signalsToDelete {
 		writeRequests = append(writeRequests, dynamodbtypes.WriteRequest{
 			DeleteRequest: &dynamodbtypes.DeleteRequest{
 				Key: map[string]dynamodbtypes.AttributeValue{
-					"pk": &dynamodbtypes.AttributeValueMemberS{Value: "SIGNAL#" + string(signal.Status)},
+					"pk": &dynamodbtypes.AttributeValueMemberS{Value: signalPartitionKey(signal.Status)},
 					"sk": &dynamodbtypes.AttributeValueMemberS{Value: signal.UUID.String()},
 				},
 			},
@@ -93,22 +197,10 @@ func (d *Service) SaveAllData(ctx context.Context, signals []types.Signal, signa
 
 	// Add signals
 	for _, signal := range signals {
-		data, err := json.Marshal(signal)
-		if err != nil {
-			continue
-		}
-
-		unifiedItem := types.UnifiedItem{
-			PK:        "SIGNAL#" + string(signal.Status),
-			SK:        signal.UUID.String(),
-			Type:      types.ItemTypeSignal,
-			Data:      string(data),
-			CreatedAt: signal.CreatedAt,
-			UpdatedAt: signal.UpdatedAt,
-		}
-
-		item, err := attributevalue.MarshalMap(unifiedItem)
+		item, err := newSignalItem(signal)
 		if err != nil {
+			failures.AddSignal(signal.UUID.String())
+			failures.Cause = err
 			continue
 		}
 
@@ -119,74 +211,105 @@ func (d *Service) SaveAllData(ctx context.Context, signals []types.Signal, signa
 		})
 	}
 
-	// Add allocation window
+	// Batch write in chunks of 25 (DynamoDB limit), retrying unprocessed items
+	d.batchWrite(ctx, writeRequests, failures)
+
+	// The window is versioned, which a batch write cannot check
 	if allocationWindow != nil {
-		data, err := json.Marshal(allocationWindow)
-		if err == nil {
-			unifiedItem := types.UnifiedItem{
-				PK:        "ALLOCATION#CURRENT",
-				SK:        "WINDOW",
-				Type:      types.ItemTypeAllocation,
-				Data:      string(data),
-				CreatedAt: allocationWindow.UpdatedAt,
-				UpdatedAt: allocationWindow.UpdatedAt,
-			}
-
-			item, err := attributevalue.MarshalMap(unifiedItem)
-			if err == nil {
-				writeRequests = append(writeRequests, dynamodbtypes.WriteRequest{
-					PutRequest: &dynamodbtypes.PutRequest{
-						Item: item,
-					},
-				})
-			}
+		if err := d.SaveAllocationWindow(ctx, allocationWindow); err != nil {
+			failures.AllocationWindowFailed = true
+			failures.Cause = err
 		}
 	}
 
-	// Batch write in chunks of 25 (DynamoDB limit)
-	for i := 0; i < len(writeRequests); i += 25 {
-		end := i + 25
-		if end > len(writeRequests) {
-			end = len(writeRequests)
-		}
-
-		batchInput := &dynamodb.BatchWriteItemInput{
-			RequestItems: map[string][]dynamodbtypes.WriteRequest{
-				d.tableName: writeRequests[i:end],
-			},
-		}
-
-		_, err := d.client.BatchWriteItem(ctx, batchInput)
-		if err != nil {
-			return fmt.Errorf("failed to batch write items: %w", err)
-		}
+	if failures.HasFailures() {
+		return failures
 	}
 
 	return nil
 }
 
-// SaveSignal saves a single signal to DynamoDB
-func (d *Service) SaveSignal(ctx context.Context, signal types.Signal) error {
-	// Marshal the signal to JSON
-	data, err := json.Marshal(signal)
+// Primary key of the current allocation window item
+const (
+	windowPartitionKey = "ALLOCATION#CURRENT"
+	windowSortKey      = "WINDOW"
+)
+
+// newAllocationWindowItem builds the unified table item for the current
+// allocation window at the window's version
+func newAllocationWindowItem(allocationWindow *types.AllocationWindow) (map[string]dynamodbtypes.AttributeValue, error) {
+	data, err := json.Marshal(allocationWindow)
 	if err != nil {
-		return fmt.Errorf("failed to marshal signal: %w", err)
+		return nil, fmt.Errorf("failed to marshal allocation window: %w", err)
 	}
 
-	// Create the unified item
 	unifiedItem := types.UnifiedItem{
-		PK:        "SIGNAL#" + string(signal.Status),
-		SK:        signal.UUID.String(),
-		Type:      types.ItemTypeSignal,
+		PK:        windowPartitionKey,
+		SK:        windowSortKey,
+		Type:      types.ItemTypeAllocation,
 		Data:      string(data),
-		CreatedAt: signal.CreatedAt,
-		UpdatedAt: signal.UpdatedAt,
+		CreatedAt: allocationWindow.UpdatedAt,
+		UpdatedAt: allocationWindow.UpdatedAt,
+		Version:   allocationWindow.Version,
 	}
 
-	// Marshal to DynamoDB format
 	item, err := attributevalue.MarshalMap(unifiedItem)
 	if err != nil {
-		return fmt.Errorf("failed to marshal item: %w", err)
+		return nil, fmt.Errorf("failed to marshal item: %w", err)
+	}
+
+	return item, nil
+}
+
+// windowVersionCondition returns a condition expression requiring the current
+// allocation window to still be at the expected version. Version 0 matches no
+// window at all as well as a window written before windows were versioned.
+func windowVersionCondition(expected int64) (string, map[string]dynamodbtypes.AttributeValue) {
+	if expected == 0 {
+		return "attribute_not_exists(version)", nil
+	}
+	return "version = :expected", map[string]dynamodbtypes.AttributeValue{
+		":expected": &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(expected, 10)},
+	}
+}
+
+// SaveAllocationWindow writes the current allocation window if the stored one
+// is still at the window's version, failing with store.ErrVersionConflict
+// otherwise. On success the window's version is incremented.
+func (d *Service) SaveAllocationWindow(ctx context.Context, window *types.AllocationWindow) error {
+	expected := window.Version
+	updated := *window
+	updated.Version = expected + 1
+
+	item, err := newAllocationWindowItem(&updated)
+	if err != nil {
+		return err
+	}
+
+	condition, values := windowVersionCondition(expected)
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(d.tableName),
+		Item:                      item,
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		if isConditionFailure(err) {
+			return fmt.Errorf("%w: allocation window %s at version %d", store.ErrVersionConflict, window.ID(), expected)
+		}
+		return fmt.Errorf("failed to save allocation window %s: %w", window.ID(), err)
+	}
+
+	window.Version = updated.Version
+	return nil
+}
+
+// SaveSignal saves a single signal to DynamoDB
+func (d *Service) SaveSignal(ctx context.Context, signal types.Signal) error {
+	// Build the unified item in DynamoDB format
+	item, err := newSignalItem(signal)
+	if err != nil {
+		return err
 	}
 
 	// Put the item directly to DynamoDB
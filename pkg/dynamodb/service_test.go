@@ -0,0 +1,67 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+func TestNewSignalItem(t *testing.T) {
+	signal := types.Signal{
+		UUID:     uuid.New(),
+		Ticker:   "AAPL",
+		BuyDate:  time.Date(2026, 11, 23, 0, 0, 0, 0, time.UTC),
+		SellDate: time.Date(2026, 12, 10, 0, 0, 0, 0, time.UTC),
+		Version:  3,
+	}
+
+	tests := []struct {
+		name    string
+		status  types.SignalStatus
+		wantPK  string
+		wantErr bool
+	}{
+		{name: "pending", status: types.SignalStatusPending, wantPK: "SIGNAL#PENDING"},
+		{name: "bought", status: types.SignalStatusBought, wantPK: "SIGNAL#BOUGHT"},
+		{name: "unknown status", status: "DONE", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal := signal
+			signal.Status = tt.status
+
+			attributes, err := newSignalItem(signal)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("newSignalItem() = %v, want an error", attributes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newSignalItem() error = %v", err)
+			}
+
+			var item types.UnifiedItem
+			if err := attributevalue.UnmarshalMap(attributes, &item); err != nil {
+				t.Fatalf("failed to unmarshal item: %v", err)
+			}
+			if item.PK != tt.wantPK || item.SK != signal.UUID.String() || item.Version != 3 {
+				t.Errorf("item key = %s/%s at version %d, want %s/%s at version 3", item.PK, item.SK, item.Version, tt.wantPK, signal.UUID)
+			}
+			if item.Ticker != "AAPL" || item.BuyDate != "2026-11-23" || item.SellDate != "2026-12-10" {
+				t.Errorf("index keys = %s, %s, %s, want AAPL, 2026-11-23, 2026-12-10", item.Ticker, item.BuyDate, item.SellDate)
+			}
+
+			var stored types.Signal
+			if err := json.Unmarshal([]byte(item.Data), &stored); err != nil || stored.UUID != signal.UUID {
+				t.Errorf("item data = %s (%v), want the signal", item.Data, err)
+			}
+		})
+	}
+}
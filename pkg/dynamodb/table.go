@@ -0,0 +1,225 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// TTLAttributeName is the attribute DynamoDB uses to expire items
+	TTLAttributeName = "expires_at"
+
+	// tableReadyTimeout bounds how long bootstrap waits for the table and its indexes
+	tableReadyTimeout = 10 * time.Minute
+	// indexPollInterval is how often index status is checked while an index is built
+	indexPollInterval = 5 * time.Second
+)
+
+// indexDefinition describes a global secondary index on the unified table
+type indexDefinition struct {
+	name     string
+	hashKey  string
+	rangeKey string
+}
+
+// tableIndexes lists the global secondary indexes the unified table must have
+var tableIndexes = []indexDefinition{
+	{name: TickerIndexName, hashKey: "ticker", rangeKey: "buy_date"},
+	{name: BuyDateIndexName, hashKey: "buy_date", rangeKey: "ticker"},
+	{name: SellDateIndexName, hashKey: "sell_date", rangeKey: "ticker"},
+}
+
+// keySchema returns the key schema for a hash and range attribute
+func keySchema(hashKey, rangeKey string) []dynamodbtypes.KeySchemaElement {
+	return []dynamodbtypes.KeySchemaElement{
+		{AttributeName: aws.String(hashKey), KeyType: dynamodbtypes.KeyTypeHash},
+		{AttributeName: aws.String(rangeKey), KeyType: dynamodbtypes.KeyTypeRange},
+	}
+}
+
+// attributeDefinitions returns the string attribute definitions for every key attribute
+func attributeDefinitions() []dynamodbtypes.AttributeDefinition {
+	names := []string{"pk", "sk", "ticker", "buy_date", "sell_date"}
+
+	definitions := make([]dynamodbtypes.AttributeDefinition, 0, len(names))
+	for _, name := range names {
+		definitions = append(definitions, dynamodbtypes.AttributeDefinition{
+			AttributeName: aws.String(name),
+			AttributeType: dynamodbtypes.ScalarAttributeTypeS,
+		})
+	}
+	return definitions
+}
+
+// EnsureTable creates the unified table with on-demand billing if it does not
+// exist, adds any missing global secondary indexes and enables TTL. It is safe
+// to run against a table that is already fully set up.
+func (d *Service) EnsureTable(ctx context.Context) error {
+	created, err := d.createTable(ctx)
+	if err != nil {
+		return err
+	}
+	if created {
+		log.Printf("Created table %s", d.tableName)
+	}
+
+	err = dynamodb.NewTableExistsWaiter(d.client).Wait(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(d.tableName),
+	}, tableReadyTimeout)
+	if err != nil {
+		return fmt.Errorf("failed waiting for table %s: %w", d.tableName, err)
+	}
+
+	if err := d.ensureIndexes(ctx); err != nil {
+		return err
+	}
+
+	return d.ensureTTL(ctx)
+}
+
+// createTable creates the unified table, reporting false if it already exists
+func (d *Service) createTable(ctx context.Context) (bool, error) {
+	var indexes []dynamodbtypes.GlobalSecondaryIndex
+	for _, index := range tableIndexes {
+		indexes = append(indexes, dynamodbtypes.GlobalSecondaryIndex{
+			IndexName:  aws.String(index.name),
+			KeySchema:  keySchema(index.hashKey, index.rangeKey),
+			Projection: &dynamodbtypes.Projection{ProjectionType: dynamodbtypes.ProjectionTypeAll},
+		})
+	}
+
+	_, err := d.client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:              aws.String(d.tableName),
+		AttributeDefinitions:   attributeDefinitions(),
+		KeySchema:              keySchema("pk", "sk"),
+		GlobalSecondaryIndexes: indexes,
+		BillingMode:            dynamodbtypes.BillingModePayPerRequest,
+	})
+	if err != nil {
+		var inUseErr *dynamodbtypes.ResourceInUseException
+		if errors.As(err, &inUseErr) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create table %s: %w", d.tableName, err)
+	}
+
+	return true, nil
+}
+
+// ensureIndexes adds the global secondary indexes missing from an existing
+// table. DynamoDB builds one index at a time, so each is waited for in turn.
+func (d *Service) ensureIndexes(ctx context.Context) error {
+	for _, index := range tableIndexes {
+		status, err := d.indexStatus(ctx, index.name)
+		if err != nil {
+			return err
+		}
+
+		if status == "" {
+			log.Printf("Adding index %s to table %s", index.name, d.tableName)
+			_, err = d.client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+				TableName:            aws.String(d.tableName),
+				AttributeDefinitions: attributeDefinitions(),
+				GlobalSecondaryIndexUpdates: []dynamodbtypes.GlobalSecondaryIndexUpdate{
+					{
+						Create: &dynamodbtypes.CreateGlobalSecondaryIndexAction{
+							IndexName:  aws.String(index.name),
+							KeySchema:  keySchema(index.hashKey, index.rangeKey),
+							Projection: &dynamodbtypes.Projection{ProjectionType: dynamodbtypes.ProjectionTypeAll},
+						},
+					},
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to add index %s: %w", index.name, err)
+			}
+		}
+
+		if err := d.waitForIndex(ctx, index.name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexStatus returns the status of a global secondary index, or an empty
+// status if the table has no such index
+func (d *Service) indexStatus(ctx context.Context, indexName string) (dynamodbtypes.IndexStatus, error) {
+	result, err := d.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(d.tableName),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe table %s: %w", d.tableName, err)
+	}
+
+	for _, index := range result.Table.GlobalSecondaryIndexes {
+		if aws.ToString(index.IndexName) == indexName {
+			return index.IndexStatus, nil
+		}
+	}
+	return "", nil
+}
+
+// waitForIndex polls until the index is active
+func (d *Service) waitForIndex(ctx context.Context, indexName string) error {
+	deadline := time.Now().Add(tableReadyTimeout)
+
+	for {
+		status, err := d.indexStatus(ctx, indexName)
+		if err != nil {
+			return err
+		}
+		if status == dynamodbtypes.IndexStatusActive {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("index %s is still %s after %s", indexName, status, tableReadyTimeout)
+		}
+
+		log.Printf("Waiting for index %s (%s)", indexName, status)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(indexPollInterval):
+		}
+	}
+}
+
+// ensureTTL enables expiry on TTLAttributeName unless it is already enabled
+func (d *Service) ensureTTL(ctx context.Context) error {
+	result, err := d.client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: aws.String(d.tableName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe TTL on %s: %w", d.tableName, err)
+	}
+
+	if description := result.TimeToLiveDescription; description != nil {
+		switch description.TimeToLiveStatus {
+		case dynamodbtypes.TimeToLiveStatusEnabled, dynamodbtypes.TimeToLiveStatusEnabling:
+			return nil
+		}
+	}
+
+	_, err = d.client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(d.tableName),
+		TimeToLiveSpecification: &dynamodbtypes.TimeToLiveSpecification{
+			AttributeName: aws.String(TTLAttributeName),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enable TTL on %s: %w", d.tableName, err)
+	}
+
+	log.Printf("Enabled TTL on %s.%s", d.tableName, TTLAttributeName)
+	return nil
+}
@@ -0,0 +1,134 @@
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// tradeHistoryPartitionKey is the partition holding the trade history
+const tradeHistoryPartitionKey = "TRADE#HISTORY"
+
+// tradeSortKey orders trades by the date they were sold
+func tradeSortKey(trade types.TradeRecord) string {
+	return trade.SoldAt.UTC().Format("2006-01-02") + "#" + trade.SignalUUID.String()
+}
+
+// newTradeItem builds the unified table item for a trade record
+func newTradeItem(trade types.TradeRecord) (map[string]dynamodbtypes.AttributeValue, error) {
+	data, err := json.Marshal(trade)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal trade record: %w", err)
+	}
+
+	unifiedItem := types.UnifiedItem{
+		PK:        tradeHistoryPartitionKey,
+		SK:        tradeSortKey(trade),
+		Type:      types.ItemTypeTrade,
+		Data:      string(data),
+		CreatedAt: trade.CreatedAt,
+		UpdatedAt: trade.CreatedAt,
+	}
+	if trade.ExpiresAt != nil {
+		unifiedItem.ExpiresAt = trade.ExpiresAt.Unix()
+	}
+
+	item, err := attributevalue.MarshalMap(unifiedItem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal item: %w", err)
+	}
+
+	return item, nil
+}
+
+// ArchiveSignal deletes a completed signal stored under previousStatus and
+// writes its trade record in a single transaction, so a trade is never lost
+// nor archived twice
+func (d *Service) ArchiveSignal(ctx context.Context, signal types.Signal, previousStatus types.SignalStatus, trade types.TradeRecord) error {
+	if signal.Status != types.SignalStatusCompleted {
+		return fmt.Errorf("failed to archive signal %s: status is %s, not %s", signal.UUID, signal.Status, types.SignalStatusCompleted)
+	}
+	if err := signal.ValidateTransitionFrom(previousStatus); err != nil {
+		return fmt.Errorf("failed to archive signal %s: %w", signal.UUID, err)
+	}
+
+	item, err := newTradeItem(trade)
+	if err != nil {
+		return err
+	}
+
+	condition, values := versionCondition(signal.Version)
+
+	err = d.transactWrite(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []dynamodbtypes.TransactWriteItem{
+			{
+				Delete: &dynamodbtypes.Delete{
+					TableName:                 aws.String(d.tableName),
+					Key:                       signalKey(signal, previousStatus),
+					ConditionExpression:       aws.String(condition),
+					ExpressionAttributeValues: values,
+				},
+			},
+			{
+				Put: &dynamodbtypes.Put{
+					TableName:           aws.String(d.tableName),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(pk)"),
+				},
+			},
+		},
+	})
+	if err != nil {
+		if isConditionFailure(err) {
+			return fmt.Errorf("%w: signal %s at version %d", store.ErrVersionConflict, signal.UUID, signal.Version)
+		}
+		return fmt.Errorf("failed to archive signal %s: %w", signal.UUID, err)
+	}
+
+	return nil
+}
+
+// LoadTradeHistory returns the trades sold between from and to (inclusive dates), oldest first
+func (d *Service) LoadTradeHistory(ctx context.Context, from, to time.Time) ([]types.TradeRecord, error) {
+	var trades []types.TradeRecord
+
+	paginator := dynamodb.NewQueryPaginator(d.client, &dynamodb.QueryInput{
+		TableName:              aws.String(d.tableName),
+		KeyConditionExpression: aws.String("pk = :pk AND sk BETWEEN :from AND :to"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":pk":   &dynamodbtypes.AttributeValueMemberS{Value: tradeHistoryPartitionKey},
+			":from": &dynamodbtypes.AttributeValueMemberS{Value: from.UTC().Format("2006-01-02")},
+			// "~" sorts after every UUID character, so the whole end date is included
+			":to": &dynamodbtypes.AttributeValueMemberS{Value: to.UTC().Format("2006-01-02") + "#~"},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query trade history: %w", err)
+		}
+
+		for _, item := range page.Items {
+			var unifiedItem types.UnifiedItem
+			if err := attributevalue.UnmarshalMap(item, &unifiedItem); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal trade item: %w", err)
+			}
+
+			var trade types.TradeRecord
+			if err := json.Unmarshal([]byte(unifiedItem.Data), &trade); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal trade record: %w", err)
+			}
+			trades = append(trades, trade)
+		}
+	}
+
+	return trades, nil
+}
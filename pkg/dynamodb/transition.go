@@ -0,0 +1,197 @@
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// signalKey returns the primary key of a signal stored under the given status
+func signalKey(signal types.Signal, status types.SignalStatus) map[string]dynamodbtypes.AttributeValue {
+	return map[string]dynamodbtypes.AttributeValue{
+		"pk": &dynamodbtypes.AttributeValueMemberS{Value: signalPartitionKey(status)},
+		"sk": &dynamodbtypes.AttributeValueMemberS{Value: signal.UUID.String()},
+	}
+}
+
+// versionCondition returns a condition expression requiring the stored item to
+// exist at the expected version. Items written before versioning was
+// introduced have no version attribute and match version 0.
+func versionCondition(expected int64) (string, map[string]dynamodbtypes.AttributeValue) {
+	values := map[string]dynamodbtypes.AttributeValue{
+		":expected": &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(expected, 10)},
+	}
+
+	if expected == 0 {
+		return "attribute_exists(pk) AND (attribute_not_exists(version) OR version = :expected)", values
+	}
+	return "version = :expected", values
+}
+
+// TransitionSignal moves a signal from previousStatus to its current status in
+// a single transaction: the record under the old status is deleted and the new
+// record is put. Both writes are guarded by the signal's version, so a writer
+// holding a stale copy gets store.ErrVersionConflict instead of clobbering newer
+// data. Status changes the state machine does not allow are rejected. On
+// success the signal's version is incremented.
+func (d *Service) TransitionSignal(ctx context.Context, signal *types.Signal, previousStatus types.SignalStatus) error {
+	if err := signal.ValidateTransitionFrom(previousStatus); err != nil {
+		return fmt.Errorf("failed to transition signal %s: %w", signal.UUID, err)
+	}
+
+	transactItems, updated, err := d.signalWriteItems(*signal, previousStatus)
+	if err != nil {
+		return err
+	}
+
+	err = d.transactWrite(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		if isConditionFailure(err) {
+			return fmt.Errorf("%w: signal %s at version %d", store.ErrVersionConflict, signal.UUID, signal.Version)
+		}
+		return fmt.Errorf("failed to transition signal %s: %w", signal.UUID, err)
+	}
+
+	signal.Version = updated.Version
+	return nil
+}
+
+// signalWriteItems returns the transaction writes that store a signal held
+// under previousStatus at its version under its current status at the next
+// version. An empty previousStatus writes a new signal that must not exist yet.
+func (d *Service) signalWriteItems(signal types.Signal, previousStatus types.SignalStatus) ([]dynamodbtypes.TransactWriteItem, types.Signal, error) {
+	expected := signal.Version
+	updated := signal
+	updated.Version = expected + 1
+
+	item, err := newSignalItem(updated)
+	if err != nil {
+		return nil, updated, err
+	}
+
+	if previousStatus == "" {
+		return []dynamodbtypes.TransactWriteItem{{
+			Put: &dynamodbtypes.Put{
+				TableName:           aws.String(d.tableName),
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(pk)"),
+			},
+		}}, updated, nil
+	}
+
+	condition, values := versionCondition(expected)
+
+	if previousStatus == signal.Status {
+		// Same partition, overwrite in place if nobody else has
+		return []dynamodbtypes.TransactWriteItem{{
+			Put: &dynamodbtypes.Put{
+				TableName:                 aws.String(d.tableName),
+				Item:                      item,
+				ConditionExpression:       aws.String(condition),
+				ExpressionAttributeValues: values,
+			},
+		}}, updated, nil
+	}
+
+	return []dynamodbtypes.TransactWriteItem{
+		{
+			Delete: &dynamodbtypes.Delete{
+				TableName:                 aws.String(d.tableName),
+				Key:                       signalKey(signal, previousStatus),
+				ConditionExpression:       aws.String(condition),
+				ExpressionAttributeValues: values,
+			},
+		},
+		{
+			Put: &dynamodbtypes.Put{
+				TableName:           aws.String(d.tableName),
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(pk)"),
+			},
+		},
+	}, updated, nil
+}
+
+// DeleteSignal deletes the record of a signal stored under the given status,
+// provided it is still at the signal's version
+func (d *Service) DeleteSignal(ctx context.Context, signal types.Signal, status types.SignalStatus) error {
+	condition, values := versionCondition(signal.Version)
+
+	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(d.tableName),
+		Key:                       signalKey(signal, status),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		if isConditionFailure(err) {
+			return fmt.Errorf("%w: signal %s at version %d", store.ErrVersionConflict, signal.UUID, signal.Version)
+		}
+		return fmt.Errorf("failed to delete item: %w", err)
+	}
+
+	return nil
+}
+
+// GetSignal returns the signal with the given UUID in any status, or nil if it does not exist
+func (d *Service) GetSignal(ctx context.Context, signalUUID uuid.UUID) (*types.Signal, error) {
+	for _, status := range types.AllSignalStatuses {
+		result, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName: aws.String(d.tableName),
+			Key:       signalKey(types.Signal{UUID: signalUUID}, status),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get signal %s: %w", signalUUID, err)
+		}
+		if result.Item == nil {
+			continue
+		}
+
+		var unifiedItem types.UnifiedItem
+		if err := attributevalue.UnmarshalMap(result.Item, &unifiedItem); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal signal item: %w", err)
+		}
+
+		var signal types.Signal
+		if err := json.Unmarshal([]byte(unifiedItem.Data), &signal); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal signal: %w", err)
+		}
+		signal.Version = unifiedItem.Version
+
+		return &signal, nil
+	}
+
+	return nil, nil
+}
+
+// isConditionFailure reports whether err was caused by a failed condition check,
+// either on a single write or within a cancelled transaction
+func isConditionFailure(err error) bool {
+	var conditionErr *dynamodbtypes.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return true
+	}
+
+	var cancelledErr *dynamodbtypes.TransactionCanceledException
+	if errors.As(err, &cancelledErr) {
+		for _, reason := range cancelledErr.CancellationReasons {
+			if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,117 @@
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// windowHistoryPartitionKey is the partition holding the closed allocation
+// windows, sorted by their start date
+const windowHistoryPartitionKey = "ALLOCATION#HISTORY"
+
+// ArchiveAllocationWindow writes a closed allocation window to the window
+// history and replaces it with window as the current allocation window, in a
+// single transaction. The replacement is only written if the stored current
+// window is still at the closed window's version, so a run holding a stale
+// window gets store.ErrVersionConflict and neither write is applied. Archiving
+// the same window again replaces the earlier copy in the history. On success
+// window's version is the closed window's version plus one.
+func (d *Service) ArchiveAllocationWindow(ctx context.Context, closed types.AllocationWindow, window *types.AllocationWindow) error {
+	data, err := json.Marshal(closed)
+	if err != nil {
+		return fmt.Errorf("failed to marshal allocation window: %w", err)
+	}
+
+	unifiedItem := types.UnifiedItem{
+		PK:        windowHistoryPartitionKey,
+		SK:        closed.ID(),
+		Type:      types.ItemTypeAllocation,
+		Data:      string(data),
+		CreatedAt: closed.WindowStartDate,
+		UpdatedAt: closed.UpdatedAt,
+	}
+
+	historyItem, err := attributevalue.MarshalMap(unifiedItem)
+	if err != nil {
+		return fmt.Errorf("failed to marshal item: %w", err)
+	}
+
+	updated := *window
+	updated.Version = closed.Version + 1
+
+	currentItem, err := newAllocationWindowItem(&updated)
+	if err != nil {
+		return err
+	}
+
+	condition, values := windowVersionCondition(closed.Version)
+	err = d.transactWrite(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []dynamodbtypes.TransactWriteItem{
+			{
+				Put: &dynamodbtypes.Put{
+					TableName: aws.String(d.tableName),
+					Item:      historyItem,
+				},
+			},
+			{
+				Put: &dynamodbtypes.Put{
+					TableName:                 aws.String(d.tableName),
+					Item:                      currentItem,
+					ConditionExpression:       aws.String(condition),
+					ExpressionAttributeValues: values,
+				},
+			},
+		},
+	})
+	if err != nil {
+		if isConditionFailure(err) {
+			return fmt.Errorf("%w: allocation window %s at version %d", store.ErrVersionConflict, closed.ID(), closed.Version)
+		}
+		return fmt.Errorf("failed to archive allocation window %s: %w", closed.ID(), err)
+	}
+
+	window.Version = updated.Version
+	return nil
+}
+
+// LoadAllocationWindowHistory returns the closed allocation windows, oldest first
+func (d *Service) LoadAllocationWindowHistory(ctx context.Context) ([]types.AllocationWindow, error) {
+	var windows []types.AllocationWindow
+
+	paginator := dynamodb.NewQueryPaginator(d.client, &dynamodb.QueryInput{
+		TableName:              aws.String(d.tableName),
+		KeyConditionExpression: aws.String("pk = :pk"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":pk": &dynamodbtypes.AttributeValueMemberS{Value: windowHistoryPartitionKey},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query allocation window history: %w", err)
+		}
+
+		for _, item := range page.Items {
+			var unifiedItem types.UnifiedItem
+			if err := attributevalue.UnmarshalMap(item, &unifiedItem); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal allocation window item: %w", err)
+			}
+
+			var window types.AllocationWindow
+			if err := json.Unmarshal([]byte(unifiedItem.Data), &window); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal allocation window: %w", err)
+			}
+			windows = append(windows, window)
+		}
+	}
+
+	return windows, nil
+}
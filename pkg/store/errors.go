@@ -0,0 +1,61 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrLockHeld is returned when a lock is currently held by another owner
+var ErrLockHeld = errors.New("lock is held by another owner")
+
+// ErrVersionConflict is returned when a signal or a control item was changed
+// by another writer since it was loaded
+var ErrVersionConflict = errors.New("item was modified concurrently")
+
+// PartialWriteError is returned when some items of a batch write could not be
+// written after all retries
+type PartialWriteError struct {
+	// FailedSignals holds the UUIDs of signals whose put or delete was not written
+	FailedSignals []string
+	// AllocationWindowFailed is set when the allocation window was not written
+	AllocationWindowFailed bool
+	// Cause is the last error seen while writing, if any
+	Cause error
+}
+
+// Error implements the error interface
+func (e *PartialWriteError) Error() string {
+	var parts []string
+	if len(e.FailedSignals) > 0 {
+		parts = append(parts, fmt.Sprintf("%d signals not written: %s", len(e.FailedSignals), strings.Join(e.FailedSignals, ", ")))
+	}
+	if e.AllocationWindowFailed {
+		parts = append(parts, "allocation window not written")
+	}
+	message := "partial batch write failure: " + strings.Join(parts, "; ")
+	if e.Cause != nil {
+		message += ": " + e.Cause.Error()
+	}
+	return message
+}
+
+// Unwrap returns the underlying cause
+func (e *PartialWriteError) Unwrap() error {
+	return e.Cause
+}
+
+// AddSignal records a signal as not written
+func (e *PartialWriteError) AddSignal(signalUUID string) {
+	for _, failed := range e.FailedSignals {
+		if failed == signalUUID {
+			return
+		}
+	}
+	e.FailedSignals = append(e.FailedSignals, signalUUID)
+}
+
+// HasFailures reports whether anything failed to be written
+func (e *PartialWriteError) HasFailures() bool {
+	return len(e.FailedSignals) > 0 || e.AllocationWindowFailed
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPartialWriteError(t *testing.T) {
+	cause := errors.New("throttled")
+
+	tests := []struct {
+		name         string
+		signals      []string
+		windowFailed bool
+		wantFailures bool
+		wantMessage  string
+	}{
+		{name: "nothing failed", wantMessage: "partial batch write failure: "},
+		{
+			name:         "repeated signal is named once",
+			signals:      []string{"signal-1", "signal-2", "signal-1"},
+			wantFailures: true,
+			wantMessage:  "partial batch write failure: 2 signals not written: signal-1, signal-2: throttled",
+		},
+		{
+			name:         "allocation window",
+			windowFailed: true,
+			wantFailures: true,
+			wantMessage:  "partial batch write failure: allocation window not written: throttled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failures := &PartialWriteError{AllocationWindowFailed: tt.windowFailed, Cause: cause}
+			for _, signal := range tt.signals {
+				failures.AddSignal(signal)
+			}
+
+			if got := failures.HasFailures(); got != tt.wantFailures {
+				t.Errorf("HasFailures() = %v, want %v", got, tt.wantFailures)
+			}
+			if got := failures.Error(); !strings.HasPrefix(got, tt.wantMessage) {
+				t.Errorf("Error() = %q, want %q", got, tt.wantMessage)
+			}
+			if !errors.Is(failures, cause) {
+				t.Errorf("errors.Is(%v, cause) = false, want the cause unwrapped", failures)
+			}
+		})
+	}
+}
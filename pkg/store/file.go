@@ -0,0 +1,138 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// FileStore is a MemoryStore that keeps its contents in a local JSON file. Every
+// change rewrites the file, so it suits local runs and development but is not
+// meant to be shared by processes running at the same time.
+type FileStore struct {
+	*MemoryStore
+	path string
+}
+
+// fileSnapshot is the on-disk layout of a FileStore
+type fileSnapshot struct {
+	Signals          []types.Signal           `json:"signals"`
+	AllocationWindow *types.AllocationWindow  `json:"allocation_window,omitempty"`
+	WindowHistory    []types.AllocationWindow `json:"window_history,omitempty"`
+	Intents          []types.TransitionIntent `json:"intents,omitempty"`
+	Locks            []types.RunLock          `json:"locks,omitempty"`
+	Trades           []types.TradeRecord      `json:"trades,omitempty"`
+	CircuitBreaker   *types.CircuitBreaker    `json:"circuit_breaker,omitempty"`
+	TradingControl   *types.TradingControl    `json:"trading_control,omitempty"`
+}
+
+// NewFileStore opens the store kept at path, starting empty if the file does not exist yet
+func NewFileStore(path string) (*FileStore, error) {
+	fileStore := &FileStore{
+		MemoryStore: NewMemoryStore(),
+		path:        path,
+	}
+
+	if err := fileStore.load(); err != nil {
+		return nil, err
+	}
+	fileStore.onChange = fileStore.save
+
+	return fileStore, nil
+}
+
+// load reads the snapshot file into memory
+func (f *FileStore) load() error {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read store file: %w", err)
+	}
+
+	var snapshot fileSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("failed to parse store file %s: %w", f.path, err)
+	}
+
+	for _, signal := range snapshot.Signals {
+		f.signals[signalKey{status: signal.Status, uuid: signal.UUID}] = signal
+	}
+	f.allocationWindow = snapshot.AllocationWindow
+	for _, window := range snapshot.WindowHistory {
+		f.windowHistory[window.ID()] = window
+	}
+	for _, intent := range snapshot.Intents {
+		f.intents[intent.SignalUUID] = intent
+	}
+	for _, lock := range snapshot.Locks {
+		f.locks[lock.Name] = lock
+	}
+	for _, trade := range snapshot.Trades {
+		f.trades[trade.SignalUUID] = trade
+	}
+	f.circuitBreaker = snapshot.CircuitBreaker
+	f.tradingControl = snapshot.TradingControl
+
+	return nil
+}
+
+// save writes the in-memory contents to the snapshot file. The file is written
+// to a temporary path and renamed so a crash never leaves it half written.
+func (f *FileStore) save() error {
+	snapshot := fileSnapshot{
+		Signals:          make([]types.Signal, 0, len(f.signals)),
+		AllocationWindow: f.allocationWindow,
+		CircuitBreaker:   f.circuitBreaker,
+		TradingControl:   f.tradingControl,
+	}
+	for _, signal := range f.signals {
+		snapshot.Signals = append(snapshot.Signals, signal)
+	}
+	sortSignals(snapshot.Signals)
+	for _, intent := range f.intents {
+		snapshot.Intents = append(snapshot.Intents, intent)
+	}
+	for _, lock := range f.locks {
+		snapshot.Locks = append(snapshot.Locks, lock)
+	}
+	for _, window := range f.windowHistory {
+		snapshot.WindowHistory = append(snapshot.WindowHistory, window)
+	}
+	sortWindows(snapshot.WindowHistory)
+	for _, trade := range f.trades {
+		snapshot.Trades = append(snapshot.Trades, trade)
+	}
+	sort.Slice(snapshot.Trades, func(i, j int) bool {
+		return snapshot.Trades[i].SoldAt.Before(snapshot.Trades[j].SoldAt)
+	})
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal store: %w", err)
+	}
+
+	if dir := filepath.Dir(f.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create store directory: %w", err)
+		}
+	}
+
+	tmpPath := fmt.Sprintf("%s.%s.tmp", f.path, uuid.New().String())
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write store file: %w", err)
+	}
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace store file: %w", err)
+	}
+
+	return nil
+}
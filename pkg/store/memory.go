@@ -0,0 +1,625 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// MemoryStore is an in-process Store with the same semantics as the DynamoDB
+// table: signals are keyed by status and UUID, transitions are version checked
+// and locks are leases. Nothing survives the process unless a change hook
+// persists it, which is how FileStore is built.
+type MemoryStore struct {
+	mu               sync.Mutex
+	signals          map[signalKey]types.Signal
+	allocationWindow *types.AllocationWindow
+	windowHistory    map[string]types.AllocationWindow
+	intents          map[uuid.UUID]types.TransitionIntent
+	locks            map[string]types.RunLock
+	trades           map[uuid.UUID]types.TradeRecord
+	circuitBreaker   *types.CircuitBreaker
+	tradingControl   *types.TradingControl
+
+	// onChange is called with the lock held after every successful mutation
+	onChange func() error
+}
+
+// signalKey identifies a stored signal the way the table's primary key does
+type signalKey struct {
+	status types.SignalStatus
+	uuid   uuid.UUID
+}
+
+// Ensure MemoryStore satisfies the Store interface
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		signals:       make(map[signalKey]types.Signal),
+		windowHistory: make(map[string]types.AllocationWindow),
+		intents:       make(map[uuid.UUID]types.TransitionIntent),
+		locks:         make(map[string]types.RunLock),
+		trades:        make(map[uuid.UUID]types.TradeRecord),
+	}
+}
+
+// changed runs the change hook, if any
+func (m *MemoryStore) changed() error {
+	if m.onChange == nil {
+		return nil
+	}
+	return m.onChange()
+}
+
+// commit runs the change hook for a mutation that has been applied and calls
+// undo if the hook fails, so a versioned write that did not reach the disk is
+// not kept in memory either
+func (m *MemoryStore) commit(undo func()) error {
+	if err := m.changed(); err != nil {
+		undo()
+		return err
+	}
+	return nil
+}
+
+// LoadAllData returns the active signals and the current allocation window
+func (m *MemoryStore) LoadAllData(ctx context.Context) ([]types.Signal, *types.AllocationWindow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var signals []types.Signal
+	for key, signal := range m.signals {
+		if key.status.IsActive() {
+			signals = append(signals, signal)
+		}
+	}
+	sortSignals(signals)
+
+	var window *types.AllocationWindow
+	if m.allocationWindow != nil {
+		windowCopy := *m.allocationWindow
+		window = &windowCopy
+	}
+
+	return signals, window, nil
+}
+
+// SaveAllData deletes signalsToDelete, writes signals and saves the allocation
+// window if the stored one is still at its version
+func (m *MemoryStore) SaveAllData(ctx context.Context, signals []types.Signal, signalsToDelete []types.Signal, allocationWindow *types.AllocationWindow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previousSignals := make(map[signalKey]types.Signal, len(m.signals))
+	for key, signal := range m.signals {
+		previousSignals[key] = signal
+	}
+	previousWindow := m.allocationWindow
+
+	for _, signal := range signalsToDelete {
+		delete(m.signals, signalKey{status: signal.Status, uuid: signal.UUID})
+	}
+	for _, signal := range signals {
+		m.signals[signalKey{status: signal.Status, uuid: signal.UUID}] = signal
+	}
+
+	failures := &PartialWriteError{}
+	if allocationWindow != nil {
+		if m.windowVersion() != allocationWindow.Version {
+			failures.AllocationWindowFailed = true
+			failures.Cause = fmt.Errorf("%w: allocation window %s at version %d", ErrVersionConflict, allocationWindow.ID(), allocationWindow.Version)
+		} else {
+			updated := *allocationWindow
+			updated.Version++
+			m.allocationWindow = &updated
+		}
+	}
+
+	err := m.commit(func() {
+		m.signals = previousSignals
+		m.allocationWindow = previousWindow
+	})
+	if err != nil {
+		return &PartialWriteError{
+			FailedSignals:          signalUUIDs(signals, signalsToDelete),
+			AllocationWindowFailed: allocationWindow != nil,
+			Cause:                  err,
+		}
+	}
+
+	if allocationWindow != nil && !failures.AllocationWindowFailed {
+		allocationWindow.Version = m.allocationWindow.Version
+	}
+	if failures.HasFailures() {
+		return failures
+	}
+	return nil
+}
+
+// SaveAllocationWindow writes the allocation window if the stored one is still at its version
+func (m *MemoryStore) SaveAllocationWindow(ctx context.Context, window *types.AllocationWindow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.windowVersion() != window.Version {
+		return fmt.Errorf("%w: allocation window %s at version %d", ErrVersionConflict, window.ID(), window.Version)
+	}
+
+	previous := m.allocationWindow
+	updated := *window
+	updated.Version++
+	m.allocationWindow = &updated
+
+	if err := m.commit(func() { m.allocationWindow = previous }); err != nil {
+		return fmt.Errorf("failed to save allocation window %s: %w", window.ID(), err)
+	}
+
+	window.Version = updated.Version
+	return nil
+}
+
+// LoadAllocationWindow returns a copy of the current allocation window, or nil if none exists
+func (m *MemoryStore) LoadAllocationWindow(ctx context.Context) (*types.AllocationWindow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.allocationWindow == nil {
+		return nil, nil
+	}
+	window := *m.allocationWindow
+	return &window, nil
+}
+
+// AdmitSignal writes an admitted signal and bumps the allocation window's
+// version if neither changed since they were loaded
+func (m *MemoryStore) AdmitSignal(ctx context.Context, signal *types.Signal, previousStatus types.SignalStatus, window *types.AllocationWindow) error {
+	if previousStatus != "" {
+		if err := signal.ValidateTransitionFrom(previousStatus); err != nil {
+			return fmt.Errorf("failed to admit signal %s: %w", signal.UUID, err)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if window == nil && m.allocationWindow != nil ||
+		window != nil && (m.allocationWindow == nil || m.allocationWindow.Version != window.Version) {
+		return fmt.Errorf("%w: signal %s was not admitted, the allocation window changed", ErrVersionConflict, signal.UUID)
+	}
+
+	oldKey := signalKey{status: previousStatus, uuid: signal.UUID}
+	newKey := signalKey{status: signal.Status, uuid: signal.UUID}
+
+	stored, wasStored := m.signals[oldKey]
+	if previousStatus != "" && (!wasStored || stored.Version != signal.Version) {
+		return fmt.Errorf("%w: signal %s at version %d", ErrVersionConflict, signal.UUID, signal.Version)
+	}
+	if oldKey != newKey {
+		if _, exists := m.signals[newKey]; exists {
+			return fmt.Errorf("%w: signal %s already exists as %s", ErrVersionConflict, signal.UUID, signal.Status)
+		}
+	}
+
+	updated := *signal
+	updated.Version++
+	delete(m.signals, oldKey)
+	m.signals[newKey] = updated
+	if m.allocationWindow != nil {
+		m.allocationWindow.Version++
+	}
+
+	err := m.commit(func() {
+		delete(m.signals, newKey)
+		if wasStored {
+			m.signals[oldKey] = stored
+		}
+		if m.allocationWindow != nil {
+			m.allocationWindow.Version--
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to admit signal %s: %w", signal.UUID, err)
+	}
+
+	signal.Version = updated.Version
+	if window != nil {
+		window.Version++
+	}
+	return nil
+}
+
+// windowVersion returns the version of the stored allocation window, 0 if there is none
+func (m *MemoryStore) windowVersion() int64 {
+	if m.allocationWindow == nil {
+		return 0
+	}
+	return m.allocationWindow.Version
+}
+
+// GetSignal returns the signal with the given UUID in any status, or nil if it does not exist
+func (m *MemoryStore) GetSignal(ctx context.Context, signalUUID uuid.UUID) (*types.Signal, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, status := range types.AllSignalStatuses {
+		if signal, ok := m.signals[signalKey{status: status, uuid: signalUUID}]; ok {
+			return &signal, nil
+		}
+	}
+	return nil, nil
+}
+
+// SaveSignal writes a single signal under its current status
+func (m *MemoryStore) SaveSignal(ctx context.Context, signal types.Signal) error {
+	if !signal.Status.IsValid() {
+		return fmt.Errorf("signal %s has unknown status %q", signal.UUID, signal.Status)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.signals[signalKey{status: signal.Status, uuid: signal.UUID}] = signal
+	return m.changed()
+}
+
+// TransitionSignal moves a signal from previousStatus to its current status if
+// the stored record is still at the signal's version
+func (m *MemoryStore) TransitionSignal(ctx context.Context, signal *types.Signal, previousStatus types.SignalStatus) error {
+	if err := signal.ValidateTransitionFrom(previousStatus); err != nil {
+		return fmt.Errorf("failed to transition signal %s: %w", signal.UUID, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldKey := signalKey{status: previousStatus, uuid: signal.UUID}
+	newKey := signalKey{status: signal.Status, uuid: signal.UUID}
+
+	stored, ok := m.signals[oldKey]
+	if !ok || stored.Version != signal.Version {
+		return fmt.Errorf("%w: signal %s at version %d", ErrVersionConflict, signal.UUID, signal.Version)
+	}
+	if oldKey != newKey {
+		if _, exists := m.signals[newKey]; exists {
+			return fmt.Errorf("%w: signal %s already exists as %s", ErrVersionConflict, signal.UUID, signal.Status)
+		}
+	}
+
+	updated := *signal
+	updated.Version++
+	delete(m.signals, oldKey)
+	m.signals[newKey] = updated
+
+	err := m.commit(func() {
+		delete(m.signals, newKey)
+		m.signals[oldKey] = stored
+	})
+	if err != nil {
+		return fmt.Errorf("failed to transition signal %s: %w", signal.UUID, err)
+	}
+
+	signal.Version = updated.Version
+	return nil
+}
+
+// DeleteSignal deletes a signal stored under status if it is still at the signal's version
+func (m *MemoryStore) DeleteSignal(ctx context.Context, signal types.Signal, status types.SignalStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := signalKey{status: status, uuid: signal.UUID}
+	stored, ok := m.signals[key]
+	if !ok || stored.Version != signal.Version {
+		return fmt.Errorf("%w: signal %s at version %d", ErrVersionConflict, signal.UUID, signal.Version)
+	}
+
+	delete(m.signals, key)
+	return m.commit(func() {
+		m.signals[key] = stored
+	})
+}
+
+// ArchiveSignal deletes a completed signal stored under previousStatus and
+// adds its trade record to the history
+func (m *MemoryStore) ArchiveSignal(ctx context.Context, signal types.Signal, previousStatus types.SignalStatus, trade types.TradeRecord) error {
+	if signal.Status != types.SignalStatusCompleted {
+		return fmt.Errorf("failed to archive signal %s: status is %s, not %s", signal.UUID, signal.Status, types.SignalStatusCompleted)
+	}
+	if err := signal.ValidateTransitionFrom(previousStatus); err != nil {
+		return fmt.Errorf("failed to archive signal %s: %w", signal.UUID, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := signalKey{status: previousStatus, uuid: signal.UUID}
+	stored, ok := m.signals[key]
+	if !ok || stored.Version != signal.Version {
+		return fmt.Errorf("%w: signal %s at version %d", ErrVersionConflict, signal.UUID, signal.Version)
+	}
+	if _, exists := m.trades[trade.SignalUUID]; exists {
+		return fmt.Errorf("%w: trade for signal %s already archived", ErrVersionConflict, signal.UUID)
+	}
+
+	delete(m.signals, key)
+	m.trades[trade.SignalUUID] = trade
+	return m.commit(func() {
+		delete(m.trades, trade.SignalUUID)
+		m.signals[key] = stored
+	})
+}
+
+// LoadTradeHistory returns the unexpired trades sold between from and to (inclusive dates), oldest first
+func (m *MemoryStore) LoadTradeHistory(ctx context.Context, from, to time.Time) ([]types.TradeRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fromDate := from.UTC().Format("2006-01-02")
+	toDate := to.UTC().Format("2006-01-02")
+	now := time.Now()
+
+	var trades []types.TradeRecord
+	for _, trade := range m.trades {
+		soldDate := trade.SoldAt.UTC().Format("2006-01-02")
+		if soldDate < fromDate || soldDate > toDate {
+			continue
+		}
+		if trade.ExpiresAt != nil && trade.ExpiresAt.Before(now) {
+			continue
+		}
+		trades = append(trades, trade)
+	}
+	sort.Slice(trades, func(i, j int) bool {
+		return trades[i].SoldAt.Before(trades[j].SoldAt)
+	})
+
+	return trades, nil
+}
+
+// ArchiveAllocationWindow adds a closed allocation window to the window history
+// and makes window the current one, if the stored window is still at the closed
+// window's version
+func (m *MemoryStore) ArchiveAllocationWindow(ctx context.Context, closed types.AllocationWindow, window *types.AllocationWindow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.windowVersion() != closed.Version {
+		return fmt.Errorf("%w: allocation window %s at version %d", ErrVersionConflict, closed.ID(), closed.Version)
+	}
+
+	previous := m.allocationWindow
+	archived, wasArchived := m.windowHistory[closed.ID()]
+	updated := *window
+	updated.Version = closed.Version + 1
+	m.windowHistory[closed.ID()] = closed
+	m.allocationWindow = &updated
+
+	err := m.commit(func() {
+		m.allocationWindow = previous
+		if wasArchived {
+			m.windowHistory[closed.ID()] = archived
+		} else {
+			delete(m.windowHistory, closed.ID())
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive allocation window %s: %w", closed.ID(), err)
+	}
+
+	window.Version = updated.Version
+	return nil
+}
+
+// LoadAllocationWindowHistory returns the closed allocation windows, oldest first
+func (m *MemoryStore) LoadAllocationWindowHistory(ctx context.Context) ([]types.AllocationWindow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	windows := make([]types.AllocationWindow, 0, len(m.windowHistory))
+	for _, window := range m.windowHistory {
+		windows = append(windows, window)
+	}
+	sortWindows(windows)
+
+	return windows, nil
+}
+
+// SaveIntent records a transition intent
+func (m *MemoryStore) SaveIntent(ctx context.Context, intent types.TransitionIntent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.intents[intent.SignalUUID] = intent
+	return m.changed()
+}
+
+// DeleteIntent removes the transition intent of a signal
+func (m *MemoryStore) DeleteIntent(ctx context.Context, signalUUID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.intents, signalUUID)
+	return m.changed()
+}
+
+// LoadIntents returns all open transition intents
+func (m *MemoryStore) LoadIntents(ctx context.Context) ([]types.TransitionIntent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	intents := make([]types.TransitionIntent, 0, len(m.intents))
+	for _, intent := range m.intents {
+		intents = append(intents, intent)
+	}
+	sort.Slice(intents, func(i, j int) bool {
+		return intents[i].CreatedAt.Before(intents[j].CreatedAt)
+	})
+
+	return intents, nil
+}
+
+// AcquireLock takes the named lock for owner if it is free, expired or already owned
+func (m *MemoryStore) AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if lock, ok := m.locks[name]; ok && lock.Owner != owner && lock.ExpiresAt.After(now) {
+		return ErrLockHeld
+	}
+
+	m.locks[name] = types.RunLock{
+		Name:       name,
+		Owner:      owner,
+		AcquiredAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	return m.changed()
+}
+
+// RenewLock extends the lease if owner still holds it
+func (m *MemoryStore) RenewLock(ctx context.Context, name, owner string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	lock, ok := m.locks[name]
+	if !ok || lock.Owner != owner || !lock.ExpiresAt.After(now) {
+		return ErrLockHeld
+	}
+
+	lock.ExpiresAt = now.Add(ttl)
+	m.locks[name] = lock
+	return m.changed()
+}
+
+// ReleaseLock deletes the lock if owner still holds it
+func (m *MemoryStore) ReleaseLock(ctx context.Context, name, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lock, ok := m.locks[name]
+	if !ok || lock.Owner != owner {
+		// Already expired and taken over, nothing to release
+		return nil
+	}
+
+	delete(m.locks, name)
+	return m.changed()
+}
+
+// LoadCircuitBreaker returns a copy of the circuit breaker, or nil if it was never saved
+func (m *MemoryStore) LoadCircuitBreaker(ctx context.Context) (*types.CircuitBreaker, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.circuitBreaker == nil {
+		return nil, nil
+	}
+	breaker := *m.circuitBreaker
+	return &breaker, nil
+}
+
+// SaveCircuitBreaker writes the circuit breaker if the stored one is still at its version
+func (m *MemoryStore) SaveCircuitBreaker(ctx context.Context, breaker *types.CircuitBreaker) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var storedVersion int64
+	if m.circuitBreaker != nil {
+		storedVersion = m.circuitBreaker.Version
+	}
+	if storedVersion != breaker.Version {
+		return fmt.Errorf("%w: circuit breaker at version %d", ErrVersionConflict, breaker.Version)
+	}
+
+	previous := m.circuitBreaker
+	updated := *breaker
+	updated.Version++
+	m.circuitBreaker = &updated
+
+	if err := m.commit(func() { m.circuitBreaker = previous }); err != nil {
+		return fmt.Errorf("failed to save circuit breaker: %w", err)
+	}
+
+	breaker.Version = updated.Version
+	return nil
+}
+
+// LoadTradingControl returns a copy of the trading kill switch, or nil if it was never set
+func (m *MemoryStore) LoadTradingControl(ctx context.Context) (*types.TradingControl, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.tradingControl == nil {
+		return nil, nil
+	}
+	control := *m.tradingControl
+	control.History = append([]types.TradingModeChange(nil), m.tradingControl.History...)
+	return &control, nil
+}
+
+// SaveTradingControl writes the trading kill switch if the stored one is still at its version
+func (m *MemoryStore) SaveTradingControl(ctx context.Context, control *types.TradingControl) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var storedVersion int64
+	if m.tradingControl != nil {
+		storedVersion = m.tradingControl.Version
+	}
+	if storedVersion != control.Version {
+		return fmt.Errorf("%w: trading control at version %d", ErrVersionConflict, control.Version)
+	}
+
+	updated := *control
+	updated.History = append([]types.TradingModeChange(nil), control.History...)
+	updated.Version++
+	previous := m.tradingControl
+	m.tradingControl = &updated
+
+	if err := m.commit(func() { m.tradingControl = previous }); err != nil {
+		return fmt.Errorf("failed to save trading control: %w", err)
+	}
+
+	control.Version = updated.Version
+	return nil
+}
+
+// sortSignals orders signals by buy date, then ticker, so results are deterministic
+func sortSignals(signals []types.Signal) {
+	sort.Slice(signals, func(i, j int) bool {
+		if !signals[i].BuyDate.Equal(signals[j].BuyDate) {
+			return signals[i].BuyDate.Before(signals[j].BuyDate)
+		}
+		if signals[i].Ticker != signals[j].Ticker {
+			return signals[i].Ticker < signals[j].Ticker
+		}
+		return signals[i].UUID.String() < signals[j].UUID.String()
+	})
+}
+
+// sortWindows orders allocation windows by their start date
+func sortWindows(windows []types.AllocationWindow) {
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].WindowStartDate.Before(windows[j].WindowStartDate)
+	})
+}
+
+// signalUUIDs returns the distinct UUIDs of the given signals
+func signalUUIDs(signalSets ...[]types.Signal) []string {
+	failures := &PartialWriteError{}
+	for _, signals := range signalSets {
+		for _, signal := range signals {
+			failures.AddSignal(signal.UUID.String())
+		}
+	}
+	return failures.FailedSignals
+}
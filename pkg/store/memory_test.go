@@ -0,0 +1,378 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// pendingSignal returns a new pending signal as it would be saved by /addsignal
+func pendingSignal() types.Signal {
+	return types.Signal{
+		UUID:     uuid.New(),
+		Ticker:   "AAPL",
+		BuyDate:  time.Date(2026, 11, 23, 0, 0, 0, 0, time.UTC),
+		SellDate: time.Date(2026, 12, 10, 0, 0, 0, 0, time.UTC),
+		Status:   types.SignalStatusPending,
+	}
+}
+
+func TestMemoryStoreTransitionSignal(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name           string
+		storedVersion  int64
+		signalVersion  int64
+		previousStatus types.SignalStatus
+		wantConflict   bool
+	}{
+		{name: "current version", storedVersion: 2, signalVersion: 2, previousStatus: types.SignalStatusPending},
+		{name: "stale version", storedVersion: 3, signalVersion: 2, previousStatus: types.SignalStatusPending, wantConflict: true},
+		{name: "newer version than stored", storedVersion: 2, signalVersion: 3, previousStatus: types.SignalStatusPending, wantConflict: true},
+		{name: "stored under another status", storedVersion: 2, signalVersion: 2, previousStatus: types.SignalStatusWaitlisted, wantConflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			stored := pendingSignal()
+			stored.Version = tt.storedVersion
+			if err := store.SaveSignal(ctx, stored); err != nil {
+				t.Fatalf("SaveSignal() error = %v", err)
+			}
+
+			signal := stored
+			signal.Version = tt.signalVersion
+			if tt.previousStatus == types.SignalStatusWaitlisted {
+				signal.Status = types.SignalStatusWaitlisted
+			}
+			if err := signal.Transition(types.SignalStatusCancelled, "test"); err != nil {
+				t.Fatalf("Transition() error = %v", err)
+			}
+
+			err := store.TransitionSignal(ctx, &signal, tt.previousStatus)
+
+			if tt.wantConflict {
+				if !errors.Is(err, ErrVersionConflict) {
+					t.Fatalf("TransitionSignal() error = %v, want ErrVersionConflict", err)
+				}
+				if signal.Version != tt.signalVersion {
+					t.Errorf("version = %d after a conflict, want %d", signal.Version, tt.signalVersion)
+				}
+				got, _ := store.GetSignal(ctx, stored.UUID)
+				if got.Status != types.SignalStatusPending || got.Version != tt.storedVersion {
+					t.Errorf("stored signal = %s at version %d, want it unchanged", got.Status, got.Version)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("TransitionSignal() error = %v", err)
+			}
+			if signal.Version != tt.storedVersion+1 {
+				t.Errorf("version = %d, want %d", signal.Version, tt.storedVersion+1)
+			}
+			got, _ := store.GetSignal(ctx, stored.UUID)
+			if got.Status != types.SignalStatusCancelled || got.Version != signal.Version {
+				t.Errorf("stored signal = %s at version %d, want CANCELLED at version %d", got.Status, got.Version, signal.Version)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreFailedChangeHook(t *testing.T) {
+	ctx := context.Background()
+	hookErr := errors.New("disk full")
+
+	tests := []struct {
+		name  string
+		write func(store *MemoryStore, signal *types.Signal) error
+	}{
+		{
+			name: "transition",
+			write: func(store *MemoryStore, signal *types.Signal) error {
+				if signal.Status == types.SignalStatusPending {
+					if err := signal.Transition(types.SignalStatusBuying, "test"); err != nil {
+						return err
+					}
+				}
+				return store.TransitionSignal(ctx, signal, types.SignalStatusPending)
+			},
+		},
+		{
+			name: "delete",
+			write: func(store *MemoryStore, signal *types.Signal) error {
+				return store.DeleteSignal(ctx, *signal, types.SignalStatusPending)
+			},
+		},
+		{
+			name: "admission",
+			write: func(store *MemoryStore, signal *types.Signal) error {
+				added := pendingSignal()
+				return store.AdmitSignal(ctx, &added, "", nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			stored := pendingSignal()
+			if err := store.SaveSignal(ctx, stored); err != nil {
+				t.Fatalf("SaveSignal() error = %v", err)
+			}
+			store.onChange = func() error { return hookErr }
+
+			signal := stored
+			err := tt.write(store, &signal)
+
+			if !errors.Is(err, hookErr) {
+				t.Fatalf("write error = %v, want the change hook's error", err)
+			}
+			if signal.Version != stored.Version {
+				t.Errorf("version = %d after a failed write, want %d", signal.Version, stored.Version)
+			}
+
+			// A retry must not see its own failed write as a concurrent change
+			store.onChange = nil
+			signals, _, _ := store.LoadAllData(ctx)
+			if len(signals) != 1 || signals[0].Status != types.SignalStatusPending || signals[0].Version != stored.Version {
+				t.Fatalf("stored signals = %+v, want the signal unchanged", signals)
+			}
+			if err := tt.write(store, &signal); err != nil {
+				t.Errorf("retry error = %v", err)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreSaveAllocationWindow(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		storedVersion int64 // 0 stores no window
+		windowVersion int64
+		wantConflict  bool
+	}{
+		{name: "first window", windowVersion: 0},
+		{name: "current version", storedVersion: 2, windowVersion: 2},
+		{name: "stale version", storedVersion: 3, windowVersion: 2, wantConflict: true},
+		{name: "window already opened", storedVersion: 1, windowVersion: 0, wantConflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			if tt.storedVersion > 0 {
+				store.allocationWindow = &types.AllocationWindow{SlotsUsed: 1, Version: tt.storedVersion}
+			}
+
+			window := &types.AllocationWindow{SlotsUsed: 2, Version: tt.windowVersion}
+			err := store.SaveAllocationWindow(ctx, window)
+
+			stored, _ := store.LoadAllocationWindow(ctx)
+			if tt.wantConflict {
+				if !errors.Is(err, ErrVersionConflict) {
+					t.Fatalf("SaveAllocationWindow() error = %v, want ErrVersionConflict", err)
+				}
+				if stored.SlotsUsed != 1 || stored.Version != tt.storedVersion {
+					t.Errorf("stored window = %+v, want it unchanged", stored)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("SaveAllocationWindow() error = %v", err)
+			}
+			if window.Version != tt.windowVersion+1 || stored.Version != window.Version || stored.SlotsUsed != 2 {
+				t.Errorf("window version = %d, stored window = %+v, want both at version %d", window.Version, stored, tt.windowVersion+1)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreAdmitSignal(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		storedWindow  bool
+		windowVersion int64 // Version the writer counted the capacity from, -1 for no window
+		wantConflict  bool
+	}{
+		{name: "no window yet", windowVersion: -1},
+		{name: "current window", storedWindow: true, windowVersion: 4},
+		{name: "window reserved by another admission", storedWindow: true, windowVersion: 3, wantConflict: true},
+		{name: "window opened meanwhile", storedWindow: true, windowVersion: -1, wantConflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			if tt.storedWindow {
+				store.allocationWindow = &types.AllocationWindow{TotalSignalsInWindow: 2, Version: 4}
+			}
+
+			var window *types.AllocationWindow
+			if tt.windowVersion >= 0 {
+				window = &types.AllocationWindow{TotalSignalsInWindow: 2, Version: tt.windowVersion}
+			}
+
+			signal := pendingSignal()
+			err := store.AdmitSignal(ctx, &signal, "", window)
+
+			signals, stored, _ := store.LoadAllData(ctx)
+			if tt.wantConflict {
+				if !errors.Is(err, ErrVersionConflict) {
+					t.Fatalf("AdmitSignal() error = %v, want ErrVersionConflict", err)
+				}
+				if len(signals) != 0 || stored.Version != 4 {
+					t.Errorf("%d signals saved and window at version %d, want none saved and version 4", len(signals), stored.Version)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("AdmitSignal() error = %v", err)
+			}
+			if len(signals) != 1 || signals[0].Version != signal.Version {
+				t.Errorf("stored signals = %+v, want the admitted signal at version %d", signals, signal.Version)
+			}
+			if window != nil && (window.Version != 5 || stored.Version != 5) {
+				t.Errorf("window version = %d, stored %d, want both at version 5", window.Version, stored.Version)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreSaveAllData(t *testing.T) {
+	ctx := context.Background()
+	hookErr := errors.New("disk full")
+
+	tests := []struct {
+		name          string
+		windowVersion int64
+		hookErr       error
+		wantWindowErr bool
+		wantFailed    int // Signals named in the error
+		wantSlots     int // Slots used by the stored window afterwards
+	}{
+		{name: "current window", windowVersion: 1, wantSlots: 2},
+		{name: "stale window is not written", windowVersion: 0, wantWindowErr: true, wantSlots: 1},
+		{name: "failed change hook writes nothing", windowVersion: 1, hookErr: hookErr, wantWindowErr: true, wantFailed: 2, wantSlots: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			deleted := pendingSignal()
+			if err := store.SaveSignal(ctx, deleted); err != nil {
+				t.Fatalf("SaveSignal() error = %v", err)
+			}
+			if err := store.SaveAllocationWindow(ctx, &types.AllocationWindow{SlotsUsed: 1}); err != nil {
+				t.Fatalf("SaveAllocationWindow() error = %v", err)
+			}
+			if tt.hookErr != nil {
+				store.onChange = func() error { return tt.hookErr }
+			}
+
+			written := pendingSignal()
+			window := &types.AllocationWindow{SlotsUsed: 2, Version: tt.windowVersion}
+			err := store.SaveAllData(ctx, []types.Signal{written}, []types.Signal{deleted}, window)
+
+			var partialErr *PartialWriteError
+			if tt.wantWindowErr {
+				if !errors.As(err, &partialErr) || !partialErr.AllocationWindowFailed {
+					t.Fatalf("SaveAllData() error = %v, want a PartialWriteError for the window", err)
+				}
+				if len(partialErr.FailedSignals) != tt.wantFailed {
+					t.Errorf("failed signals = %v, want %d", partialErr.FailedSignals, tt.wantFailed)
+				}
+			} else if err != nil {
+				t.Fatalf("SaveAllData() error = %v", err)
+			}
+
+			store.onChange = nil
+			signals, stored, _ := store.LoadAllData(ctx)
+			wantSignal := written.UUID
+			if tt.hookErr != nil {
+				wantSignal = deleted.UUID
+			}
+			if len(signals) != 1 || signals[0].UUID != wantSignal {
+				t.Errorf("stored signals = %+v, want only signal %s", signals, wantSignal)
+			}
+			if stored.SlotsUsed != tt.wantSlots {
+				t.Errorf("stored window has %d slots used, want %d", stored.SlotsUsed, tt.wantSlots)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreArchiveSignal(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	// Trades sold on November 30 and December 5, the later one past its retention
+	expired := time.Now().Add(-time.Hour)
+	var archived []types.Signal
+	for i, trade := range []types.TradeRecord{
+		{SoldAt: time.Date(2026, 11, 30, 20, 0, 0, 0, time.UTC)},
+		{SoldAt: time.Date(2026, 12, 5, 20, 0, 0, 0, time.UTC), ExpiresAt: &expired},
+	} {
+		signal := pendingSignal()
+		signal.Status = types.SignalStatusSelling
+		signal.Version = int64(i + 1)
+		if err := store.SaveSignal(ctx, signal); err != nil {
+			t.Fatalf("SaveSignal() error = %v", err)
+		}
+		if err := signal.Transition(types.SignalStatusCompleted, "sold"); err != nil {
+			t.Fatalf("Transition() error = %v", err)
+		}
+
+		trade.SignalUUID = signal.UUID
+		if err := store.ArchiveSignal(ctx, signal, types.SignalStatusSelling, trade); err != nil {
+			t.Fatalf("ArchiveSignal() error = %v", err)
+		}
+		archived = append(archived, signal)
+	}
+
+	signals, _, err := store.LoadAllData(ctx)
+	if err != nil || len(signals) != 0 {
+		t.Errorf("LoadAllData() = %d signals, %v, want the archived signals gone", len(signals), err)
+	}
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want int
+	}{
+		{name: "sell day is inclusive", from: time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC), to: time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC), want: 1},
+		{name: "before the sales", from: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), to: time.Date(2026, 11, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "expired trades are left out", from: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), to: time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trades, err := store.LoadTradeHistory(ctx, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("LoadTradeHistory() error = %v", err)
+			}
+			if len(trades) != tt.want {
+				t.Errorf("LoadTradeHistory() = %d trades, want %d", len(trades), tt.want)
+			}
+		})
+	}
+
+	// A second archive of the same signal is refused
+	err = store.ArchiveSignal(ctx, archived[0], types.SignalStatusSelling, types.TradeRecord{SignalUUID: archived[0].UUID})
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("second ArchiveSignal() error = %v, want ErrVersionConflict", err)
+	}
+}
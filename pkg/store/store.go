@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// SignalStore persists signals and the allocation window
+type SignalStore interface {
+	// LoadAllData loads the active signals and the current allocation window
+	LoadAllData(ctx context.Context) ([]types.Signal, *types.AllocationWindow, error)
+	// LoadAllocationWindow returns the current allocation window, or nil if none exists
+	LoadAllocationWindow(ctx context.Context) (*types.AllocationWindow, error)
+	// AdmitSignal atomically writes a signal admitted against the allocation
+	// window's capacity and bumps the window's version, so two writers cannot
+	// both admit into the same free slot. An empty previousStatus adds a new
+	// signal, otherwise it is transitioned from previousStatus. A nil window
+	// requires that no window exists yet. It fails with ErrVersionConflict if
+	// the window or the signal changed since they were loaded.
+	AdmitSignal(ctx context.Context, signal *types.Signal, previousStatus types.SignalStatus, window *types.AllocationWindow) error
+	// SaveAllData writes signals, deletes signalsToDelete and saves the
+	// allocation window with the version check of SaveAllocationWindow. Items
+	// that could not be written are reported through a *PartialWriteError.
+	SaveAllData(ctx context.Context, signals []types.Signal, signalsToDelete []types.Signal, allocationWindow *types.AllocationWindow) error
+	// SaveAllocationWindow writes the current allocation window if the stored
+	// one is still at the window's version, failing with ErrVersionConflict
+	// otherwise. On success the window's version is incremented.
+	SaveAllocationWindow(ctx context.Context, window *types.AllocationWindow) error
+	// GetSignal returns the signal with the given UUID in any status, or nil if it does not exist
+	GetSignal(ctx context.Context, signalUUID uuid.UUID) (*types.Signal, error)
+	// SaveSignal writes a single signal under its current status
+	SaveSignal(ctx context.Context, signal types.Signal) error
+	// TransitionSignal atomically moves a signal from previousStatus to its current
+	// status, failing with ErrVersionConflict if the stored version has moved on
+	// and with types.ErrInvalidTransition if the state machine forbids the change
+	TransitionSignal(ctx context.Context, signal *types.Signal, previousStatus types.SignalStatus) error
+	// DeleteSignal deletes a signal stored under status if it is still at the signal's version
+	DeleteSignal(ctx context.Context, signal types.Signal, status types.SignalStatus) error
+	// ArchiveSignal atomically deletes a completed signal stored under
+	// previousStatus and adds its trade record to the trade history
+	ArchiveSignal(ctx context.Context, signal types.Signal, previousStatus types.SignalStatus, trade types.TradeRecord) error
+	// LoadTradeHistory returns the trades sold between from and to (inclusive dates), oldest first
+	LoadTradeHistory(ctx context.Context, from, to time.Time) ([]types.TradeRecord, error)
+	// ArchiveAllocationWindow atomically adds a closed allocation window to the
+	// window history, replacing any earlier copy of it, and saves window as the
+	// current window in its place. It fails with ErrVersionConflict unless the
+	// stored current window is still at the closed window's version.
+	ArchiveAllocationWindow(ctx context.Context, closed types.AllocationWindow, window *types.AllocationWindow) error
+	// LoadAllocationWindowHistory returns the closed allocation windows, oldest first
+	LoadAllocationWindowHistory(ctx context.Context) ([]types.AllocationWindow, error)
+}
+
+// IntentStore persists transition intents written before orders are placed
+type IntentStore interface {
+	SaveIntent(ctx context.Context, intent types.TransitionIntent) error
+	DeleteIntent(ctx context.Context, signalUUID uuid.UUID) error
+	LoadIntents(ctx context.Context) ([]types.TransitionIntent, error)
+}
+
+// LockStore provides lease-based named locks
+type LockStore interface {
+	// AcquireLock fails with ErrLockHeld if another owner holds an unexpired lease
+	AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) error
+	// RenewLock fails with ErrLockHeld if owner no longer holds the lease
+	RenewLock(ctx context.Context, name, owner string, ttl time.Duration) error
+	ReleaseLock(ctx context.Context, name, owner string) error
+}
+
+// ControlStore persists the controls that halt trading
+type ControlStore interface {
+	// LoadCircuitBreaker returns the circuit breaker state, or nil if it was never saved
+	LoadCircuitBreaker(ctx context.Context) (*types.CircuitBreaker, error)
+	// SaveCircuitBreaker writes the circuit breaker if the stored record is
+	// still at its version, failing with ErrVersionConflict otherwise. On
+	// success the breaker's version is incremented.
+	SaveCircuitBreaker(ctx context.Context, breaker *types.CircuitBreaker) error
+	// LoadTradingControl returns the trading kill switch, or nil if it was never set
+	LoadTradingControl(ctx context.Context) (*types.TradingControl, error)
+	// SaveTradingControl writes the trading kill switch with the same version
+	// check as SaveCircuitBreaker
+	SaveTradingControl(ctx context.Context, control *types.TradingControl) error
+}
+
+// Store is everything the trading bot needs from its storage backend
+type Store interface {
+	SignalStore
+	IntentStore
+	LockStore
+	ControlStore
+}
+
+// Storage backends selectable through configuration
+const (
+	BackendDynamoDB = "dynamodb"
+	BackendMemory   = "memory"
+	BackendFile     = "file"
+)
@@ -0,0 +1,95 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// WindowCapacity is how the slots of the allocation window are spoken for.
+// Signals are admitted against it when they are added and waitlisted signals
+// are promoted from it once slots free up.
+type WindowCapacity struct {
+	Slots      int // Slots in the window
+	Used       int // Slots taken by buys placed in the window
+	Committed  int // Pending signals that take a slot once they are bought
+	Waitlisted int // Signals waiting for a free slot
+}
+
+// NewWindowCapacity counts the slots of the current allocation window against
+// the active signals on currentDate, a session date. A window that has ended is
+// counted as the empty window that replaces it, with defaultSlots slots, as is
+// a missing one.
+func NewWindowCapacity(window *AllocationWindow, signals []Signal, defaultSlots int, currentDate time.Time) WindowCapacity {
+	if window != nil && window.IsExpired(currentDate) {
+		window = nil
+	}
+
+	capacity := WindowCapacity{Slots: defaultSlots}
+	if window != nil {
+		capacity.Slots = window.TotalSignalsInWindow
+		capacity.Used = window.SlotsUsed
+	}
+
+	for i := range signals {
+		switch signals[i].Status {
+		case SignalStatusPending:
+			if window == nil || !window.HoldsSlot(&signals[i]) {
+				capacity.Committed++
+			}
+		case SignalStatusWaitlisted:
+			capacity.Waitlisted++
+		}
+	}
+
+	return capacity
+}
+
+// Free returns the slots not used or committed, which waitlisted signals are promoted into
+func (c WindowCapacity) Free() int {
+	free := c.Slots - c.Used - c.Committed
+	if free < 0 {
+		return 0
+	}
+	return free
+}
+
+// Remaining returns the slots a new signal can take, after the waitlist has been served
+func (c WindowCapacity) Remaining() int {
+	remaining := c.Free() - c.Waitlisted
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// String summarizes the capacity for messages
+func (c WindowCapacity) String() string {
+	return fmt.Sprintf("%d of %d slots remaining (%d used, %d pending, %d waitlisted)",
+		c.Remaining(), c.Slots, c.Used, c.Committed, c.Waitlisted)
+}
+
+// Waitlist returns the waitlisted signals in the order they are promoted, oldest first
+func Waitlist(signals []Signal) []*Signal {
+	var waitlist []*Signal
+	for i := range signals {
+		if signals[i].Status == SignalStatusWaitlisted {
+			waitlist = append(waitlist, &signals[i])
+		}
+	}
+
+	sort.SliceStable(waitlist, func(i, j int) bool {
+		return waitlist[i].CreatedAt.Before(waitlist[j].CreatedAt)
+	})
+	return waitlist
+}
+
+// Promote moves a waitlisted signal to PENDING. Its buy date becomes today, as
+// the date it was added on may already be past the time a pending signal waits.
+func (s *Signal) Promote(today time.Time, reason string) error {
+	if err := s.Transition(SignalStatusPending, reason); err != nil {
+		return err
+	}
+	s.BuyDate = today
+	return nil
+}
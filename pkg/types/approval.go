@@ -0,0 +1,66 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ApprovalDecision is a human decision on a planned order
+type ApprovalDecision string
+
+const (
+	ApprovalDecisionPending  ApprovalDecision = "PENDING"
+	ApprovalDecisionApproved ApprovalDecision = "APPROVED"
+	ApprovalDecisionRejected ApprovalDecision = "REJECTED"
+)
+
+// approvalCustomIDPrefix starts the custom_id of every approval button
+const approvalCustomIDPrefix = "approval"
+
+// OrderApproval is the approval requested for a signal's next order. The
+// trading bot only places the order once it is approved, and only until the
+// deadline, after which a new approval is requested.
+type OrderApproval struct {
+	Step        string           `json:"step"` // "buy" or "sell"
+	Decision    ApprovalDecision `json:"decision"`
+	Quantity    float64          `json:"quantity,omitempty"` // Planned quantity when the approval was requested
+	Price       float64          `json:"price,omitempty"`    // Quote the plan was based on
+	RequestedAt time.Time        `json:"requested_at"`
+	Deadline    time.Time        `json:"deadline"`
+	DecidedBy   string           `json:"decided_by,omitempty"`
+	DecidedAt   time.Time        `json:"decided_at,omitempty"`
+}
+
+// IsOpen reports whether the approval can still be decided at now
+func (a *OrderApproval) IsOpen(now time.Time) bool {
+	return a.Decision == ApprovalDecisionPending && !now.After(a.Deadline)
+}
+
+// ApprovalCustomID builds the custom_id of the button that records decision
+// for the given order step of a signal
+func ApprovalCustomID(decision ApprovalDecision, signalUUID uuid.UUID, step string) string {
+	return strings.Join([]string{approvalCustomIDPrefix, string(decision), signalUUID.String(), step}, ":")
+}
+
+// ParseApprovalCustomID parses a custom_id built by ApprovalCustomID
+func ParseApprovalCustomID(customID string) (ApprovalDecision, uuid.UUID, string, error) {
+	parts := strings.Split(customID, ":")
+	if len(parts) != 4 || parts[0] != approvalCustomIDPrefix {
+		return "", uuid.Nil, "", fmt.Errorf("not an approval custom_id: %q", customID)
+	}
+
+	decision := ApprovalDecision(parts[1])
+	if decision != ApprovalDecisionApproved && decision != ApprovalDecisionRejected {
+		return "", uuid.Nil, "", fmt.Errorf("unknown approval decision %q", parts[1])
+	}
+
+	signalUUID, err := uuid.Parse(parts[2])
+	if err != nil {
+		return "", uuid.Nil, "", fmt.Errorf("invalid signal UUID in custom_id: %w", err)
+	}
+
+	return decision, signalUUID, parts[3], nil
+}
@@ -0,0 +1,86 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseApprovalCustomID(t *testing.T) {
+	signalUUID := uuid.New()
+
+	tests := []struct {
+		name         string
+		customID     string
+		wantDecision ApprovalDecision
+		wantStep     string
+		wantErr      bool
+	}{
+		{
+			name:         "approved buy",
+			customID:     ApprovalCustomID(ApprovalDecisionApproved, signalUUID, "buy"),
+			wantDecision: ApprovalDecisionApproved,
+			wantStep:     "buy",
+		},
+		{
+			name:         "rejected sell",
+			customID:     ApprovalCustomID(ApprovalDecisionRejected, signalUUID, "sell"),
+			wantDecision: ApprovalDecisionRejected,
+			wantStep:     "sell",
+		},
+		{name: "pending is not a decision", customID: ApprovalCustomID(ApprovalDecisionPending, signalUUID, "buy"), wantErr: true},
+		{name: "another button", customID: "signal:" + signalUUID.String(), wantErr: true},
+		{name: "wrong prefix", customID: "order:APPROVED:" + signalUUID.String() + ":buy", wantErr: true},
+		{name: "missing step", customID: "approval:APPROVED:" + signalUUID.String(), wantErr: true},
+		{name: "extra part", customID: ApprovalCustomID(ApprovalDecisionApproved, signalUUID, "buy") + ":1", wantErr: true},
+		{name: "invalid UUID", customID: "approval:APPROVED:not-a-uuid:buy", wantErr: true},
+		{name: "empty", customID: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, gotUUID, step, err := ParseApprovalCustomID(tt.customID)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseApprovalCustomID(%q) = %s, %s, %s, want an error", tt.customID, decision, gotUUID, step)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseApprovalCustomID(%q) error = %v", tt.customID, err)
+			}
+			if decision != tt.wantDecision || gotUUID != signalUUID || step != tt.wantStep {
+				t.Errorf("ParseApprovalCustomID(%q) = %s, %s, %s, want %s, %s, %s",
+					tt.customID, decision, gotUUID, step, tt.wantDecision, signalUUID, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestOrderApprovalIsOpen(t *testing.T) {
+	deadline := time.Date(2026, 11, 23, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		decision ApprovalDecision
+		now      time.Time
+		want     bool
+	}{
+		{name: "pending before the deadline", decision: ApprovalDecisionPending, now: deadline.Add(-time.Minute), want: true},
+		{name: "pending at the deadline", decision: ApprovalDecisionPending, now: deadline, want: true},
+		{name: "pending after the deadline", decision: ApprovalDecisionPending, now: deadline.Add(time.Second)},
+		{name: "already approved", decision: ApprovalDecisionApproved, now: deadline.Add(-time.Minute)},
+		{name: "already rejected", decision: ApprovalDecisionRejected, now: deadline.Add(-time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approval := &OrderApproval{Decision: tt.decision, Deadline: deadline}
+			if got := approval.IsOpen(tt.now); got != tt.want {
+				t.Errorf("IsOpen() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
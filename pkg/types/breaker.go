@@ -0,0 +1,55 @@
+package types
+
+import "time"
+
+// CircuitBreakerRule names the loss threshold that tripped the circuit breaker
+type CircuitBreakerRule string
+
+const (
+	CircuitBreakerRuleDailyLoss CircuitBreakerRule = "MAX_DAILY_LOSS_PERCENT" // Equity fell too far below the previous close
+	CircuitBreakerRuleDrawdown  CircuitBreakerRule = "MAX_DRAWDOWN_PERCENT"   // Equity fell too far below its peak
+)
+
+// CircuitBreaker is the persisted state of the loss circuit breaker. The
+// trading bot follows the account's peak equity in it and trips it when a loss
+// threshold is passed. A tripped breaker blocks new buys until it is cleared by
+// hand, sells keep going.
+type CircuitBreaker struct {
+	Tripped   bool               `json:"tripped"`
+	Rule      CircuitBreakerRule `json:"rule,omitempty"`
+	Reason    string             `json:"reason,omitempty"`
+	TrippedAt time.Time          `json:"tripped_at,omitempty"`
+
+	// Equity marks the drawdown is measured from
+	PeakEquity float64   `json:"peak_equity,omitempty"`
+	PeakAt     time.Time `json:"peak_at,omitempty"`
+	LastEquity float64   `json:"last_equity,omitempty"` // Equity seen by the latest run
+
+	ClearedBy string    `json:"cleared_by,omitempty"`
+	ClearedAt time.Time `json:"cleared_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Version of the stored record, used for optimistic concurrency between
+	// the trading bot and a manual clear
+	Version int64 `json:"version"`
+}
+
+// Trip halts new buys because rule was broken
+func (b *CircuitBreaker) Trip(rule CircuitBreakerRule, reason string, now time.Time) {
+	b.Tripped = true
+	b.Rule = rule
+	b.Reason = reason
+	b.TrippedAt = now
+	b.UpdatedAt = now
+}
+
+// Clear lets buys resume. The peak is forgotten so the drawdown is measured
+// again from the equity at the next run.
+func (b *CircuitBreaker) Clear(by string, now time.Time) {
+	b.Tripped = false
+	b.PeakEquity = 0
+	b.PeakAt = time.Time{}
+	b.ClearedBy = by
+	b.ClearedAt = now
+	b.UpdatedAt = now
+}
@@ -0,0 +1,94 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TradingMode is the global switch that decides which orders the trading bot may place
+type TradingMode string
+
+const (
+	TradingModeNormal       TradingMode = "NORMAL"        // Buy and sell as scheduled
+	TradingModeSellsOnly    TradingMode = "SELLS_ONLY"    // No new buys, sells as scheduled
+	TradingModePaused       TradingMode = "PAUSED"        // Runs exit before touching anything
+	TradingModeLiquidateAll TradingMode = "LIQUIDATE_ALL" // No new buys, every held position is sold now
+)
+
+// TradingModes lists every trading mode
+var TradingModes = []TradingMode{
+	TradingModeNormal,
+	TradingModeSellsOnly,
+	TradingModePaused,
+	TradingModeLiquidateAll,
+}
+
+// maxTradingModeHistory is how many mode changes are kept on the control item
+const maxTradingModeHistory = 50
+
+// ParseTradingMode parses a mode name such as "sells only", "sells-only" or "SELLS_ONLY"
+func ParseTradingMode(value string) (TradingMode, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(value))
+	normalized = strings.NewReplacer(" ", "_", "-", "_").Replace(normalized)
+
+	for _, mode := range TradingModes {
+		if TradingMode(normalized) == mode {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown trading mode %q", value)
+}
+
+// AllowsBuys reports whether new buy orders may be placed
+func (m TradingMode) AllowsBuys() bool {
+	return m == TradingModeNormal
+}
+
+// TradingModeChange is an audit entry for a change of the trading mode
+type TradingModeChange struct {
+	From      TradingMode `json:"from"`
+	To        TradingMode `json:"to"`
+	Reason    string      `json:"reason,omitempty"`
+	ChangedBy string      `json:"changed_by"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
+
+// TradingControl is the persisted kill switch of the trading bot. A missing
+// control item means TradingModeNormal.
+type TradingControl struct {
+	Mode      TradingMode `json:"mode"`
+	Reason    string      `json:"reason,omitempty"`
+	UpdatedBy string      `json:"updated_by,omitempty"`
+	UpdatedAt time.Time   `json:"updated_at"`
+
+	// History lists the latest mode changes, oldest first
+	History []TradingModeChange `json:"history,omitempty"`
+
+	// Version of the stored record, used for optimistic concurrency
+	Version int64 `json:"version"`
+}
+
+// SetMode switches the trading mode and records who did it and why
+func (c *TradingControl) SetMode(mode TradingMode, changedBy, reason string, now time.Time) {
+	from := c.Mode
+	if from == "" {
+		from = TradingModeNormal
+	}
+
+	c.History = append(c.History, TradingModeChange{
+		From:      from,
+		To:        mode,
+		Reason:    reason,
+		ChangedBy: changedBy,
+		ChangedAt: now,
+	})
+	if len(c.History) > maxTradingModeHistory {
+		c.History = c.History[len(c.History)-maxTradingModeHistory:]
+	}
+
+	c.Mode = mode
+	c.Reason = reason
+	c.UpdatedBy = changedBy
+	c.UpdatedAt = now
+}
@@ -0,0 +1,14 @@
+package types
+
+import "time"
+
+// MarketTimezone is the timezone trading sessions and signal dates are evaluated in
+const MarketTimezone = "America/New_York"
+
+// SessionDate returns the calendar date of t in location, the market timezone,
+// as midnight UTC. Signal buy and sell dates and allocation window dates are
+// stored this way, so the result can be compared with them directly.
+func SessionDate(t time.Time, location *time.Location) time.Time {
+	year, month, day := t.In(location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
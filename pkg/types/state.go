@@ -0,0 +1,123 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidTransition is returned when a status change is not allowed by the state machine
+var ErrInvalidTransition = errors.New("invalid signal status transition")
+
+// StatusTransition records a single status change of a signal
+type StatusTransition struct {
+	From   SignalStatus `json:"from"`
+	To     SignalStatus `json:"to"`
+	Reason string       `json:"reason"`
+	At     time.Time    `json:"at"`
+}
+
+// signalTransitions is the signal state machine: the statuses each status may move to
+var signalTransitions = map[SignalStatus][]SignalStatus{
+	SignalStatusWaitlisted: {
+		SignalStatusPending, // Promoted once the allocation window has a free slot
+		SignalStatusExpired,
+		SignalStatusCancelled,
+	},
+	SignalStatusPending: {
+		SignalStatusBuying,
+		SignalStatusFailed,
+		SignalStatusExpired,
+		SignalStatusCancelled,
+	},
+	SignalStatusBuying: {
+		SignalStatusPartiallyFilled,
+		SignalStatusBought,
+		SignalStatusPending, // Order ended without fills and will be retried
+		SignalStatusFailed,
+	},
+	SignalStatusPartiallyFilled: {
+		SignalStatusBought,
+	},
+	SignalStatusBought: {
+		SignalStatusSelling,
+	},
+	SignalStatusSelling: {
+		SignalStatusBought, // Order ended before every share was sold and will be retried
+		SignalStatusCompleted,
+	},
+	SignalStatusCompleted: {},
+	SignalStatusFailed:    {},
+	SignalStatusExpired:   {},
+	SignalStatusCancelled: {},
+}
+
+// IsValid reports whether the status is known to the state machine
+func (s SignalStatus) IsValid() bool {
+	_, ok := signalTransitions[s]
+	return ok
+}
+
+// IsTerminal reports whether no further transition is possible from the status
+func (s SignalStatus) IsTerminal() bool {
+	next, ok := signalTransitions[s]
+	return ok && len(next) == 0
+}
+
+// CanTransitionTo reports whether the state machine allows moving to the given status
+func (s SignalStatus) CanTransitionTo(to SignalStatus) bool {
+	for _, next := range signalTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition returns ErrInvalidTransition if moving from one status to the other is not allowed
+func ValidateTransition(from, to SignalStatus) error {
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// ValidateTransitionFrom returns ErrInvalidTransition unless the signal's
+// history records a path of allowed transitions from the given status to its
+// current one. A run can move a signal more than one step before saving it,
+// PENDING to BUYING to BOUGHT when an order fills at once.
+func (s *Signal) ValidateTransitionFrom(from SignalStatus) error {
+	status := s.Status
+	for i := len(s.History) - 1; i >= 0 && status != from; i-- {
+		step := s.History[i]
+		if step.To != status || !step.From.CanTransitionTo(step.To) {
+			break
+		}
+		status = step.From
+	}
+	if status == from {
+		return nil
+	}
+	return ValidateTransition(from, s.Status)
+}
+
+// Transition moves the signal to status, recording the reason in its history.
+// The signal is left unchanged if the state machine does not allow the change.
+func (s *Signal) Transition(to SignalStatus, reason string) error {
+	if err := ValidateTransition(s.Status, to); err != nil {
+		return fmt.Errorf("signal %s: %w", s.UUID, err)
+	}
+
+	now := time.Now()
+	s.History = append(s.History, StatusTransition{
+		From:   s.Status,
+		To:     to,
+		Reason: reason,
+		At:     now,
+	})
+	s.Status = to
+	s.StatusReason = reason
+	s.UpdatedAt = now
+
+	return nil
+}
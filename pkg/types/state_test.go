@@ -0,0 +1,124 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSignalTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    SignalStatus
+		to      SignalStatus
+		wantErr bool
+	}{
+		{name: "waitlisted is promoted", from: SignalStatusWaitlisted, to: SignalStatusPending},
+		{name: "waitlisted cannot be bought", from: SignalStatusWaitlisted, to: SignalStatusBuying, wantErr: true},
+		{name: "pending places a buy", from: SignalStatusPending, to: SignalStatusBuying},
+		{name: "pending is cancelled", from: SignalStatusPending, to: SignalStatusCancelled},
+		{name: "pending cannot skip the buy order", from: SignalStatusPending, to: SignalStatusBought, wantErr: true},
+		{name: "buy without fills is retried", from: SignalStatusBuying, to: SignalStatusPending},
+		{name: "buy partially fills", from: SignalStatusBuying, to: SignalStatusPartiallyFilled},
+		{name: "partial fill cannot go back to pending", from: SignalStatusPartiallyFilled, to: SignalStatusPending, wantErr: true},
+		{name: "bought places a sell", from: SignalStatusBought, to: SignalStatusSelling},
+		{name: "bought cannot be cancelled", from: SignalStatusBought, to: SignalStatusCancelled, wantErr: true},
+		{name: "partial sell is retried", from: SignalStatusSelling, to: SignalStatusBought},
+		{name: "sell completes", from: SignalStatusSelling, to: SignalStatusCompleted},
+		{name: "completed is terminal", from: SignalStatusCompleted, to: SignalStatusPending, wantErr: true},
+		{name: "cancelled is terminal", from: SignalStatusCancelled, to: SignalStatusPending, wantErr: true},
+		{name: "same status is not a transition", from: SignalStatusPending, to: SignalStatusPending, wantErr: true},
+		{name: "unknown status", from: SignalStatus("UNKNOWN"), to: SignalStatusPending, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal := Signal{Status: tt.from}
+
+			err := signal.Transition(tt.to, "test")
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTransition) {
+					t.Fatalf("Transition(%s -> %s) error = %v, want ErrInvalidTransition", tt.from, tt.to, err)
+				}
+				if signal.Status != tt.from || len(signal.History) != 0 || !signal.UpdatedAt.IsZero() {
+					t.Errorf("rejected transition changed the signal: status %s, %d history entries", signal.Status, len(signal.History))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Transition(%s -> %s) error = %v", tt.from, tt.to, err)
+			}
+			if signal.Status != tt.to || signal.StatusReason != "test" {
+				t.Errorf("status = %s (%q), want %s (%q)", signal.Status, signal.StatusReason, tt.to, "test")
+			}
+			if len(signal.History) != 1 || signal.History[0].From != tt.from || signal.History[0].To != tt.to {
+				t.Errorf("history = %+v, want a single %s -> %s entry", signal.History, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestSignalValidateTransitionFrom(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   SignalStatus
+		steps   []SignalStatus
+		from    SignalStatus
+		wantErr bool
+	}{
+		{name: "unchanged signal", start: SignalStatusPending, from: SignalStatusPending},
+		{name: "single step", start: SignalStatusPending, steps: []SignalStatus{SignalStatusBuying}, from: SignalStatusPending},
+		{
+			name:  "order filled at once",
+			start: SignalStatusPending,
+			steps: []SignalStatus{SignalStatusBuying, SignalStatusBought},
+			from:  SignalStatusPending,
+		},
+		{
+			name:  "sold in the same run it was bought",
+			start: SignalStatusPending,
+			steps: []SignalStatus{SignalStatusBuying, SignalStatusBought, SignalStatusSelling, SignalStatusCompleted},
+			from:  SignalStatusPending,
+		},
+		{
+			name:  "from an intermediate status",
+			start: SignalStatusPending,
+			steps: []SignalStatus{SignalStatusBuying, SignalStatusBought},
+			from:  SignalStatusBuying,
+		},
+		{
+			name:    "stored status before the recorded path",
+			start:   SignalStatusPending,
+			steps:   []SignalStatus{SignalStatusBuying, SignalStatusBought},
+			from:    SignalStatusWaitlisted,
+			wantErr: true,
+		},
+		{
+			name:    "no history",
+			start:   SignalStatusBought,
+			from:    SignalStatusPending,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal := Signal{Status: tt.start}
+			for _, step := range tt.steps {
+				if err := signal.Transition(step, "test"); err != nil {
+					t.Fatalf("Transition(%s) error = %v", step, err)
+				}
+			}
+
+			err := signal.ValidateTransitionFrom(tt.from)
+
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("ValidateTransitionFrom(%s) error = %v, want error %v", tt.from, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTransition) {
+				t.Errorf("error = %v, want ErrInvalidTransition", err)
+			}
+		})
+	}
+}
@@ -10,17 +10,64 @@ import (
 type SignalStatus string
 
 const (
-	SignalStatusPending   SignalStatus = "PENDING"
-	SignalStatusBought    SignalStatus = "BOUGHT"
-	SignalStatusCompleted SignalStatus = "COMPLETED"
+	SignalStatusWaitlisted      SignalStatus = "WAITLISTED"       // Added while the allocation window was full, waiting for a slot
+	SignalStatusPending         SignalStatus = "PENDING"          // Waiting for its buy date
+	SignalStatusBuying          SignalStatus = "BUYING"           // Buy order placed, nothing filled yet
+	SignalStatusPartiallyFilled SignalStatus = "PARTIALLY_FILLED" // Buy order still working with some shares filled
+	SignalStatusBought          SignalStatus = "BOUGHT"           // Position held until the sell date
+	SignalStatusSelling         SignalStatus = "SELLING"          // Sell order placed and working
+	SignalStatusCompleted       SignalStatus = "COMPLETED"        // Position sold, moved to the trade history
+	SignalStatusFailed          SignalStatus = "FAILED"           // Cannot be traded, e.g. the broker rejected the order
+	SignalStatusExpired         SignalStatus = "EXPIRED"          // Never bought within its buy window
+	SignalStatusCancelled       SignalStatus = "CANCELLED"        // Cancelled by a user before it was bought
 )
 
+// ActiveSignalStatuses lists the statuses of signals the trading bot still has to act on
+var ActiveSignalStatuses = []SignalStatus{
+	SignalStatusWaitlisted,
+	SignalStatusPending,
+	SignalStatusBuying,
+	SignalStatusPartiallyFilled,
+	SignalStatusBought,
+	SignalStatusSelling,
+}
+
+// AllSignalStatuses lists every signal status
+var AllSignalStatuses = []SignalStatus{
+	SignalStatusWaitlisted,
+	SignalStatusPending,
+	SignalStatusBuying,
+	SignalStatusPartiallyFilled,
+	SignalStatusBought,
+	SignalStatusSelling,
+	SignalStatusCompleted,
+	SignalStatusFailed,
+	SignalStatusExpired,
+	SignalStatusCancelled,
+}
+
+// IsActive reports whether a signal in this status still has to be acted on
+func (s SignalStatus) IsActive() bool {
+	for _, status := range ActiveSignalStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
 // ItemType represents the type of item in the unified table
 type ItemType string
 
 const (
 	ItemTypeSignal     ItemType = "SIGNAL"
 	ItemTypeAllocation ItemType = "ALLOCATION"
+	ItemTypeIntent     ItemType = "INTENT"
+	ItemTypeLock       ItemType = "LOCK"
+	ItemTypeSchema     ItemType = "SCHEMA"
+	ItemTypeTrade      ItemType = "TRADE"
+	ItemTypeBreaker    ItemType = "BREAKER"
+	ItemTypeControl    ItemType = "CONTROL"
 )
 
 // UnifiedItem represents a single item in the unified DynamoDB table
@@ -31,6 +78,15 @@ type UnifiedItem struct {
 	Data      string    `json:"data" dynamodbav:"data"` // JSON data
 	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" dynamodbav:"updated_at"`
+	Version   int64     `json:"version" dynamodbav:"version"` // Optimistic concurrency version
+
+	// Secondary index keys, only set on signal items
+	Ticker   string `json:"ticker,omitempty" dynamodbav:"ticker,omitempty"`
+	BuyDate  string `json:"buy_date,omitempty" dynamodbav:"buy_date,omitempty"`   // YYYY-MM-DD
+	SellDate string `json:"sell_date,omitempty" dynamodbav:"sell_date,omitempty"` // YYYY-MM-DD
+
+	// ExpiresAt is the epoch second after which DynamoDB TTL removes the item, unset to keep it
+	ExpiresAt int64 `json:"expires_at,omitempty" dynamodbav:"expires_at,omitempty"`
 }
 
 // Signal represents a trading signal
@@ -45,14 +101,280 @@ type Signal struct {
 	SellPrice float64      `json:"sell_price"`
 	CreatedAt time.Time    `json:"created_at"`
 	UpdatedAt time.Time    `json:"updated_at"`
+
+	// Broker order IDs being followed until they reach a terminal state
+	BuyOrderID  string `json:"buy_order_id,omitempty"`
+	SellOrderID string `json:"sell_order_id,omitempty"`
+
+	// Order attempt counters used to derive deterministic client order IDs.
+	// They only advance once an order has ended without filling.
+	BuyOrderAttempt  int `json:"buy_order_attempt,omitempty"`
+	SellOrderAttempt int `json:"sell_order_attempt,omitempty"`
+
+	// Buy attempts that ended without a position, used to expire signals that
+	// cannot be bought, and the error of the latest one
+	FailedBuyAttempts int    `json:"failed_buy_attempts,omitempty"`
+	LastBuyError      string `json:"last_buy_error,omitempty"`
+
+	// Fill history used to build the trade record once the signal completes
+	BoughtAt     time.Time  `json:"bought_at,omitempty"`
+	SoldAt       time.Time  `json:"sold_at,omitempty"`
+	SoldQuantity float64    `json:"sold_quantity,omitempty"` // Shares sold so far, across partial sells
+	SellProceeds float64    `json:"sell_proceeds,omitempty"` // Proceeds of the shares sold so far
+	ExitReason   ExitReason `json:"exit_reason,omitempty"`
+
+	// Conviction from MinConviction to MaxConviction used by conviction-weighted
+	// sizing, 0 means DefaultConviction
+	Conviction int `json:"conviction,omitempty"`
+
+	// Priority from MinPriority to MaxPriority, higher priorities are funded
+	// first when more signals are due than there is cash or slots for
+	Priority int `json:"priority,omitempty"`
+
+	// Size of the latest buy and the inputs it was computed from
+	Sizing *PositionSize `json:"sizing,omitempty"`
+
+	// Part of the allocation the filled buy did not spend, from rounding down to
+	// whole shares or to the cent of a notional order
+	LeftoverCash float64 `json:"leftover_cash,omitempty"`
+
+	// ID of the allocation window the signal took a slot in when its buy was
+	// placed, and when it was first held back because the window was full
+	AllocationWindowID string     `json:"allocation_window_id,omitempty"`
+	WindowQueuedAt     *time.Time `json:"window_queued_at,omitempty"`
+
+	// Latest pre-trade risk rule that blocked the buy, cleared once a buy order is placed
+	RiskRejection *RiskRejection `json:"risk_rejection,omitempty"`
+
+	// Why a due signal was last left unfunded, cleared once a buy order is placed
+	CarryOver *CarryOver `json:"carry_over,omitempty"`
+
+	// Approval requested for the next order when orders need a human decision
+	Approval *OrderApproval `json:"approval,omitempty"`
+
+	// StatusReason explains the latest status change, History lists every change
+	StatusReason string             `json:"status_reason,omitempty"`
+	History      []StatusTransition `json:"history,omitempty"`
+
+	// Version of the stored record this signal was loaded from, used for
+	// optimistic concurrency on status transitions
+	Version int64 `json:"version"`
+}
+
+// ExitReason explains why a position was closed
+type ExitReason string
+
+const (
+	ExitReasonSellDate    ExitReason = "SELL_DATE"   // Sold on the signal's sell date
+	ExitReasonLiquidation ExitReason = "LIQUIDATION" // Sold early because the trading mode was LIQUIDATE_ALL
+)
+
+// Range of signal conviction
+const (
+	MinConviction     = 1
+	MaxConviction     = 5
+	DefaultConviction = 3
+)
+
+// EffectiveConviction returns the signal's conviction, DefaultConviction when unset
+func (s *Signal) EffectiveConviction() int {
+	if s.Conviction == 0 {
+		return DefaultConviction
+	}
+	return s.Conviction
+}
+
+// Range of signal priority
+const (
+	MinPriority = 0
+	MaxPriority = 9
+)
+
+// PositionSize records how a signal's buy was sized
+type PositionSize struct {
+	Strategy   string             `json:"strategy"`
+	Allocation float64            `json:"allocation"`
+	Inputs     map[string]float64 `json:"inputs,omitempty"`
+	Fallback   string             `json:"fallback,omitempty"` // Why the fixed count size was used instead of the strategy's
+	SizedAt    time.Time          `json:"sized_at"`
+}
+
+// RiskRejection records the pre-trade risk rule that blocked a signal's buy
+type RiskRejection struct {
+	Rule       string    `json:"rule"`
+	Reason     string    `json:"reason"`
+	RejectedAt time.Time `json:"rejected_at"`
+}
+
+// CarryOverReason is the kind of limit that kept a due signal from being funded
+type CarryOverReason string
+
+const (
+	CarryOverBuysHalted    CarryOverReason = "BUYS_HALTED"    // Trading mode or circuit breaker blocks new buys
+	CarryOverWindowFull    CarryOverReason = "WINDOW_FULL"    // The allocation window has no slot left
+	CarryOverNotSized      CarryOverReason = "NOT_SIZED"      // The sizing strategy allocated nothing to the signal
+	CarryOverQuoteRejected CarryOverReason = "QUOTE_REJECTED" // The quote failed the sanity checks
+	CarryOverRiskLimit     CarryOverReason = "RISK_LIMIT"     // A pre-trade risk rule blocked the buy
+)
+
+// CarryOver records why a due signal was not funded and carried over to the
+// next run, and where it ranked among the signals competing for the funds. It
+// only changes when the reason or the risk rule does, the figures are those of
+// the run the reason was recorded in.
+type CarryOver struct {
+	Reason     CarryOverReason `json:"reason,omitempty"`     // Empty once a run got as far as trying to buy the signal again
+	Rule       string          `json:"rule,omitempty"`       // RISK_LIMIT: the risk rule that blocked the buy
+	Allocation float64         `json:"allocation,omitempty"` // NOT_SIZED: what the signal was sized to
+	BidPrice   float64         `json:"bid_price,omitempty"`  // QUOTE_REJECTED: the rejected quote
+	AskPrice   float64         `json:"ask_price,omitempty"`
+	Rank       int             `json:"rank"`            // Funding rank in the run the reason was recorded in, 1 is funded first
+	Since      time.Time       `json:"since,omitempty"` // New York date of the first session it was carried over in for the reason
+	Sessions   int             `json:"sessions"`        // Sessions it was carried over in for earlier reasons
+	At         time.Time       `json:"at"`
+}
+
+// TradeRecord is the permanent history entry of a completed signal
+type TradeRecord struct {
+	SignalUUID uuid.UUID `json:"signal_uuid"`
+	Ticker     string    `json:"ticker"`
+	BuyDate    time.Time `json:"buy_date"`  // Planned buy date of the signal
+	SellDate   time.Time `json:"sell_date"` // Planned sell date of the signal
+	BoughtAt   time.Time `json:"bought_at"`
+	SoldAt     time.Time `json:"sold_at"`
+
+	Quantity  float64 `json:"quantity"`
+	BuyPrice  float64 `json:"buy_price"`  // Average buy fill price
+	SellPrice float64 `json:"sell_price"` // Average sell fill price across all sell orders
+	CostBasis float64 `json:"cost_basis"`
+	Proceeds  float64 `json:"proceeds"`
+
+	RealizedPnL        float64    `json:"realized_pnl"`
+	RealizedPnLPercent float64    `json:"realized_pnl_percent"`
+	HoldingDays        int        `json:"holding_days"`
+	ExitReason         ExitReason `json:"exit_reason"`
+
+	BuyOrderID  string     `json:"buy_order_id,omitempty"`
+	SellOrderID string     `json:"sell_order_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // Removed from the history after this time when set
 }
 
 // AllocationWindow represents the rolling window for signal allocation
 type AllocationWindow struct {
 	WindowStartDate      time.Time `json:"window_start_date"`
 	WindowEndDate        time.Time `json:"window_end_date"`
-	AccountValue         float64   `json:"account_value"`
+	AccountValue         float64   `json:"account_value"` // Budget of the window, the account value when it opened
 	AllocationPerSignal  float64   `json:"allocation_per_signal"`
-	TotalSignalsInWindow int       `json:"total_signals_in_window"`
-	UpdatedAt            time.Time `json:"updated_at"`
+	TotalSignalsInWindow int       `json:"total_signals_in_window"` // Slots in the window
+
+	// Accounting of the buys and sells made while the window is current
+	SlotsUsed       int     `json:"slots_used"`       // Signals that placed a buy in the window
+	CapitalDeployed float64 `json:"capital_deployed"` // Cost of the buys filled in the window
+	CapitalReturned float64 `json:"capital_returned"` // Proceeds of the sells filled in the window
+	RemainingBudget float64 `json:"remaining_budget"` // Budget less the capital deployed plus the capital returned
+	LeftoverCash    float64 `json:"leftover_cash"`    // Allocations left unspent by buys rounded to whole shares or cents
+
+	UpdatedAt time.Time  `json:"updated_at"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"` // Set once the window was replaced and moved to the history
+
+	// Version of the stored record, used for optimistic concurrency between
+	// trading bot runs and the Discord bot
+	Version int64 `json:"version"`
+}
+
+// ID identifies the window by its start date
+func (w *AllocationWindow) ID() string {
+	return w.WindowStartDate.UTC().Format("2006-01-02")
+}
+
+// IsExpired reports whether the window has ended by currentDate, a session
+// date, and is replaced by the next run
+func (w *AllocationWindow) IsExpired(currentDate time.Time) bool {
+	return currentDate.After(w.WindowEndDate)
+}
+
+// SlotsRemaining returns how many more signals can take a slot in the window
+func (w *AllocationWindow) SlotsRemaining() int {
+	if w.SlotsUsed >= w.TotalSignalsInWindow {
+		return 0
+	}
+	return w.TotalSignalsInWindow - w.SlotsUsed
+}
+
+// HoldsSlot reports whether the signal took its slot in this window
+func (w *AllocationWindow) HoldsSlot(signal *Signal) bool {
+	return signal.AllocationWindowID != "" && signal.AllocationWindowID == w.ID()
+}
+
+// TakeSlot counts a slot for the signal, which keeps it until ReleaseSlot
+func (w *AllocationWindow) TakeSlot(signal *Signal, now time.Time) {
+	if w.HoldsSlot(signal) {
+		return
+	}
+	w.SlotsUsed++
+	signal.AllocationWindowID = w.ID()
+	w.refresh(now)
+}
+
+// ReleaseSlot gives back the signal's slot when its buy ended without a position
+func (w *AllocationWindow) ReleaseSlot(signal *Signal, now time.Time) {
+	if !w.HoldsSlot(signal) {
+		return
+	}
+	if w.SlotsUsed > 0 {
+		w.SlotsUsed--
+	}
+	signal.AllocationWindowID = ""
+	w.refresh(now)
+}
+
+// RecordDeployed counts the cost of a filled buy against the window's budget
+func (w *AllocationWindow) RecordDeployed(amount float64, now time.Time) {
+	w.CapitalDeployed += amount
+	w.refresh(now)
+}
+
+// RecordLeftover counts the part of a filled buy's allocation it did not spend.
+// It stays in the remaining budget, as only the cost was deployed.
+func (w *AllocationWindow) RecordLeftover(amount float64, now time.Time) {
+	w.LeftoverCash += amount
+	w.UpdatedAt = now
+}
+
+// RecordReturned adds the proceeds of a filled sell back to the window's budget
+func (w *AllocationWindow) RecordReturned(amount float64, now time.Time) {
+	w.CapitalReturned += amount
+	w.refresh(now)
+}
+
+// refresh recomputes the remaining budget after a change
+func (w *AllocationWindow) refresh(now time.Time) {
+	w.RemainingBudget = w.AccountValue - w.CapitalDeployed + w.CapitalReturned
+	w.UpdatedAt = now
+}
+
+// TransitionIntent records that an order is about to be placed for a signal,
+// so a run that dies before saving the result can be resumed or repaired
+type TransitionIntent struct {
+	SignalUUID    uuid.UUID    `json:"signal_uuid"`
+	Ticker        string       `json:"ticker"`
+	Step          string       `json:"step"` // "buy" or "sell"
+	FromStatus    SignalStatus `json:"from_status"`
+	ClientOrderID string       `json:"client_order_id"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// RunLock represents a lease held by a single bot execution
+type RunLock struct {
+	Name       string    `json:"name"`
+	Owner      string    `json:"owner"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// SchemaVersion records the last schema migration applied to the table
+type SchemaVersion struct {
+	Version     int       `json:"version"`
+	Description string    `json:"description"`
+	AppliedAt   time.Time `json:"applied_at"`
 }
@@ -2,14 +2,17 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/vignesh-goutham/artemis/pkg/store"
 	"github.com/vignesh-goutham/artemis/trading-bot/internal"
 )
 
@@ -50,24 +53,89 @@ func handler(ctx context.Context, request events.CloudWatchEvent) error {
 func loadConfigFromEnv() (*internal.Config, error) {
 	config := &internal.Config{}
 
-	// Required environment variables
-	config.AlpacaAPIKey = getEnvOrFail("ALPACA_API_KEY")
-	config.AlpacaSecretKey = getEnvOrFail("ALPACA_SECRET_KEY")
+	// Broker configuration
+	config.BrokerMode = getEnvOrDefault("BROKER_MODE", internal.BrokerModeAlpaca)
+	config.SimulatedStartingCash = getEnvAsFloatOrDefault("SIMULATED_STARTING_CASH", 100000.0)
+	config.SimulatedPriceFeedPath = getEnvOrDefault("SIMULATED_PRICE_FEED_PATH", "")
 
-	// DynamoDB configuration
+	// Alpaca credentials are only required when trading against Alpaca
+	if config.BrokerMode == internal.BrokerModeAlpaca {
+		config.AlpacaAPIKey = getEnvOrFail("ALPACA_API_KEY")
+		config.AlpacaSecretKey = getEnvOrFail("ALPACA_SECRET_KEY")
+	}
+
+	// Storage configuration
+	config.StoreBackend = getEnvOrDefault("STORE_BACKEND", store.BackendDynamoDB)
+	config.StoreFilePath = getEnvOrDefault("STORE_FILE_PATH", "artemis-store.json")
 	config.DynamoDBRegion = getEnvOrDefault("DYNAMODB_REGION", "us-east-1")
 	config.TableName = getEnvOrDefault("TABLE_NAME", "artemis-data")
+	config.DynamoDBEndpoint = getEnvOrDefault("DYNAMODB_ENDPOINT", "")
+	config.DynamoDBAccessKeyID = getEnvOrDefault("DYNAMODB_ACCESS_KEY_ID", "")
+	config.DynamoDBSecretAccessKey = getEnvOrDefault("DYNAMODB_SECRET_ACCESS_KEY", "")
 
 	// Trading configuration
 	config.MaxSignalsPerWindow = getEnvAsIntOrDefault("MAX_SIGNALS_PER_WINDOW", 39)
 	config.WindowDurationDays = getEnvAsIntOrDefault("WINDOW_DURATION_DAYS", 90)
+	config.WindowFullPolicy = getEnvOrDefault("WINDOW_FULL_POLICY", internal.WindowFullPolicyQueue)
+	config.SignalRanking = getEnvOrDefault("SIGNAL_RANKING", internal.DefaultSignalRanking)
 	config.DefaultAllocationAmount = getEnvAsFloatOrDefault("DEFAULT_ALLOCATION_AMOUNT", 1000.0)
+	config.OrderFillTimeoutSeconds = getEnvAsIntOrDefault("ORDER_FILL_TIMEOUT_SECONDS", 10)
+	config.RunLockTTLSeconds = getEnvAsIntOrDefault("RUN_LOCK_TTL_SECONDS", 120)
+	if config.RunLockTTLSeconds < internal.MinRunLockTTLSeconds {
+		return nil, fmt.Errorf("RUN_LOCK_TTL_SECONDS must be at least %d, got %d", internal.MinRunLockTTLSeconds, config.RunLockTTLSeconds)
+	}
+	config.MaxBuyAttempts = getEnvAsIntOrDefault("MAX_BUY_ATTEMPTS", 9)
+	config.MaxPendingDays = getEnvAsIntOrDefault("MAX_PENDING_DAYS", 0)
+	config.TradeHistoryRetentionDays = getEnvAsIntOrDefault("TRADE_HISTORY_RETENTION_DAYS", 0)
+
+	// Position sizing
+	config.SizingStrategy = getEnvOrDefault("SIZING_STRATEGY", internal.SizingStrategyFixedCount)
+	config.SizingEquityPercent = getEnvAsFloatOrDefault("SIZING_EQUITY_PERCENT", 2.5)
+	config.SizingRiskPercent = getEnvAsFloatOrDefault("SIZING_RISK_PERCENT", 0.5)
+	config.SizingATRPeriod = getEnvAsIntOrDefault("SIZING_ATR_PERIOD", 14)
+	config.SizingATRMultiplier = getEnvAsFloatOrDefault("SIZING_ATR_MULTIPLIER", 2.0)
+	config.SizingKellyFraction = getEnvAsFloatOrDefault("SIZING_KELLY_FRACTION", 0.25)
+	config.SizingKellyLookbackDays = getEnvAsIntOrDefault("SIZING_KELLY_LOOKBACK_DAYS", 365)
+	config.SizingKellyMinTrades = getEnvAsIntOrDefault("SIZING_KELLY_MIN_TRADES", 20)
+
+	// Trading calendar
+	config.CalendarCachePath = getEnvOrDefault("CALENDAR_CACHE_PATH", filepath.Join(os.TempDir(), "artemis-calendar.json"))
+	config.CalendarCacheTTLHours = getEnvAsIntOrDefault("CALENDAR_CACHE_TTL_HOURS", 24)
+	config.EnforceMarketHours = getEnvAsBoolOrDefault("ENFORCE_MARKET_HOURS", true)
+
+	// Dry run
+	config.DryRun = getEnvAsBoolOrDefault("DRY_RUN", false)
+	config.PlanOutputPath = getEnvOrDefault("PLAN_OUTPUT_PATH", "")
 
 	// Paper trading flag
 	config.IsPaperTrading = getEnvAsBoolOrDefault("IS_PAPER_TRADING", true)
 
+	// Pre-trade risk limits, off unless set
+	config.MaxTickerEquityPercent = getEnvAsFloatOrDefault("MAX_TICKER_EQUITY_PERCENT", 0.0)
+	config.MaxGrossExposurePercent = getEnvAsFloatOrDefault("MAX_GROSS_EXPOSURE_PERCENT", 0.0)
+	config.MinCashReserve = getEnvAsFloatOrDefault("MIN_CASH_RESERVE", 0.0)
+	config.MaxNewOrdersPerRun = getEnvAsIntOrDefault("MAX_NEW_ORDERS_PER_RUN", 0)
+	config.MinPrice = getEnvAsFloatOrDefault("MIN_PRICE", 0.0)
+	config.MaxPrice = getEnvAsFloatOrDefault("MAX_PRICE", 0.0)
+
+	// Order execution
+	config.ExecutionMode = getEnvOrDefault("EXECUTION_MODE", internal.ExecutionModeMarket)
+	config.MaxSlippageBps = getEnvAsFloatOrDefault("MAX_SLIPPAGE_BPS", 50.0)
+	config.QuoteMaxSpreadBps = getEnvAsFloatOrDefault("QUOTE_MAX_SPREAD_BPS", 200.0)
+	config.QuoteMaxAgeSeconds = getEnvAsIntOrDefault("QUOTE_MAX_AGE_SECONDS", 300)
+
+	// Loss circuit breaker, off unless set
+	config.MaxDailyLossPercent = getEnvAsFloatOrDefault("MAX_DAILY_LOSS_PERCENT", 0.0)
+	config.MaxDrawdownPercent = getEnvAsFloatOrDefault("MAX_DRAWDOWN_PERCENT", 0.0)
+
+	// Order approval
+	config.RequireApproval = getEnvAsBoolOrDefault("REQUIRE_APPROVAL", false)
+	config.ApprovalDeadlineMinutes = getEnvAsIntOrDefault("APPROVAL_DEADLINE_MINUTES", 0)
+
 	// Discord notifications
 	config.DiscordWebhookURL = getEnvOrDefault("DISCORD_WEBHOOK_URL", "")
+	config.DiscordBotToken = getEnvOrDefault("DISCORD_BOT_TOKEN", "")
+	config.DiscordApprovalChannelID = getEnvOrDefault("DISCORD_APPROVAL_CHANNEL_ID", "")
 
 	return config, nil
 }
@@ -133,5 +201,14 @@ func getEnvAsBoolOrDefault(key string, defaultValue bool) bool {
 }
 
 func main() {
+	// Run a single pass directly when not running inside Lambda
+	if getEnvAsBoolOrDefault("RUN_LOCAL", false) {
+		err := handler(context.Background(), events.CloudWatchEvent{ID: "local"})
+		if err != nil {
+			log.Fatalf("Local run failed: %v", err)
+		}
+		return
+	}
+
 	lambda.Start(handler)
 }
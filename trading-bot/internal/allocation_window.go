@@ -0,0 +1,210 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// validateWindowFullPolicy checks the configured policy for a full allocation window
+func validateWindowFullPolicy(policy string) error {
+	switch policy {
+	case "", WindowFullPolicyReject, WindowFullPolicyQueue, WindowFullPolicyShrink:
+		return nil
+	default:
+		return fmt.Errorf("unknown window full policy: %s", policy)
+	}
+}
+
+// windowAdmission decides whether a due signal can take a slot in the current
+// allocation window. It returns the base allocation to size the buy from, or
+// why the signal cannot be bought in this window and whether it is rejected
+// rather than queued. A signal that already holds a slot keeps it.
+func (tb *TradingBot) windowAdmission(signal *types.Signal, allocationPerSignal float64) (float64, string, bool) {
+	window := tb.allocationWindow
+	if window == nil || window.HoldsSlot(signal) || window.SlotsRemaining() > 0 {
+		return allocationPerSignal, "", false
+	}
+
+	reason := fmt.Sprintf("Allocation window %s has used all %d slots", window.ID(), window.TotalSignalsInWindow)
+
+	switch tb.config.WindowFullPolicy {
+	case WindowFullPolicyReject:
+		return 0, reason, true
+	case WindowFullPolicyShrink:
+		if window.RemainingBudget <= 0 {
+			return 0, reason + " and has no budget left", false
+		}
+		// Split the budget as if the window had one more slot than it has used
+		shrunk := window.AccountValue / float64(window.SlotsUsed+1)
+		return math.Min(math.Min(shrunk, allocationPerSignal), window.RemainingBudget), "", false
+	default:
+		return 0, reason, false
+	}
+}
+
+// queueForWindow keeps a signal pending until the next allocation window. The
+// first time it is held back is recorded on the signal, which exempts it from
+// MaxPendingDays while it waits.
+func (tb *TradingBot) queueForWindow(signal *types.Signal, reason string) {
+	log.Printf("Signal %s queued for the next allocation window: %s", signal.UUID, reason)
+
+	if signal.WindowQueuedAt == nil {
+		now := time.Now()
+		signal.WindowQueuedAt = &now
+		signal.UpdatedAt = now
+	}
+}
+
+// takeWindowSlot counts a placed buy against the allocation window
+func (tb *TradingBot) takeWindowSlot(signal *types.Signal) {
+	if tb.allocationWindow == nil {
+		return
+	}
+
+	tb.allocationWindow.TakeSlot(signal, time.Now())
+	signal.WindowQueuedAt = nil
+	tb.windowChanged = true
+}
+
+// releaseWindowSlot gives back the slot of a buy that ended without a position
+func (tb *TradingBot) releaseWindowSlot(signal *types.Signal) {
+	if tb.allocationWindow == nil || !tb.allocationWindow.HoldsSlot(signal) {
+		return
+	}
+
+	tb.allocationWindow.ReleaseSlot(signal, time.Now())
+	tb.windowChanged = true
+}
+
+// recordWindowDeployed counts the cost of a filled buy against the current window
+func (tb *TradingBot) recordWindowDeployed(amount float64) {
+	if tb.allocationWindow == nil || amount <= 0 {
+		return
+	}
+
+	tb.allocationWindow.RecordDeployed(amount, time.Now())
+	tb.windowChanged = true
+}
+
+// recordLeftoverCash records the part of the signal's allocation that its
+// filled buy did not spend, on the signal and in the current window. Only fully
+// filled buys are counted, what a cancelled partial fill leaves is not rounding.
+func (tb *TradingBot) recordLeftoverCash(signal *types.Signal, cost float64) {
+	if signal.Sizing == nil {
+		return
+	}
+
+	leftover := signal.Sizing.Allocation - cost
+	if leftover <= 0 {
+		// The ask moved up between sizing and the fill, nothing was left over
+		signal.LeftoverCash = 0
+		return
+	}
+
+	signal.LeftoverCash = leftover
+	log.Printf("Buy for signal %s left $%.2f of its $%.2f allocation unspent", signal.UUID, leftover, signal.Sizing.Allocation)
+
+	if tb.allocationWindow != nil {
+		tb.allocationWindow.RecordLeftover(leftover, time.Now())
+		tb.windowChanged = true
+	}
+}
+
+// recordWindowReturned adds the proceeds of a filled sell to the current window
+func (tb *TradingBot) recordWindowReturned(amount float64) {
+	if tb.allocationWindow == nil || amount <= 0 {
+		return
+	}
+
+	tb.allocationWindow.RecordReturned(amount, time.Now())
+	tb.windowChanged = true
+}
+
+// persistAllocationWindow writes the window's accounting right after it
+// changed, like persistSignal does for signals. A failed write is retried when
+// the run saves its data.
+func (tb *TradingBot) persistAllocationWindow(ctx context.Context) {
+	if err := tb.saveAllocationWindow(ctx); err != nil {
+		log.Printf("Warning: Failed to persist allocation window, will retry at end of run: %v", err)
+	}
+}
+
+// maxWindowWriteAttempts bounds how often a write of the allocation window is
+// retried after a signal admission bumped its version
+const maxWindowWriteAttempts = 3
+
+// saveAllocationWindow writes the allocation window if it changed since it was
+// last saved. The write is checked against the version the window was loaded
+// at, so a stale window fails with a version conflict instead of overwriting
+// the stored one.
+func (tb *TradingBot) saveAllocationWindow(ctx context.Context) error {
+	if !tb.windowChanged || tb.allocationWindow == nil {
+		return nil
+	}
+
+	window := tb.allocationWindow
+	err := tb.retryReservedWindow(ctx, window.ID(), tb.windowStoredAt,
+		func(version int64) { window.Version = version },
+		func() error { return tb.dbService.SaveAllocationWindow(ctx, window) })
+	if err != nil {
+		return err
+	}
+
+	tb.windowChanged = false
+	tb.windowStoredAt = window.UpdatedAt
+	return nil
+}
+
+// archiveAllocationWindow moves a window that was replaced to the window
+// history and saves its replacement in the same transaction, so
+// ALLOCATION#CURRENT only ever holds the live window. The run stops if the
+// closed window cannot be archived, the stored window is left unchanged and
+// the next run replaces it again.
+func (tb *TradingBot) archiveAllocationWindow(ctx context.Context, closed *types.AllocationWindow) error {
+	now := time.Now()
+	closed.ClosedAt = &now
+
+	err := tb.retryReservedWindow(ctx, closed.ID(), closed.UpdatedAt,
+		func(version int64) { closed.Version = version },
+		func() error { return tb.dbService.ArchiveAllocationWindow(ctx, *closed, tb.allocationWindow) })
+	if err != nil {
+		return fmt.Errorf("failed to archive allocation window %s: %w", closed.ID(), err)
+	}
+
+	log.Printf("Archived allocation window %s: %d of %d slots used, $%.2f deployed, $%.2f returned",
+		closed.ID(), closed.SlotsUsed, closed.TotalSignalsInWindow, closed.CapitalDeployed, closed.CapitalReturned)
+
+	tb.windowChanged = false
+	tb.windowStoredAt = tb.allocationWindow.UpdatedAt
+	return nil
+}
+
+// retryReservedWindow runs write, a version-checked write of the allocation
+// window with the given ID as it was stored at storedAt. Admitting a signal
+// bumps the window's version without changing its accounting, so when write
+// fails with a version conflict and the stored window is otherwise unchanged,
+// its version is passed to adopt and the write is retried. Any other change
+// of the stored window is returned as the conflict.
+func (tb *TradingBot) retryReservedWindow(ctx context.Context, id string, storedAt time.Time, adopt func(version int64), write func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if !errors.Is(err, store.ErrVersionConflict) || attempt >= maxWindowWriteAttempts {
+			return err
+		}
+
+		stored, loadErr := tb.dbService.LoadAllocationWindow(ctx)
+		if loadErr != nil || stored == nil || stored.ID() != id || !stored.UpdatedAt.Equal(storedAt) {
+			return err
+		}
+
+		log.Printf("Allocation window %s is at version %d after a signal was admitted, writing it again", id, stored.Version)
+		adopt(stored.Version)
+	}
+}
@@ -0,0 +1,181 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+func TestWindowAdmission(t *testing.T) {
+	window := func(slotsUsed int, remainingBudget float64) *types.AllocationWindow {
+		return &types.AllocationWindow{
+			WindowStartDate:      date(t, "2026-11-23"),
+			AccountValue:         3000,
+			AllocationPerSignal:  1000,
+			TotalSignalsInWindow: 3,
+			SlotsUsed:            slotsUsed,
+			RemainingBudget:      remainingBudget,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		policy     string
+		window     *types.AllocationWindow
+		slotHolder bool // The signal already took a slot in the window
+		want       float64
+		wantReason bool
+		wantReject bool
+	}{
+		{name: "no window", want: 1000},
+		{name: "slot left", window: window(2, 1000), want: 1000},
+		{name: "full window queues by default", window: window(3, 500), wantReason: true},
+		{name: "full window rejects", policy: WindowFullPolicyReject, window: window(3, 500), wantReason: true, wantReject: true},
+		{name: "full window queues", policy: WindowFullPolicyQueue, window: window(3, 500), wantReason: true},
+		{name: "slot holder keeps its slot", policy: WindowFullPolicyReject, window: window(3, 0), slotHolder: true, want: 1000},
+		{name: "shrink splits one more slot", policy: WindowFullPolicyShrink, window: window(3, 900), want: 750},
+		{name: "shrink is capped at the remaining budget", policy: WindowFullPolicyShrink, window: window(3, 400), want: 400},
+		{name: "shrink without budget queues", policy: WindowFullPolicyShrink, window: window(3, 0), wantReason: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &TradingBot{
+				config:           &Config{WindowFullPolicy: tt.policy},
+				allocationWindow: tt.window,
+			}
+			signal := &types.Signal{UUID: uuid.New()}
+			if tt.slotHolder {
+				signal.AllocationWindowID = tt.window.ID()
+			}
+
+			got, reason, rejected := tb.windowAdmission(signal, 1000)
+
+			if got != tt.want || (reason != "") != tt.wantReason || rejected != tt.wantReject {
+				t.Errorf("windowAdmission() = %.2f, %q, %v, want %.2f, reason %v, rejected %v",
+					got, reason, rejected, tt.want, tt.wantReason, tt.wantReject)
+			}
+		})
+	}
+}
+
+func TestSaveAllocationWindowAfterConcurrentWrite(t *testing.T) {
+	ctx := context.Background()
+	openedAt := time.Date(2026, 11, 23, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		concurrent   func(t *testing.T, memory *store.MemoryStore)
+		wantConflict bool
+	}{
+		{
+			name: "signal admitted from Discord",
+			concurrent: func(t *testing.T, memory *store.MemoryStore) {
+				window, _ := memory.LoadAllocationWindow(ctx)
+				signal := types.Signal{UUID: uuid.New(), Ticker: "MSFT", Status: types.SignalStatusPending}
+				if err := memory.AdmitSignal(ctx, &signal, "", window); err != nil {
+					t.Fatalf("AdmitSignal() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "window changed by another run",
+			concurrent: func(t *testing.T, memory *store.MemoryStore) {
+				window, _ := memory.LoadAllocationWindow(ctx)
+				window.SlotsUsed++
+				window.UpdatedAt = openedAt.Add(time.Hour)
+				if err := memory.SaveAllocationWindow(ctx, window); err != nil {
+					t.Fatalf("SaveAllocationWindow() error = %v", err)
+				}
+			},
+			wantConflict: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := store.NewMemoryStore()
+			opened := &types.AllocationWindow{
+				WindowStartDate:      date(t, "2026-11-23"),
+				AccountValue:         3000,
+				TotalSignalsInWindow: 3,
+				RemainingBudget:      3000,
+				UpdatedAt:            openedAt,
+			}
+			if err := memory.SaveAllocationWindow(ctx, opened); err != nil {
+				t.Fatalf("SaveAllocationWindow() error = %v", err)
+			}
+
+			window, _ := memory.LoadAllocationWindow(ctx)
+			tb := &TradingBot{
+				dbService:        memory,
+				allocationWindow: window,
+				windowStoredAt:   window.UpdatedAt,
+			}
+
+			tt.concurrent(t, memory)
+			tb.takeWindowSlot(&types.Signal{UUID: uuid.New()})
+			err := tb.saveAllocationWindow(ctx)
+
+			stored, _ := memory.LoadAllocationWindow(ctx)
+			if tt.wantConflict {
+				if !errors.Is(err, store.ErrVersionConflict) {
+					t.Fatalf("saveAllocationWindow() error = %v, want ErrVersionConflict", err)
+				}
+				if !tb.windowChanged || stored.SlotsUsed != 1 {
+					t.Errorf("stored window has %d slots used, want the other run's write kept", stored.SlotsUsed)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("saveAllocationWindow() error = %v", err)
+			}
+			if tb.windowChanged || stored.SlotsUsed != 1 || stored.Version != 3 {
+				t.Errorf("stored window has %d slots used at version %d, want 1 at version 3", stored.SlotsUsed, stored.Version)
+			}
+			if !tb.windowStoredAt.Equal(stored.UpdatedAt) {
+				t.Errorf("window stored at %s, want %s", tb.windowStoredAt, stored.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestRecordLeftoverCash(t *testing.T) {
+	tests := []struct {
+		name         string
+		allocation   float64 // 0 for a signal bought without a recorded size
+		cost         float64
+		wantLeftover float64
+	}{
+		{name: "whole shares leave cash over", allocation: 1000, cost: 990, wantLeftover: 10},
+		{name: "fully spent", allocation: 1000, cost: 1000},
+		{name: "ask moved up before the fill", allocation: 1000, cost: 1005},
+		{name: "no recorded size", cost: 990},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &TradingBot{allocationWindow: &types.AllocationWindow{AccountValue: 3000, RemainingBudget: 3000}}
+			signal := &types.Signal{UUID: uuid.New()}
+			if tt.allocation > 0 {
+				signal.Sizing = &types.PositionSize{Allocation: tt.allocation}
+			}
+
+			tb.recordLeftoverCash(signal, tt.cost)
+
+			if signal.LeftoverCash != tt.wantLeftover || tb.allocationWindow.LeftoverCash != tt.wantLeftover {
+				t.Errorf("leftover = $%.2f on the signal, $%.2f in the window, want $%.2f",
+					signal.LeftoverCash, tb.allocationWindow.LeftoverCash, tt.wantLeftover)
+			}
+			if tb.windowChanged != (tt.wantLeftover > 0) {
+				t.Errorf("window changed = %v, want %v", tb.windowChanged, tt.wantLeftover > 0)
+			}
+		})
+	}
+}
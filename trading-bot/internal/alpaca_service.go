@@ -2,9 +2,11 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math"
+	"strings"
 	"time"
 
 	alpaca "github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
@@ -12,6 +14,9 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// alpacaErrCodeNotFound is the error code Alpaca returns when a resource does not exist
+const alpacaErrCodeNotFound = 40410000
+
 // AlpacaService handles all Alpaca trading operations
 type AlpacaService struct {
 	client     alpaca.Client
@@ -75,6 +80,18 @@ func (a *AlpacaService) GetCashBalance(ctx context.Context) (float64, error) {
 	return cash, nil
 }
 
+// GetPreviousCloseEquity retrieves the account equity as of the previous trading day's close
+func (a *AlpacaService) GetPreviousCloseEquity(ctx context.Context) (float64, error) {
+	account, err := a.client.GetAccount()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	// Convert from decimal to float64
+	equity, _ := account.LastEquity.Float64()
+	return equity, nil
+}
+
 // GetCurrentPrice retrieves the current ask price for a ticker (for buying)
 func (a *AlpacaService) GetCurrentPrice(ctx context.Context, ticker string) (float64, error) {
 	// Get the latest quote
@@ -99,29 +116,55 @@ func (a *AlpacaService) GetBidPrice(ctx context.Context, ticker string) (float64
 	return quote.BidPrice, nil
 }
 
-// IsFractionable checks if a ticker supports fractional shares
-func (a *AlpacaService) IsFractionable(ctx context.Context, ticker string) (bool, error) {
-	asset, err := a.client.GetAsset(ticker)
+// GetQuote retrieves the latest bid and ask for a ticker
+func (a *AlpacaService) GetQuote(ctx context.Context, ticker string) (Quote, error) {
+	quote, err := a.marketData.GetLatestQuote(ticker)
 	if err != nil {
-		return false, fmt.Errorf("failed to get asset info for %s: %w", ticker, err)
+		return Quote{}, fmt.Errorf("failed to get latest quote for %s: %w", ticker, err)
 	}
-	return asset.Fractionable, nil
+
+	return Quote{
+		BidPrice:  quote.BidPrice,
+		AskPrice:  quote.AskPrice,
+		Timestamp: quote.Timestamp,
+	}, nil
 }
 
-// BuyStock executes a buy order for the specified ticker and allocation
-func (a *AlpacaService) BuyStock(ctx context.Context, ticker string, allocation float64) (*alpaca.Order, error) {
-	// Calculate the number of shares based on allocation and current price
-	currentPrice, err := a.GetCurrentPrice(ctx, ticker)
+// GetDailyBars retrieves split-adjusted daily bars for a ticker
+func (a *AlpacaService) GetDailyBars(ctx context.Context, ticker string, start, end time.Time) ([]marketdata.Bar, error) {
+	// Recent SIP data is not available without a subscription, so stop short of now
+	if latest := time.Now().Add(-20 * time.Minute); end.After(latest) {
+		end = latest
+	}
+
+	bars, err := a.marketData.GetBars(ticker, marketdata.GetBarsParams{
+		TimeFrame:  marketdata.OneDay,
+		Adjustment: marketdata.Split,
+		Start:      start,
+		End:        end,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get current price for %s: %w", ticker, err)
+		return nil, fmt.Errorf("failed to get daily bars for %s: %w", ticker, err)
 	}
+	return bars, nil
+}
 
-	// Calculate shares based on allocation amount
-	shares := allocation / currentPrice
-	if shares <= 0 {
-		return nil, fmt.Errorf("allocation amount %.2f is too small for current price %.2f", allocation, currentPrice)
+// IsFractionable checks if a ticker supports fractional shares
+func (a *AlpacaService) IsFractionable(ctx context.Context, ticker string) (bool, error) {
+	asset, err := a.client.GetAsset(ticker)
+	if err != nil {
+		return false, fmt.Errorf("failed to get asset info for %s: %w", ticker, err)
 	}
+	return asset.Fractionable, nil
+}
 
+// BuyStock executes a buy order for the specified ticker and allocation.
+// Fractionable tickers are bought as a notional order for the allocation, so
+// the spend does not depend on the quote; other tickers are bought in whole
+// shares at the current ask. With a limit price the order is a limit order for
+// the shares the allocation buys at the limit, as notional orders can only be
+// market orders.
+func (a *AlpacaService) BuyStock(ctx context.Context, ticker string, allocation, limitPrice float64, clientOrderID string) (*alpaca.Order, error) {
 	// Check if the ticker supports fractional shares
 	isFractionable, err := a.IsFractionable(ctx, ticker)
 	if err != nil {
@@ -129,23 +172,57 @@ func (a *AlpacaService) BuyStock(ctx context.Context, ticker string, allocation
 		isFractionable = true // Default to fractional shares if we can't check
 	}
 
-	// If not fractionable, round down to whole number of shares
-	if !isFractionable {
-		shares = math.Floor(shares)
+	// Create the buy order as a market order for guaranteed execution, unless a limit caps the slippage
+	orderRequest := alpaca.PlaceOrderRequest{
+		AssetKey:      &ticker,
+		Side:          alpaca.Buy,
+		Type:          alpaca.Market,
+		TimeInForce:   alpaca.Day,
+		ClientOrderID: clientOrderID,
+	}
+
+	var size string
+	switch {
+	case limitPrice > 0:
+		// Size the quantity so the allocation covers every share at the limit
+		shares := allocation / limitPrice
+		if !isFractionable {
+			shares = math.Floor(shares)
+		}
+		qty := decimal.NewFromFloat(shares).Truncate(9)
+		if !qty.IsPositive() {
+			return nil, fmt.Errorf("allocation amount %.2f results in 0 shares for %s at limit price %.4f", allocation, ticker, limitPrice)
+		}
+		limit := decimal.NewFromFloat(limitPrice)
+		orderRequest.Type = alpaca.Limit
+		orderRequest.Qty = &qty
+		orderRequest.LimitPrice = &limit
+		size = fmt.Sprintf("%s shares at most $%s", qty, limit)
+	case isFractionable:
+		notional := notionalAmount(allocation)
+		if notional <= 0 {
+			return nil, fmt.Errorf("allocation amount %.4f is too small for a notional order for %s", allocation, ticker)
+		}
+		amount := decimal.NewFromFloat(notional)
+		orderRequest.Notional = &amount
+		size = fmt.Sprintf("$%.2f", notional)
+	default:
+		currentPrice, err := a.GetCurrentPrice(ctx, ticker)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current price for %s: %w", ticker, err)
+		}
+		if currentPrice <= 0 {
+			return nil, fmt.Errorf("invalid ask price %.2f for non-fractionable ticker %s", currentPrice, ticker)
+		}
+
+		// Round down to whole shares, the rest of the allocation is left over
+		shares := math.Floor(allocation / currentPrice)
 		if shares <= 0 {
 			return nil, fmt.Errorf("allocation amount %.2f results in 0 shares for non-fractionable ticker %s at price %.2f", allocation, ticker, currentPrice)
 		}
-		log.Printf("Rounded down to %f whole shares for non-fractionable ticker %s", shares, ticker)
-	}
-
-	// Create the buy order as a market order for guaranteed execution
-	qty := decimal.NewFromFloat(shares)
-	orderRequest := alpaca.PlaceOrderRequest{
-		AssetKey:    &ticker,
-		Qty:         &qty,
-		Side:        alpaca.Buy,
-		Type:        alpaca.Market,
-		TimeInForce: alpaca.Day,
+		qty := decimal.NewFromFloat(shares)
+		orderRequest.Qty = &qty
+		size = fmt.Sprintf("%.0f whole shares", shares)
 	}
 
 	order, err := a.client.PlaceOrder(orderRequest)
@@ -153,12 +230,13 @@ func (a *AlpacaService) BuyStock(ctx context.Context, ticker string, allocation
 		return nil, fmt.Errorf("failed to place buy order for %s: %w", ticker, err)
 	}
 
-	log.Printf("Placed market buy order for %s: %f shares", ticker, shares)
+	log.Printf("Placed %s buy order for %s: %s", orderRequest.Type, ticker, size)
 	return order, nil
 }
 
-// SellStock executes a sell order for the specified ticker and quantity
-func (a *AlpacaService) SellStock(ctx context.Context, ticker string, quantity float64) (*alpaca.Order, error) {
+// SellStock executes a sell order for the specified ticker and quantity, a
+// limit order when a limit price is given
+func (a *AlpacaService) SellStock(ctx context.Context, ticker string, quantity, limitPrice float64, clientOrderID string) (*alpaca.Order, error) {
 	// Check if we have enough shares to sell
 	currentPosition, err := a.GetPosition(ctx, ticker)
 	if err != nil {
@@ -172,11 +250,17 @@ func (a *AlpacaService) SellStock(ctx context.Context, ticker string, quantity f
 	// Create the sell order as a market order for guaranteed execution
 	qty := decimal.NewFromFloat(quantity)
 	orderRequest := alpaca.PlaceOrderRequest{
-		AssetKey:    &ticker,
-		Qty:         &qty,
-		Side:        alpaca.Sell,
-		Type:        alpaca.Market,
-		TimeInForce: alpaca.Day, // Changed from GTC to Day since market orders execute immediately
+		AssetKey:      &ticker,
+		Qty:           &qty,
+		Side:          alpaca.Sell,
+		Type:          alpaca.Market,
+		TimeInForce:   alpaca.Day, // Changed from GTC to Day since market orders execute immediately
+		ClientOrderID: clientOrderID,
+	}
+	if limitPrice > 0 {
+		limit := decimal.NewFromFloat(limitPrice)
+		orderRequest.Type = alpaca.Limit
+		orderRequest.LimitPrice = &limit
 	}
 
 	order, err := a.client.PlaceOrder(orderRequest)
@@ -184,7 +268,7 @@ func (a *AlpacaService) SellStock(ctx context.Context, ticker string, quantity f
 		return nil, fmt.Errorf("failed to place sell order for %s: %w", ticker, err)
 	}
 
-	log.Printf("Placed market sell order for %s: %f shares", ticker, quantity)
+	log.Printf("Placed %s sell order for %s: %f shares", orderRequest.Type, ticker, quantity)
 	return order, nil
 }
 
@@ -198,6 +282,20 @@ func (a *AlpacaService) GetOrderStatus(ctx context.Context, orderID string) (*al
 	return order, nil
 }
 
+// FindOrderByClientOrderID looks up an order by its client order ID, returning nil if none exists
+func (a *AlpacaService) FindOrderByClientOrderID(ctx context.Context, clientOrderID string) (*alpaca.Order, error) {
+	order, err := a.client.GetOrderByClientOrderID(clientOrderID)
+	if err != nil {
+		var apiErr *alpaca.APIError
+		if errors.As(err, &apiErr) && apiErr.Code == alpacaErrCodeNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get order by client order ID %s: %w", clientOrderID, err)
+	}
+
+	return order, nil
+}
+
 // IsMarketOpen checks if the market is currently open
 func (a *AlpacaService) IsMarketOpen(ctx context.Context) (bool, error) {
 	clock, err := a.client.GetClock()
@@ -218,6 +316,19 @@ func (a *AlpacaService) GetNextMarketOpen(ctx context.Context) (time.Time, error
 	return clock.NextOpen, nil
 }
 
+// GetCalendar retrieves the trading sessions between start and end (inclusive dates)
+func (a *AlpacaService) GetCalendar(ctx context.Context, start, end time.Time) ([]alpaca.CalendarDay, error) {
+	startDate := start.Format("2006-01-02")
+	endDate := end.Format("2006-01-02")
+
+	days, err := a.client.GetCalendar(&startDate, &endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get market calendar: %w", err)
+	}
+
+	return days, nil
+}
+
 // GetPosition retrieves the current position for a ticker
 func (a *AlpacaService) GetPosition(ctx context.Context, ticker string) (float64, error) {
 	position, err := a.client.GetPosition(ticker)
@@ -229,3 +340,22 @@ func (a *AlpacaService) GetPosition(ctx context.Context, ticker string) (float64
 	qty, _ := position.Qty.Float64()
 	return qty, nil
 }
+
+// GetPositionValues retrieves the market value of every open position by ticker
+func (a *AlpacaService) GetPositionValues(ctx context.Context) (map[string]float64, error) {
+	positions, err := a.client.ListPositions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list positions: %w", err)
+	}
+
+	values := make(map[string]float64, len(positions))
+	for _, position := range positions {
+		if position.MarketValue == nil {
+			continue
+		}
+		value, _ := position.MarketValue.Float64()
+		values[strings.ToUpper(position.Symbol)] += math.Abs(value)
+	}
+
+	return values, nil
+}
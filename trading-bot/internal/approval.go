@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// approvalRequest is an order that needs a human decision, posted to Discord
+// once the run has saved the signal
+type approvalRequest struct {
+	action   PlannedAction
+	deadline time.Time
+}
+
+// awaitApproval reports whether the signal's next order may be placed. Without
+// a usable approval it requests one, and a pending signal whose buy was
+// rejected is cancelled.
+func (tb *TradingBot) awaitApproval(ctx context.Context, signal *types.Signal, step OrderStep, allocation float64) (bool, error) {
+	if !tb.config.RequireApproval {
+		return true, nil
+	}
+
+	now := time.Now()
+	approval := signal.Approval
+
+	if approval != nil && approval.Step == string(step) {
+		switch {
+		case approval.Decision == types.ApprovalDecisionRejected && step == OrderStepBuy:
+			return false, signal.Transition(types.SignalStatusCancelled, fmt.Sprintf("Buy rejected by %s", approval.DecidedBy))
+		case now.After(approval.Deadline):
+			log.Printf("Approval of %s order for signal %s lapsed at %s, requesting a new one",
+				step, signal.UUID, approval.Deadline.Format(time.RFC3339))
+		case approval.Decision == types.ApprovalDecisionApproved:
+			return true, nil
+		case approval.Decision == types.ApprovalDecisionRejected:
+			log.Printf("Sell for signal %s was rejected by %s, holding until %s",
+				signal.UUID, approval.DecidedBy, approval.Deadline.Format(time.RFC3339))
+			return false, nil
+		default:
+			log.Printf("Waiting for approval of %s order for signal %s until %s",
+				step, signal.UUID, approval.Deadline.Format(time.RFC3339))
+			return false, nil
+		}
+	}
+
+	action := PlannedAction{
+		SignalUUID: signal.UUID,
+		Ticker:     signal.Ticker,
+		Status:     signal.Status,
+	}
+	if step == OrderStepBuy {
+		action = tb.planBuy(ctx, action, allocation)
+	} else {
+		action = tb.planSell(ctx, action, signal.NumStocks)
+	}
+	if action.Action != PlanActionBuy && action.Action != PlanActionSell {
+		return false, fmt.Errorf("cannot request approval for signal %s: %s", signal.UUID, action.Reason)
+	}
+
+	deadline := tb.approvalDeadline(now)
+	signal.Approval = &types.OrderApproval{
+		Step:        string(step),
+		Decision:    types.ApprovalDecisionPending,
+		Quantity:    action.Quantity,
+		Price:       action.Price,
+		RequestedAt: now,
+		Deadline:    deadline,
+	}
+	signal.UpdatedAt = now
+
+	tb.approvalRequests = append(tb.approvalRequests, approvalRequest{action: action, deadline: deadline})
+	log.Printf("Requested approval of %s order for signal %s until %s", step, signal.UUID, deadline.Format(time.RFC3339))
+	return false, nil
+}
+
+// approvalDeadline returns how long an approval requested at now stays open.
+// Unless APPROVAL_DEADLINE_MINUTES sets a fixed time, it lasts until the close
+// of the next trading session, so a request posted by the last run of the day
+// can still be decided and used by the next day's runs.
+func (tb *TradingBot) approvalDeadline(now time.Time) time.Time {
+	if minutes := tb.config.ApprovalDeadlineMinutes; minutes > 0 {
+		return now.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	nextDay := tb.calendar.NextTradingDay(tb.calendar.Today(now).AddDate(0, 0, 1))
+	if session, ok := tb.calendar.Session(nextDay); ok {
+		return session.Close
+	}
+	// Past the end of the calendar, a day is the closest estimate
+	return now.Add(24 * time.Hour)
+}
+
+// postApprovalRequests posts the approval requests of the run to Discord. It
+// runs after the signals were saved so a decision always finds its request.
+func (tb *TradingBot) postApprovalRequests() {
+	for _, request := range tb.approvalRequests {
+		action := request.action
+		step := OrderStepBuy
+		if action.Action == PlanActionSell {
+			step = OrderStepSell
+		}
+
+		err := tb.notificationService.NotifyApprovalRequest(string(action.Action), action.Ticker, action.SignalUUID.String(),
+			action.Quantity, action.Price, request.deadline,
+			types.ApprovalCustomID(types.ApprovalDecisionApproved, action.SignalUUID, string(step)),
+			types.ApprovalCustomID(types.ApprovalDecisionRejected, action.SignalUUID, string(step)))
+		if err != nil {
+			log.Printf("Warning: Failed to post approval request for signal %s: %v", action.SignalUUID, err)
+		}
+	}
+}
+
+// approvalNote describes where the approval of the signal's next order stands,
+// for the dry run plan
+func (tb *TradingBot) approvalNote(signal *types.Signal, step OrderStep, now time.Time) string {
+	if !tb.config.RequireApproval {
+		return ""
+	}
+
+	approval := signal.Approval
+	if approval == nil || approval.Step != string(step) || now.After(approval.Deadline) {
+		return "Approval would be requested"
+	}
+
+	switch approval.Decision {
+	case types.ApprovalDecisionApproved:
+		return fmt.Sprintf("Approved by %s", approval.DecidedBy)
+	case types.ApprovalDecisionRejected:
+		return fmt.Sprintf("Rejected by %s", approval.DecidedBy)
+	default:
+		return fmt.Sprintf("Awaiting approval until %s", approval.Deadline.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"context"
+	"math"
+	"time"
+
+	alpaca "github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v2/marketdata"
+)
+
+// Broker defines the brokerage operations the trading bot depends on
+type Broker interface {
+	// Account
+	GetAccountValue(ctx context.Context) (float64, error)
+	GetCashBalance(ctx context.Context) (float64, error)
+	// GetPreviousCloseEquity returns the account equity at the close of the previous trading day
+	GetPreviousCloseEquity(ctx context.Context) (float64, error)
+
+	// Market data
+	GetCurrentPrice(ctx context.Context, ticker string) (float64, error)
+	GetBidPrice(ctx context.Context, ticker string) (float64, error)
+	GetQuote(ctx context.Context, ticker string) (Quote, error)
+	IsFractionable(ctx context.Context, ticker string) (bool, error)
+	// GetDailyBars returns the split-adjusted daily bars between start and end, oldest first
+	GetDailyBars(ctx context.Context, ticker string, start, end time.Time) ([]marketdata.Bar, error)
+
+	// Orders and positions
+	// BuyStock buys a fractionable ticker for the allocation rounded down to the
+	// cent and any other ticker in whole shares at the current ask. With a limit
+	// price it places a limit order for the shares the allocation buys at the limit.
+	BuyStock(ctx context.Context, ticker string, allocation, limitPrice float64, clientOrderID string) (*alpaca.Order, error)
+	// SellStock sells the quantity with a market order, or a limit order when a limit price is given
+	SellStock(ctx context.Context, ticker string, quantity, limitPrice float64, clientOrderID string) (*alpaca.Order, error)
+	GetOrderStatus(ctx context.Context, orderID string) (*alpaca.Order, error)
+	// FindOrderByClientOrderID returns nil without an error when no order has the given client order ID
+	FindOrderByClientOrderID(ctx context.Context, clientOrderID string) (*alpaca.Order, error)
+	GetPosition(ctx context.Context, ticker string) (float64, error)
+	// GetPositionValues returns the market value of every open position by ticker
+	GetPositionValues(ctx context.Context) (map[string]float64, error)
+
+	// Market clock
+	IsMarketOpen(ctx context.Context) (bool, error)
+	GetNextMarketOpen(ctx context.Context) (time.Time, error)
+	// GetCalendar returns the trading sessions between start and end (inclusive dates)
+	GetCalendar(ctx context.Context, start, end time.Time) ([]alpaca.CalendarDay, error)
+}
+
+// Quote is the latest bid and ask of a ticker
+type Quote struct {
+	BidPrice  float64
+	AskPrice  float64
+	Timestamp time.Time
+}
+
+// SpreadBps returns the spread in basis points of the midpoint
+func (q Quote) SpreadBps() float64 {
+	mid := (q.BidPrice + q.AskPrice) / 2
+	if mid <= 0 {
+		return 0
+	}
+	return (q.AskPrice - q.BidPrice) / mid * 10000
+}
+
+// Ensure AlpacaService satisfies the Broker interface
+var _ Broker = (*AlpacaService)(nil)
+
+// notionalAmount rounds an allocation down to the cent, the precision of a
+// notional order. The epsilon keeps amounts like 0.29 from losing a cent.
+func notionalAmount(allocation float64) float64 {
+	return math.Floor(allocation*100+1e-6) / 100
+}
@@ -0,0 +1,24 @@
+package internal
+
+import "testing"
+
+func TestNotionalAmount(t *testing.T) {
+	tests := []struct {
+		name       string
+		allocation float64
+		want       float64
+	}{
+		{name: "whole cents", allocation: 1000, want: 1000},
+		{name: "rounded down", allocation: 333.339, want: 333.33},
+		{name: "cents lost to float error are kept", allocation: 0.29, want: 0.29},
+		{name: "below a cent", allocation: 0.009, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notionalAmount(tt.allocation); got != tt.want {
+				t.Errorf("notionalAmount(%v) = %v, want %v", tt.allocation, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// breakerCheck compares the account's equity with the loss thresholds and
+// returns the rule it breaks, or an empty rule. The daily loss is not checked
+// on the session the breaker was cleared in, otherwise a clear could not hold
+// until the next day.
+func breakerCheck(breaker *types.CircuitBreaker, equity, previousCloseEquity, maxDailyLossPercent, maxDrawdownPercent float64, skipDailyLoss bool) (types.CircuitBreakerRule, string) {
+	if maxDailyLossPercent > 0 && !skipDailyLoss && previousCloseEquity > 0 {
+		dailyLossPercent := (previousCloseEquity - equity) / previousCloseEquity * 100
+		if dailyLossPercent >= maxDailyLossPercent {
+			return types.CircuitBreakerRuleDailyLoss,
+				fmt.Sprintf("equity $%.2f is %.2f%% below the previous close of $%.2f (max %.2f%%)",
+					equity, dailyLossPercent, previousCloseEquity, maxDailyLossPercent)
+		}
+	}
+
+	if maxDrawdownPercent > 0 && breaker.PeakEquity > 0 {
+		drawdownPercent := (breaker.PeakEquity - equity) / breaker.PeakEquity * 100
+		if drawdownPercent >= maxDrawdownPercent {
+			return types.CircuitBreakerRuleDrawdown,
+				fmt.Sprintf("equity $%.2f is %.2f%% below the peak of $%.2f (max %.2f%%)",
+					equity, drawdownPercent, breaker.PeakEquity, maxDrawdownPercent)
+		}
+	}
+
+	return "", ""
+}
+
+// evaluateCircuitBreaker loads the circuit breaker, raises the peak equity and
+// trips the breaker when the account's loss passes a threshold. The trip is
+// saved and announced once, a tripped breaker stays tripped until it is
+// cleared by hand. When save is false, as in a dry run, nothing is written or
+// announced. If the state or the account cannot be read, buys are blocked for
+// the run.
+func (tb *TradingBot) evaluateCircuitBreaker(ctx context.Context, save bool) {
+	tb.circuitBreaker = nil
+
+	breaker, err := tb.dbService.LoadCircuitBreaker(ctx)
+	if err != nil {
+		tb.circuitBreakerUnavailable(fmt.Errorf("failed to load circuit breaker: %w", err), save)
+		return
+	}
+	if breaker == nil {
+		breaker = &types.CircuitBreaker{}
+	}
+
+	if breaker.Tripped {
+		log.Printf("Circuit breaker has been tripped since %s (%s), no buys will be placed: %s",
+			breaker.TrippedAt.Format(time.RFC3339), breaker.Rule, breaker.Reason)
+		tb.circuitBreaker = breaker
+		return
+	}
+
+	if tb.config.MaxDailyLossPercent <= 0 && tb.config.MaxDrawdownPercent <= 0 {
+		// Both thresholds are off, there is nothing to evaluate nor to block buys on
+		tb.circuitBreaker = breaker
+		return
+	}
+
+	equity, err := tb.broker.GetAccountValue(ctx)
+	if err != nil {
+		tb.circuitBreakerUnavailable(fmt.Errorf("failed to get account value: %w", err), save)
+		return
+	}
+	previousCloseEquity, err := tb.broker.GetPreviousCloseEquity(ctx)
+	if err != nil {
+		tb.circuitBreakerUnavailable(fmt.Errorf("failed to get previous close equity: %w", err), save)
+		return
+	}
+
+	now := time.Now()
+	breaker.LastEquity = equity
+	breaker.UpdatedAt = now
+	if equity > breaker.PeakEquity {
+		breaker.PeakEquity = equity
+		breaker.PeakAt = now
+	}
+
+	clearedThisSession := !breaker.ClearedAt.IsZero() &&
+		tb.calendar.Today(breaker.ClearedAt).Equal(tb.calendar.Today(now))
+	rule, reason := breakerCheck(breaker, equity, previousCloseEquity,
+		tb.config.MaxDailyLossPercent, tb.config.MaxDrawdownPercent, clearedThisSession)
+	if rule != "" {
+		breaker.Trip(rule, reason, now)
+		log.Printf("Circuit breaker tripped (%s), no buys will be placed until it is cleared: %s", rule, reason)
+	}
+	tb.circuitBreaker = breaker
+
+	if !save {
+		return
+	}
+
+	err = tb.dbService.SaveCircuitBreaker(ctx, breaker)
+	if err != nil {
+		// Another writer got there first, this run still honours what it computed
+		if errors.Is(err, store.ErrVersionConflict) {
+			log.Printf("Warning: Circuit breaker was changed by another writer, not saving it: %v", err)
+		} else {
+			log.Printf("Warning: Failed to save circuit breaker: %v", err)
+			tb.notificationService.NotifyError("Circuit Breaker", "Failed to save the circuit breaker state", err.Error())
+		}
+		return
+	}
+
+	if breaker.Tripped {
+		err := tb.notificationService.NotifyCircuitBreakerTripped(string(rule), reason, equity, breaker.PeakEquity, previousCloseEquity)
+		if err != nil {
+			log.Printf("Warning: Failed to announce circuit breaker trip: %v", err)
+		}
+	}
+}
+
+// circuitBreakerUnavailable blocks buys for the run when the breaker cannot be evaluated
+func (tb *TradingBot) circuitBreakerUnavailable(err error, notify bool) {
+	log.Printf("Warning: Circuit breaker could not be evaluated, no buys will be placed this run: %v", err)
+	if notify {
+		tb.notificationService.NotifyError("Circuit Breaker", "Failed to evaluate the circuit breaker, buys are blocked this run", err.Error())
+	}
+}
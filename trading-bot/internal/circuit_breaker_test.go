@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+func TestBreakerCheck(t *testing.T) {
+	tests := []struct {
+		name                string
+		peakEquity          float64
+		equity              float64
+		previousCloseEquity float64
+		maxDailyLossPercent float64
+		maxDrawdownPercent  float64
+		skipDailyLoss       bool
+		want                types.CircuitBreakerRule
+	}{
+		{name: "thresholds off", peakEquity: 10000, equity: 5000, previousCloseEquity: 10000},
+		{name: "within the daily loss", equity: 9600, previousCloseEquity: 10000, maxDailyLossPercent: 5},
+		{
+			name: "daily loss reached", equity: 9500, previousCloseEquity: 10000, maxDailyLossPercent: 5,
+			want: types.CircuitBreakerRuleDailyLoss,
+		},
+		{
+			name: "daily loss skipped on the session it was cleared", equity: 9000, previousCloseEquity: 10000,
+			maxDailyLossPercent: 5, skipDailyLoss: true,
+		},
+		{name: "no previous close", equity: 9000, maxDailyLossPercent: 5},
+		{name: "gain over the previous close", equity: 11000, previousCloseEquity: 10000, maxDailyLossPercent: 5},
+		{name: "within the drawdown", peakEquity: 12000, equity: 11000, maxDrawdownPercent: 10},
+		{
+			name: "drawdown reached", peakEquity: 12000, equity: 10800, maxDrawdownPercent: 10,
+			want: types.CircuitBreakerRuleDrawdown,
+		},
+		{name: "no peak yet", equity: 5000, maxDrawdownPercent: 10},
+		{
+			name: "daily loss is checked first", peakEquity: 12000, equity: 9000, previousCloseEquity: 10000,
+			maxDailyLossPercent: 5, maxDrawdownPercent: 10,
+			want: types.CircuitBreakerRuleDailyLoss,
+		},
+		{
+			name: "drawdown still checked when the daily loss is skipped", peakEquity: 12000, equity: 9000,
+			previousCloseEquity: 10000, maxDailyLossPercent: 5, maxDrawdownPercent: 10, skipDailyLoss: true,
+			want: types.CircuitBreakerRuleDrawdown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := &types.CircuitBreaker{PeakEquity: tt.peakEquity}
+
+			got, reason := breakerCheck(breaker, tt.equity, tt.previousCloseEquity,
+				tt.maxDailyLossPercent, tt.maxDrawdownPercent, tt.skipDailyLoss)
+
+			if got != tt.want {
+				t.Errorf("breakerCheck() = %q (%s), want %q", got, reason, tt.want)
+			}
+			if (got == "") != (reason == "") {
+				t.Errorf("breakerCheck() = %q with reason %q, want a reason only for a broken rule", got, reason)
+			}
+		})
+	}
+}
+
+func TestEvaluateCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	dbService := store.NewMemoryStore()
+	broker := NewSimulatedBroker(10000)
+	broker.SetPreviousCloseEquity(10000)
+
+	tb := testBot(dbService, broker)
+	tb.config = &Config{MaxDailyLossPercent: 5, MaxDrawdownPercent: 10}
+	tb.calendar = testCalendar(t, now.AddDate(0, 0, -7).Format("2006-01-02"), now.AddDate(0, 0, 7).Format("2006-01-02"))
+
+	steps := []struct {
+		name        string
+		clear       bool // Cleared by hand before the run
+		equity      float64
+		wantTripped bool
+		wantRule    types.CircuitBreakerRule
+		wantPeak    float64
+	}{
+		{name: "no loss", equity: 10000, wantPeak: 10000},
+		{name: "daily loss trips", equity: 9400, wantTripped: true, wantRule: types.CircuitBreakerRuleDailyLoss, wantPeak: 10000},
+		{name: "stays tripped after a recovery", equity: 10000, wantTripped: true, wantRule: types.CircuitBreakerRuleDailyLoss, wantPeak: 10000},
+		{name: "clear holds for the rest of the session", clear: true, equity: 9400, wantPeak: 9400},
+		{name: "drawdown from the new peak trips", equity: 8400, wantTripped: true, wantRule: types.CircuitBreakerRuleDrawdown, wantPeak: 9400},
+	}
+
+	for _, step := range steps {
+		if step.clear {
+			breaker, err := dbService.LoadCircuitBreaker(ctx)
+			if err != nil || breaker == nil {
+				t.Fatalf("%s: LoadCircuitBreaker() = %v, %v", step.name, breaker, err)
+			}
+			breaker.Clear("admin", time.Now())
+			if err := dbService.SaveCircuitBreaker(ctx, breaker); err != nil {
+				t.Fatalf("%s: SaveCircuitBreaker() error = %v", step.name, err)
+			}
+		}
+		broker.cash = step.equity
+
+		tb.evaluateCircuitBreaker(ctx, true)
+
+		saved, err := dbService.LoadCircuitBreaker(ctx)
+		if err != nil || saved == nil {
+			t.Fatalf("%s: LoadCircuitBreaker() = %v, %v", step.name, saved, err)
+		}
+		// A cleared breaker keeps the rule it last tripped on
+		if saved.Tripped != step.wantTripped || (step.wantTripped && saved.Rule != step.wantRule) || saved.PeakEquity != step.wantPeak {
+			t.Errorf("%s: saved breaker tripped %v (%s) with peak $%.2f, want tripped %v (%s) with peak $%.2f",
+				step.name, saved.Tripped, saved.Rule, saved.PeakEquity, step.wantTripped, step.wantRule, step.wantPeak)
+		}
+		if halted := tb.buysHalted() != ""; halted != step.wantTripped {
+			t.Errorf("%s: buys halted = %v, want %v", step.name, halted, step.wantTripped)
+		}
+	}
+}
+
+func TestEvaluateCircuitBreakerDryRun(t *testing.T) {
+	ctx := context.Background()
+	dbService := store.NewMemoryStore()
+	broker := NewSimulatedBroker(9000)
+	broker.SetPreviousCloseEquity(10000)
+
+	tb := testBot(dbService, broker)
+	tb.config = &Config{MaxDailyLossPercent: 5}
+
+	tb.evaluateCircuitBreaker(ctx, false)
+
+	if tb.circuitBreaker == nil || !tb.circuitBreaker.Tripped {
+		t.Fatalf("circuit breaker = %+v, want it tripped for the run", tb.circuitBreaker)
+	}
+	if saved, err := dbService.LoadCircuitBreaker(ctx); err != nil || saved != nil {
+		t.Errorf("LoadCircuitBreaker() = %+v, %v, want nothing saved", saved, err)
+	}
+}
@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	alpaca "github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// OrderStep identifies the lifecycle step an order belongs to
+type OrderStep string
+
+const (
+	OrderStepBuy  OrderStep = "buy"
+	OrderStepSell OrderStep = "sell"
+)
+
+// clientOrderID derives a deterministic client order ID from the signal UUID,
+// the lifecycle step and the attempt number for that step
+func clientOrderID(signal *types.Signal, step OrderStep) string {
+	attempt := signal.BuyOrderAttempt
+	if step == OrderStepSell {
+		attempt = signal.SellOrderAttempt
+	}
+
+	return fmt.Sprintf("artemis-%s-%s-%d", signal.UUID, step, attempt)
+}
+
+// placeBuyOrder places the buy order for a signal, adopting an existing order
+// with the same client order ID instead of placing a duplicate. A limit price
+// of 0 places a market order.
+func (tb *TradingBot) placeBuyOrder(ctx context.Context, signal *types.Signal, allocation, limitPrice float64) (*alpaca.Order, error) {
+	id := clientOrderID(signal, OrderStepBuy)
+
+	existing, err := tb.broker.FindOrderByClientOrderID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up existing buy order %s: %w", id, err)
+	}
+	if existing != nil {
+		log.Printf("Adopting existing buy order %s (%s) for signal %s", existing.ID, id, signal.UUID)
+		return existing, nil
+	}
+
+	err = tb.recordIntent(ctx, signal, OrderStepBuy, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return tb.broker.BuyStock(ctx, signal.Ticker, allocation, limitPrice, id)
+}
+
+// placeSellOrder places the sell order for a signal, adopting an existing order
+// with the same client order ID instead of placing a duplicate. A limit price
+// of 0 places a market order.
+func (tb *TradingBot) placeSellOrder(ctx context.Context, signal *types.Signal, limitPrice float64) (*alpaca.Order, error) {
+	id := clientOrderID(signal, OrderStepSell)
+
+	existing, err := tb.broker.FindOrderByClientOrderID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up existing sell order %s: %w", id, err)
+	}
+	if existing != nil {
+		log.Printf("Adopting existing sell order %s (%s) for signal %s", existing.ID, id, signal.UUID)
+		return existing, nil
+	}
+
+	err = tb.recordIntent(ctx, signal, OrderStepSell, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return tb.broker.SellStock(ctx, signal.Ticker, signal.NumStocks, limitPrice, id)
+}
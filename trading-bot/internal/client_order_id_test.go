@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+func TestClientOrderID(t *testing.T) {
+	signalUUID := uuid.MustParse("6f1c2b9e-3d4a-4b5c-8e7f-0a1b2c3d4e5f")
+
+	tests := []struct {
+		name   string
+		signal types.Signal
+		step   OrderStep
+		want   string
+	}{
+		{name: "first buy", signal: types.Signal{UUID: signalUUID}, step: OrderStepBuy, want: "artemis-6f1c2b9e-3d4a-4b5c-8e7f-0a1b2c3d4e5f-buy-0"},
+		{name: "retried buy", signal: types.Signal{UUID: signalUUID, BuyOrderAttempt: 2}, step: OrderStepBuy, want: "artemis-6f1c2b9e-3d4a-4b5c-8e7f-0a1b2c3d4e5f-buy-2"},
+		{
+			name:   "sell counts its own attempts",
+			signal: types.Signal{UUID: signalUUID, BuyOrderAttempt: 2, SellOrderAttempt: 1},
+			step:   OrderStepSell,
+			want:   "artemis-6f1c2b9e-3d4a-4b5c-8e7f-0a1b2c3d4e5f-sell-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clientOrderID(&tt.signal, tt.step); got != tt.want {
+				t.Errorf("clientOrderID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlaceOrderAdoptsExistingOrder(t *testing.T) {
+	ctx := context.Background()
+	dbService := store.NewMemoryStore()
+	broker := NewSimulatedBroker(10000)
+	broker.SetQuote("AAPL", 9.99, 10.01)
+	tb := testBot(dbService, broker)
+
+	signal := &types.Signal{UUID: uuid.New(), Ticker: "AAPL", Status: types.SignalStatusPending}
+
+	first, err := tb.placeBuyOrder(ctx, signal, 1000, 0)
+	if err != nil {
+		t.Fatalf("first placeBuyOrder() error = %v", err)
+	}
+	intents, err := dbService.LoadIntents(ctx)
+	if err != nil || len(intents) != 1 || intents[0].ClientOrderID != clientOrderID(signal, OrderStepBuy) {
+		t.Fatalf("LoadIntents() = %+v, %v, want one intent for the buy", intents, err)
+	}
+
+	// A rerun after a crash places the same buy again
+	second, err := tb.placeBuyOrder(ctx, signal, 1000, 0)
+	if err != nil {
+		t.Fatalf("second placeBuyOrder() error = %v", err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("second buy placed order %s, want order %s adopted", second.ID, first.ID)
+	}
+	if cash, _ := broker.GetCashBalance(ctx); cash < 9000-0.01 {
+		t.Errorf("cash = %.2f, want only one buy paid", cash)
+	}
+
+	// A new attempt is a new order
+	signal.BuyOrderAttempt++
+	retried, err := tb.placeBuyOrder(ctx, signal, 1000, 0)
+	if err != nil {
+		t.Fatalf("retried placeBuyOrder() error = %v", err)
+	}
+	if retried.ID == first.ID {
+		t.Errorf("retried buy adopted order %s, want a new order", first.ID)
+	}
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// validateExecution checks the configured execution mode and its slippage cap
+func validateExecution(config *Config) error {
+	switch config.ExecutionMode {
+	case "", ExecutionModeMarket:
+		return nil
+	case ExecutionModeMarketableLimit:
+		if config.MaxSlippageBps < 0 {
+			return fmt.Errorf("max slippage must not be negative: %.2f bps", config.MaxSlippageBps)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown execution mode: %s", config.ExecutionMode)
+	}
+}
+
+// checkQuote gets the latest quote of a ticker and rejects one no order should
+// be placed on: a missing bid or ask, a crossed market, a spread wider than
+// QuoteMaxSpreadBps or a quote older than QuoteMaxAgeSeconds. Such quotes are
+// common at the open and on illiquid tickers and lead to absurd sizes or fills.
+func (tb *TradingBot) checkQuote(ctx context.Context, ticker string) (Quote, error) {
+	quote, err := tb.broker.GetQuote(ctx, ticker)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	if quote.BidPrice <= 0 || quote.AskPrice <= 0 {
+		return quote, fmt.Errorf("quote for %s has no bid or ask (bid $%.2f, ask $%.2f)", ticker, quote.BidPrice, quote.AskPrice)
+	}
+	if quote.BidPrice > quote.AskPrice {
+		return quote, fmt.Errorf("quote for %s is crossed (bid $%.2f, ask $%.2f)", ticker, quote.BidPrice, quote.AskPrice)
+	}
+
+	if maxSpread := tb.config.QuoteMaxSpreadBps; maxSpread > 0 && quote.SpreadBps() > maxSpread {
+		return quote, fmt.Errorf("spread of %s is %.0f bps (bid $%.2f, ask $%.2f), wider than %.0f bps",
+			ticker, quote.SpreadBps(), quote.BidPrice, quote.AskPrice, maxSpread)
+	}
+
+	if maxAge := time.Duration(tb.config.QuoteMaxAgeSeconds) * time.Second; maxAge > 0 {
+		if quote.Timestamp.IsZero() {
+			return quote, fmt.Errorf("quote for %s has no time, its age cannot be checked", ticker)
+		}
+		age := time.Since(quote.Timestamp)
+		if age > maxAge {
+			return quote, fmt.Errorf("quote for %s is %s old, older than %s",
+				ticker, age.Truncate(time.Second), maxAge)
+		}
+	}
+
+	return quote, nil
+}
+
+// limitPrice returns the limit of a marketable limit order for the step: the
+// ask plus at most MaxSlippageBps for buys, the bid less at most
+// MaxSlippageBps for sells. It returns 0, a market order, in market mode.
+func (tb *TradingBot) limitPrice(quote Quote, step OrderStep) float64 {
+	if tb.config.ExecutionMode != ExecutionModeMarketableLimit {
+		return 0
+	}
+
+	slippage := tb.config.MaxSlippageBps / 10000
+	if step == OrderStepSell {
+		return roundToTick(quote.BidPrice*(1-slippage), true)
+	}
+	return roundToTick(quote.AskPrice*(1+slippage), false)
+}
+
+// roundToTick rounds a limit price to the price increments the broker accepts,
+// cents from $1 and hundredths of a cent below. Buy limits round down and sell
+// limits up, so the slippage never exceeds the cap.
+func roundToTick(price float64, up bool) float64 {
+	ticksPerDollar := 100.0
+	if price < 1 {
+		ticksPerDollar = 10000.0
+	}
+
+	ticks := price * ticksPerDollar
+	if up {
+		ticks = math.Ceil(ticks - 1e-6)
+	} else {
+		ticks = math.Floor(ticks + 1e-6)
+	}
+	return ticks / ticksPerDollar
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestRoundToTick(t *testing.T) {
+	tests := []struct {
+		name  string
+		price float64
+		up    bool
+		want  float64
+	}{
+		{name: "buy limit rounds down to the cent", price: 10.128, want: 10.12},
+		{name: "sell limit rounds up to the cent", price: 10.121, up: true, want: 10.13},
+		{name: "whole cents are kept down", price: 10.12, want: 10.12},
+		{name: "whole cents are kept up", price: 10.12, up: true, want: 10.12},
+		{name: "half a cent rounds up", price: 1.005, up: true, want: 1.01},
+		{name: "sub-dollar rounds down to a hundredth of a cent", price: 0.50125, want: 0.5012},
+		{name: "sub-dollar rounds up to a hundredth of a cent", price: 0.50121, up: true, want: 0.5013},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := roundToTick(tt.price, tt.up)
+			if got != tt.want {
+				t.Errorf("roundToTick(%v, %v) = %v, want %v", tt.price, tt.up, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitPrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		slippage float64
+		quote    Quote
+		step     OrderStep
+		want     float64
+	}{
+		{name: "market mode", mode: ExecutionModeMarket, slippage: 10, quote: Quote{BidPrice: 99.9, AskPrice: 100}, step: OrderStepBuy},
+		{name: "default mode is market", slippage: 10, quote: Quote{BidPrice: 99.9, AskPrice: 100}, step: OrderStepSell},
+		{name: "buy above the ask", mode: ExecutionModeMarketableLimit, slippage: 10, quote: Quote{BidPrice: 99.9, AskPrice: 100}, step: OrderStepBuy, want: 100.1},
+		{name: "sell below the bid", mode: ExecutionModeMarketableLimit, slippage: 10, quote: Quote{BidPrice: 99.9, AskPrice: 100}, step: OrderStepSell, want: 99.81},
+		{name: "no slippage", mode: ExecutionModeMarketableLimit, quote: Quote{BidPrice: 99.9, AskPrice: 100}, step: OrderStepBuy, want: 100},
+		{name: "sub-dollar buy", mode: ExecutionModeMarketableLimit, slippage: 25, quote: Quote{BidPrice: 0.49, AskPrice: 0.5}, step: OrderStepBuy, want: 0.5012},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &TradingBot{config: &Config{ExecutionMode: tt.mode, MaxSlippageBps: tt.slippage}}
+
+			got := tb.limitPrice(tt.quote, tt.step)
+			if got != tt.want {
+				t.Errorf("limitPrice(%+v, %s) = %v, want %v", tt.quote, tt.step, got, tt.want)
+			}
+		})
+	}
+}
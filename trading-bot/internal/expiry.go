@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// pendingExpiryReason returns why a pending signal should no longer be bought,
+// or an empty string if it can still be bought
+func (tb *TradingBot) pendingExpiryReason(signal *types.Signal, currentDate time.Time) string {
+	buyDate := tb.calendar.NextTradingDay(signal.BuyDate)
+	sellDate := tb.calendar.NextTradingDay(signal.SellDate)
+
+	// Buying on or after the sell date would only open a position that is sold straight away
+	if !currentDate.Before(sellDate) {
+		return fmt.Sprintf("Sell date %s was reached before the signal was bought", sellDate.Format("2006-01-02"))
+	}
+
+	if tb.config.MaxBuyAttempts > 0 && signal.FailedBuyAttempts >= tb.config.MaxBuyAttempts {
+		return fmt.Sprintf("Buy failed %d times (last error: %s)", signal.FailedBuyAttempts, signal.LastBuyError)
+	}
+
+	// A signal queued for a full allocation window is waiting on a slot, not on the market
+	if tb.config.MaxPendingDays > 0 && signal.WindowQueuedAt == nil {
+		if pendingSessions(tb.calendar, signal, currentDate) >= tb.config.MaxPendingDays {
+			return fmt.Sprintf("Not bought within %d trading sessions of its buy date %s", tb.config.MaxPendingDays, buyDate.Format("2006-01-02"))
+		}
+	}
+
+	return ""
+}
+
+// pendingSessions counts the trading sessions before currentDate in which a due
+// signal could have been bought. Sessions it was carried over in do not count:
+// the bot itself held the buy back, because buys were halted, a risk limit
+// blocked it, its quote failed the checks or it was sized to $0.
+func pendingSessions(calendar *TradingCalendar, signal *types.Signal, currentDate time.Time) int {
+	sessions := calendar.SessionsBetween(calendar.NextTradingDay(signal.BuyDate), currentDate)
+	return max(sessions-excusedSessions(calendar, signal.CarryOver, currentDate), 0)
+}
+
+// excusedSessions counts the trading sessions before currentDate a signal was
+// carried over in, those of its current reason and of the reasons before it
+func excusedSessions(calendar *TradingCalendar, carryOver *types.CarryOver, currentDate time.Time) int {
+	if carryOver == nil {
+		return 0
+	}
+
+	excused := carryOver.Sessions
+	if carryOver.Reason != "" {
+		excused += calendar.SessionsBetween(carryOver.Since, currentDate)
+	}
+	return excused
+}
+
+// recordFailedBuyAttempt counts a buy attempt that did not open a position
+func recordFailedBuyAttempt(signal *types.Signal, cause string) {
+	signal.FailedBuyAttempts++
+	signal.LastBuyError = cause
+	signal.UpdatedAt = time.Now()
+}
+
+// expireSignal moves a pending signal to EXPIRED and announces it. The signal
+// leaves the active partitions, so the notification is only ever sent once.
+func (tb *TradingBot) expireSignal(signal *types.Signal, reason string) error {
+	if err := signal.Transition(types.SignalStatusExpired, reason); err != nil {
+		return err
+	}
+
+	log.Printf("Expired signal %s for %s: %s", signal.UUID, signal.Ticker, reason)
+	tb.notificationService.NotifySignalExpired(signal.Ticker, signal.UUID.String(), reason)
+	return nil
+}
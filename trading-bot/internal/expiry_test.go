@@ -0,0 +1,163 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+func TestPendingExpiryReason(t *testing.T) {
+	calendar := testCalendar(t, "2026-11-01", "2026-12-31", "2026-11-26")
+	queuedAt := time.Now()
+
+	tests := []struct {
+		name           string
+		maxPendingDays int
+		maxBuyAttempts int
+		signal         types.Signal
+		currentDate    string
+		want           string // Prefix of the reason, empty when the signal can still be bought
+	}{
+		{
+			name:        "can still be bought",
+			signal:      types.Signal{BuyDate: date(t, "2026-11-23"), SellDate: date(t, "2026-12-10")},
+			currentDate: "2026-11-24",
+		},
+		{
+			name:        "sell date reached",
+			signal:      types.Signal{BuyDate: date(t, "2026-11-23"), SellDate: date(t, "2026-11-25")},
+			currentDate: "2026-11-25",
+			want:        "Sell date 2026-11-25 was reached",
+		},
+		{
+			name:        "sell date on a holiday is reached on the next session",
+			signal:      types.Signal{BuyDate: date(t, "2026-11-23"), SellDate: date(t, "2026-11-26")},
+			currentDate: "2026-11-25",
+		},
+		{
+			name:           "buy attempts used up",
+			maxBuyAttempts: 3,
+			signal: types.Signal{BuyDate: date(t, "2026-11-23"), SellDate: date(t, "2026-12-10"),
+				FailedBuyAttempts: 3, LastBuyError: "not tradable"},
+			currentDate: "2026-11-24",
+			want:        "Buy failed 3 times",
+		},
+		{
+			name:           "buy attempts left",
+			maxBuyAttempts: 3,
+			signal: types.Signal{BuyDate: date(t, "2026-11-23"), SellDate: date(t, "2026-12-10"),
+				FailedBuyAttempts: 2},
+			currentDate: "2026-11-24",
+		},
+		{
+			name:           "pending sessions used up",
+			maxPendingDays: 2,
+			signal:         types.Signal{BuyDate: date(t, "2026-11-23"), SellDate: date(t, "2026-12-10")},
+			currentDate:    "2026-11-25",
+			want:           "Not bought within 2 trading sessions",
+		},
+		{
+			name:           "weekends and holidays are not counted",
+			maxPendingDays: 3,
+			signal:         types.Signal{BuyDate: date(t, "2026-11-24"), SellDate: date(t, "2026-12-10")},
+			currentDate:    "2026-11-27",
+		},
+		{
+			name:           "buy date on a weekend starts on the next session",
+			maxPendingDays: 1,
+			signal:         types.Signal{BuyDate: date(t, "2026-11-21"), SellDate: date(t, "2026-12-10")},
+			currentDate:    "2026-11-23",
+		},
+		{
+			name:           "queued for the allocation window",
+			maxPendingDays: 2,
+			signal: types.Signal{BuyDate: date(t, "2026-11-16"), SellDate: date(t, "2026-12-10"),
+				WindowQueuedAt: &queuedAt},
+			currentDate: "2026-11-25",
+		},
+		{
+			name:           "sessions carried over are excused",
+			maxPendingDays: 2,
+			signal: types.Signal{BuyDate: date(t, "2026-11-23"), SellDate: date(t, "2026-12-10"),
+				CarryOver: &types.CarryOver{Reason: types.CarryOverBuysHalted, Since: date(t, "2026-11-23")}},
+			currentDate: "2026-11-30",
+		},
+		{
+			name:           "sessions after a carry-over ended count again",
+			maxPendingDays: 2,
+			signal: types.Signal{BuyDate: date(t, "2026-11-23"), SellDate: date(t, "2026-12-10"),
+				CarryOver: &types.CarryOver{Sessions: 1}},
+			currentDate: "2026-11-27",
+			want:        "Not bought within 2 trading sessions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &TradingBot{
+				config:   &Config{MaxPendingDays: tt.maxPendingDays, MaxBuyAttempts: tt.maxBuyAttempts},
+				calendar: calendar,
+			}
+
+			got := tb.pendingExpiryReason(&tt.signal, date(t, tt.currentDate))
+
+			if tt.want == "" && got != "" {
+				t.Errorf("pendingExpiryReason() = %q, want no reason", got)
+			}
+			if tt.want != "" && !strings.HasPrefix(got, tt.want) {
+				t.Errorf("pendingExpiryReason() = %q, want a reason starting with %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPendingSessions(t *testing.T) {
+	calendar := testCalendar(t, "2026-11-01", "2026-12-31", "2026-11-26")
+
+	tests := []struct {
+		name        string
+		carryOver   *types.CarryOver
+		currentDate string
+		want        int
+	}{
+		{name: "due today", currentDate: "2026-11-23", want: 0},
+		{name: "no carry-over", currentDate: "2026-11-30", want: 4},
+		{
+			name:        "carried over since the buy date",
+			carryOver:   &types.CarryOver{Reason: types.CarryOverRiskLimit, Since: date(t, "2026-11-23")},
+			currentDate: "2026-11-30",
+			want:        0,
+		},
+		{
+			name:        "carried over from a later session",
+			carryOver:   &types.CarryOver{Reason: types.CarryOverRiskLimit, Since: date(t, "2026-11-25")},
+			currentDate: "2026-11-30",
+			want:        2,
+		},
+		{
+			name:        "carried over for earlier reasons",
+			carryOver:   &types.CarryOver{Reason: types.CarryOverRiskLimit, Since: date(t, "2026-11-27"), Sessions: 1},
+			currentDate: "2026-11-30",
+			want:        2,
+		},
+		{
+			name:        "ended carry-over",
+			carryOver:   &types.CarryOver{Sessions: 2},
+			currentDate: "2026-11-30",
+			want:        2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal := &types.Signal{BuyDate: date(t, "2026-11-23"), CarryOver: tt.carryOver}
+
+			got := pendingSessions(calendar, signal, date(t, tt.currentDate))
+			if got != tt.want {
+				t.Errorf("pendingSessions() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	alpaca "github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+)
+
+// Alpaca order statuses the bot cares about
+const (
+	OrderStatusFilled          = "filled"
+	OrderStatusPartiallyFilled = "partially_filled"
+	OrderStatusCanceled        = "canceled"
+	OrderStatusExpired         = "expired"
+	OrderStatusRejected        = "rejected"
+)
+
+// orderPollInterval is the delay between order status checks while waiting for a fill
+const orderPollInterval = time.Second
+
+// isOrderTerminal reports whether an order will not receive any further fills
+func isOrderTerminal(order *alpaca.Order) bool {
+	switch order.Status {
+	case OrderStatusFilled, OrderStatusCanceled, OrderStatusExpired, OrderStatusRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+// orderFill returns the filled quantity and average fill price of an order
+func orderFill(order *alpaca.Order) (float64, float64) {
+	filledQty, _ := order.FilledQty.Float64()
+
+	var avgPrice float64
+	if order.FilledAvgPrice != nil {
+		avgPrice, _ = order.FilledAvgPrice.Float64()
+	}
+
+	return filledQty, avgPrice
+}
+
+// openBuyNotional returns what the unfilled part of a buy order can still
+// spend: the rest of its notional, or its unfilled shares at the limit. A
+// market order for shares has no price to count it at and is left out.
+func openBuyNotional(order *alpaca.Order) float64 {
+	filledQty, avgPrice := orderFill(order)
+
+	var total float64
+	switch {
+	case order.Notional != nil:
+		total, _ = order.Notional.Float64()
+	case order.Qty != nil && order.LimitPrice != nil:
+		qty, _ := order.Qty.Float64()
+		limit, _ := order.LimitPrice.Float64()
+		total = qty * limit
+	}
+	return math.Max(total-filledQty*avgPrice, 0)
+}
+
+// waitForOrder follows an order until it reaches a terminal state or the
+// configured fill timeout elapses, returning the latest known order state
+func (tb *TradingBot) waitForOrder(ctx context.Context, orderID string) (*alpaca.Order, error) {
+	deadline := time.Now().Add(time.Duration(tb.config.OrderFillTimeoutSeconds) * time.Second)
+
+	for {
+		order, err := tb.broker.GetOrderStatus(ctx, orderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status of order %s: %w", orderID, err)
+		}
+
+		if isOrderTerminal(order) || !time.Now().Before(deadline) {
+			return order, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Stopped waiting for order %s: %v", orderID, ctx.Err())
+			return order, nil
+		case <-time.After(orderPollInterval):
+		}
+	}
+}
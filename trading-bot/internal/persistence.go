@@ -0,0 +1,302 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// recordIntent durably records that an order is about to be placed for a signal
+func (tb *TradingBot) recordIntent(ctx context.Context, signal *types.Signal, step OrderStep, clientOrderID string) error {
+	intent := types.TransitionIntent{
+		SignalUUID:    signal.UUID,
+		Ticker:        signal.Ticker,
+		Step:          string(step),
+		FromStatus:    signal.Status,
+		ClientOrderID: clientOrderID,
+		CreatedAt:     time.Now(),
+	}
+
+	err := tb.dbService.SaveIntent(ctx, intent)
+	if err != nil {
+		return fmt.Errorf("failed to record %s intent for signal %s: %w", step, signal.UUID, err)
+	}
+
+	tb.openIntents[signal.UUID.String()] = true
+	return nil
+}
+
+// persistSignal writes a signal's new state right after it was processed so a
+// timeout or panic later in the run cannot lose it. Writes that fail are retried
+// at the end of the run, except version conflicts, which mean someone else
+// changed the signal and must not be overwritten.
+func (tb *TradingBot) persistSignal(ctx context.Context, signal *types.Signal, previousStatus types.SignalStatus) {
+	if storedStatus, ok := tb.unsavedSignals[signal.UUID]; ok {
+		// An earlier write failed, the record is still under its old status
+		previousStatus = storedStatus
+	}
+
+	err := tb.writeSignal(ctx, signal, previousStatus)
+	if errors.Is(err, store.ErrVersionConflict) {
+		tb.notifySignalConflict(signal, err)
+		delete(tb.unsavedSignals, signal.UUID)
+		return
+	}
+
+	if err != nil {
+		log.Printf("Warning: Failed to persist signal %s, will retry at end of run: %v", signal.UUID, err)
+
+		if signal.Status == types.SignalStatusCompleted {
+			delete(tb.unsavedSignals, signal.UUID)
+			tb.pendingArchives = append(tb.pendingArchives, pendingArchive{signal: *signal, previousStatus: previousStatus})
+			return
+		}
+
+		tb.unsavedSignals[signal.UUID] = previousStatus
+		return
+	}
+
+	delete(tb.unsavedSignals, signal.UUID)
+	log.Printf("Persisted signal %s (%s -> %s)", signal.UUID, previousStatus, signal.Status)
+	tb.resolveIntent(ctx, signal)
+}
+
+// writeSignal archives a completed signal or transitions it from the status it
+// is stored under, both version checked
+func (tb *TradingBot) writeSignal(ctx context.Context, signal *types.Signal, previousStatus types.SignalStatus) error {
+	if signal.Status == types.SignalStatusCompleted {
+		// Completed signals move from the active partitions to the trade history
+		return tb.dbService.ArchiveSignal(ctx, *signal, previousStatus, tb.newTradeRecord(signal))
+	}
+	return tb.dbService.TransitionSignal(ctx, signal, previousStatus)
+}
+
+// notifySignalConflict reports a signal that another writer changed during the run
+func (tb *TradingBot) notifySignalConflict(signal *types.Signal, err error) {
+	// Leave any intent in place so the next run repairs the transition
+	log.Printf("Signal %s was modified concurrently, not overwriting it: %v", signal.UUID, err)
+	tb.notificationService.NotifyError("Signal Conflict",
+		fmt.Sprintf("Signal %s (%s) was modified by another writer during this run", signal.UUID, signal.Ticker), err.Error())
+}
+
+// maxSaveRetryAttempts bounds how often a signal write that failed during the
+// run is attempted at its end
+const maxSaveRetryAttempts = 4
+
+// saveRetryBackoff is the delay before the second attempt of a failed write,
+// doubled on every further attempt
+var saveRetryBackoff = 500 * time.Millisecond
+
+// retriedWrite is a signal write retried at the end of the run
+type retriedWrite struct {
+	signalUUID string
+	write      func() error
+}
+
+// retryWrites runs each write until it succeeds, up to maxSaveRetryAttempts
+// times with backoff in between, so a throttled table gets time to recover.
+// Version conflicts and invalid transitions are not retried, writing the same
+// record again cannot succeed. The writes that still failed are reported
+// through a *store.PartialWriteError.
+func retryWrites(ctx context.Context, writes []retriedWrite) error {
+	failures := &store.PartialWriteError{}
+
+	for attempt := 1; len(writes) > 0; attempt++ {
+		if attempt > 1 && !waitToRetry(ctx, attempt) {
+			for _, write := range writes {
+				failures.AddSignal(write.signalUUID)
+			}
+			failures.Cause = ctx.Err()
+			break
+		}
+
+		var retry []retriedWrite
+		for _, write := range writes {
+			err := write.write()
+			if err == nil {
+				continue
+			}
+
+			log.Printf("Failed to save signal %s (attempt %d of %d): %v", write.signalUUID, attempt, maxSaveRetryAttempts, err)
+			if attempt < maxSaveRetryAttempts && isRetryableWriteError(err) {
+				retry = append(retry, write)
+				continue
+			}
+			failures.AddSignal(write.signalUUID)
+			failures.Cause = err
+		}
+		writes = retry
+	}
+
+	if failures.HasFailures() {
+		return failures
+	}
+	return nil
+}
+
+// isRetryableWriteError reports whether a failed write may succeed when it is
+// attempted again
+func isRetryableWriteError(err error) bool {
+	return !errors.Is(err, store.ErrVersionConflict) && !errors.Is(err, types.ErrInvalidTransition) &&
+		!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// waitToRetry waits out the backoff before the given attempt, returning false
+// if the context ended first
+func waitToRetry(ctx context.Context, attempt int) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(saveRetryBackoff << (attempt - 2)):
+		return true
+	}
+}
+
+// retrySignalWrites retries the signal writes that failed during the run.
+// Each is the same versioned transition as the first attempt, from the status
+// the signal is still stored under, so a retry never overwrites a newer record
+// nor leaves the signal under two statuses.
+func (tb *TradingBot) retrySignalWrites(ctx context.Context) error {
+	var writes []retriedWrite
+	for i := range tb.signals {
+		signal := &tb.signals[i]
+		previousStatus, ok := tb.unsavedSignals[signal.UUID]
+		if !ok {
+			continue
+		}
+
+		writes = append(writes, retriedWrite{
+			signalUUID: signal.UUID.String(),
+			write: func() error {
+				err := tb.dbService.TransitionSignal(ctx, signal, previousStatus)
+				if err != nil {
+					if errors.Is(err, store.ErrVersionConflict) {
+						tb.notifySignalConflict(signal, err)
+					}
+					return err
+				}
+
+				delete(tb.unsavedSignals, signal.UUID)
+				log.Printf("Persisted signal %s (%s -> %s) on retry", signal.UUID, previousStatus, signal.Status)
+				tb.resolveIntent(ctx, signal)
+				return nil
+			},
+		})
+	}
+
+	return retryWrites(ctx, writes)
+}
+
+// retryArchives retries the archive writes of completed signals that failed
+// during the run, each a single transaction of the signal delete and the trade
+// record.
+func (tb *TradingBot) retryArchives(ctx context.Context) error {
+	var writes []retriedWrite
+	for i := range tb.pendingArchives {
+		archive := &tb.pendingArchives[i]
+
+		writes = append(writes, retriedWrite{
+			signalUUID: archive.signal.UUID.String(),
+			write: func() error {
+				err := tb.dbService.ArchiveSignal(ctx, archive.signal, archive.previousStatus, tb.newTradeRecord(&archive.signal))
+				if err != nil {
+					return err
+				}
+
+				tb.resolveIntent(ctx, &archive.signal)
+				return nil
+			},
+		})
+	}
+
+	return retryWrites(ctx, writes)
+}
+
+// resolveIntent removes the signal's transition intent once its result is durable
+func (tb *TradingBot) resolveIntent(ctx context.Context, signal *types.Signal) {
+	if !tb.openIntents[signal.UUID.String()] {
+		return
+	}
+
+	err := tb.dbService.DeleteIntent(ctx, signal.UUID)
+	if err != nil {
+		// A leftover intent is harmless, the next run resolves it again
+		log.Printf("Warning: Failed to delete intent for signal %s: %v", signal.UUID, err)
+		return
+	}
+
+	delete(tb.openIntents, signal.UUID.String())
+}
+
+// resumeIntents repairs transitions that a previous run started but never saved.
+// If the intended order exists at the broker it is attached to the signal so it
+// gets reconciled normally; otherwise the intent is simply discarded. A resumed
+// buy takes its window slot unless it already holds it, and what it can still
+// spend is counted in the run's risk snapshot.
+func (tb *TradingBot) resumeIntents(ctx context.Context) error {
+	intents, err := tb.dbService.LoadIntents(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load intents: %w", err)
+	}
+
+	for _, intent := range intents {
+		signal := tb.findSignal(intent.SignalUUID.String())
+		tb.openIntents[intent.SignalUUID.String()] = true
+
+		if signal == nil {
+			log.Printf("Discarding intent for unknown signal %s", intent.SignalUUID)
+			tb.resolveIntent(ctx, &types.Signal{UUID: intent.SignalUUID})
+			continue
+		}
+
+		previousStatus := signal.Status
+		order, err := tb.broker.FindOrderByClientOrderID(ctx, intent.ClientOrderID)
+		if err != nil {
+			// Leave the intent in place and try again on the next run
+			log.Printf("Warning: Could not look up order %s for intent on signal %s: %v", intent.ClientOrderID, signal.UUID, err)
+			continue
+		}
+
+		if order == nil {
+			log.Printf("Intended %s order %s for signal %s was never placed, discarding intent", intent.Step, intent.ClientOrderID, signal.UUID)
+			tb.resolveIntent(ctx, signal)
+			continue
+		}
+
+		switch OrderStep(intent.Step) {
+		case OrderStepBuy:
+			if signal.BuyOrderID == "" {
+				signal.BuyOrderID = order.ID
+			}
+			tb.takeWindowSlot(signal)
+			if !isOrderTerminal(order) {
+				tb.resumedBuys[signal.Ticker] += openBuyNotional(order)
+			}
+		case OrderStepSell:
+			if signal.SellOrderID == "" {
+				signal.SellOrderID = order.ID
+			}
+		}
+		signal.UpdatedAt = time.Now()
+
+		log.Printf("Resumed %s order %s for signal %s from an unfinished run", intent.Step, order.ID, signal.UUID)
+		tb.persistSignal(ctx, signal, previousStatus)
+	}
+
+	return nil
+}
+
+// findSignal returns the loaded signal with the given UUID, or nil
+func (tb *TradingBot) findSignal(signalUUID string) *types.Signal {
+	for i := range tb.signals {
+		if tb.signals[i].UUID.String() == signalUUID {
+			return &tb.signals[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+	"github.com/vignesh-goutham/artemis/trading-bot/pkg/notification"
+)
+
+// flakyStore fails the first transitions with err before passing them on
+type flakyStore struct {
+	*store.MemoryStore
+	failures    int
+	err         error
+	transitions int
+}
+
+func (s *flakyStore) TransitionSignal(ctx context.Context, signal *types.Signal, previousStatus types.SignalStatus) error {
+	s.transitions++
+	if s.transitions <= s.failures {
+		return s.err
+	}
+	return s.MemoryStore.TransitionSignal(ctx, signal, previousStatus)
+}
+
+// withoutSaveBackoff lets a test retry failed writes without waiting
+func withoutSaveBackoff(t *testing.T) {
+	backoff := saveRetryBackoff
+	saveRetryBackoff = 0
+	t.Cleanup(func() { saveRetryBackoff = backoff })
+}
+
+// testBot returns a trading bot on dbService with nothing loaded yet
+func testBot(dbService store.Store, broker Broker) *TradingBot {
+	return &TradingBot{
+		config:              &Config{},
+		dbService:           dbService,
+		broker:              broker,
+		notificationService: notification.NewDiscordNotificationService(""),
+		unsavedSignals:      make(map[uuid.UUID]types.SignalStatus),
+		openIntents:         make(map[string]bool),
+		resumedBuys:         make(map[string]float64),
+	}
+}
+
+func TestRetrySignalWrites(t *testing.T) {
+	withoutSaveBackoff(t)
+	throttled := errors.New("ProvisionedThroughputExceededException: rate exceeded")
+
+	tests := []struct {
+		name            string
+		failures        int
+		err             error
+		wantTransitions int
+		wantFailed      bool
+	}{
+		{name: "written on the first retry", wantTransitions: 1},
+		{name: "throttled until the last attempt", failures: maxSaveRetryAttempts - 1, err: throttled, wantTransitions: maxSaveRetryAttempts},
+		{name: "throttled on every attempt", failures: maxSaveRetryAttempts, err: throttled, wantTransitions: maxSaveRetryAttempts, wantFailed: true},
+		{
+			name:            "version conflicts are not retried",
+			failures:        maxSaveRetryAttempts,
+			err:             fmt.Errorf("%w: signal at version 1", store.ErrVersionConflict),
+			wantTransitions: 1,
+			wantFailed:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			dbService := &flakyStore{MemoryStore: store.NewMemoryStore(), failures: tt.failures, err: tt.err}
+			signal := types.Signal{UUID: uuid.New(), Ticker: "AAPL", Status: types.SignalStatusPending}
+			if err := dbService.SaveSignal(ctx, signal); err != nil {
+				t.Fatalf("SaveSignal() error = %v", err)
+			}
+			if err := signal.Transition(types.SignalStatusCancelled, "test"); err != nil {
+				t.Fatalf("Transition() error = %v", err)
+			}
+
+			tb := testBot(dbService, nil)
+			tb.signals = []types.Signal{signal}
+			tb.unsavedSignals[signal.UUID] = types.SignalStatusPending
+
+			err := tb.retrySignalWrites(ctx)
+
+			if dbService.transitions != tt.wantTransitions {
+				t.Errorf("%d transitions attempted, want %d", dbService.transitions, tt.wantTransitions)
+			}
+			if !tt.wantFailed {
+				if err != nil {
+					t.Fatalf("retrySignalWrites() error = %v", err)
+				}
+				if _, unsaved := tb.unsavedSignals[signal.UUID]; unsaved {
+					t.Errorf("signal is still unsaved after it was written")
+				}
+				return
+			}
+
+			var partialErr *store.PartialWriteError
+			if !errors.As(err, &partialErr) {
+				t.Fatalf("retrySignalWrites() error = %v, want a PartialWriteError", err)
+			}
+			if len(partialErr.FailedSignals) != 1 || partialErr.FailedSignals[0] != signal.UUID.String() {
+				t.Errorf("failed signals = %v, want %s", partialErr.FailedSignals, signal.UUID)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want it caused by %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRetryWritesStopsWithTheContext(t *testing.T) {
+	backoff := saveRetryBackoff
+	saveRetryBackoff = time.Hour
+	t.Cleanup(func() { saveRetryBackoff = backoff })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	writes := []retriedWrite{{
+		signalUUID: "signal-1",
+		write: func() error {
+			attempts++
+			cancel()
+			return errors.New("throttled")
+		},
+	}}
+
+	err := retryWrites(ctx, writes)
+
+	var partialErr *store.PartialWriteError
+	if !errors.As(err, &partialErr) || len(partialErr.FailedSignals) != 1 || !errors.Is(err, context.Canceled) {
+		t.Fatalf("retryWrites() error = %v, want signal-1 failed because the run ended", err)
+	}
+	if attempts != 1 {
+		t.Errorf("%d attempts, want 1", attempts)
+	}
+}
+
+func TestResumeBuyIntent(t *testing.T) {
+	tests := []struct {
+		name          string
+		placed        bool // The run died after the order was placed
+		open          bool // The order has not filled yet
+		holdsSlot     bool // The run died after the slot was counted
+		wantSlotsUsed int
+		wantOpenBuy   float64 // Added to the broker's position in the risk snapshot
+	}{
+		{name: "never placed", wantSlotsUsed: 1},
+		{name: "filled after the run died", placed: true, wantSlotsUsed: 2},
+		{name: "still open", placed: true, open: true, wantSlotsUsed: 2, wantOpenBuy: 1000},
+		{name: "slot already counted", placed: true, open: true, holdsSlot: true, wantSlotsUsed: 2, wantOpenBuy: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			dbService := store.NewMemoryStore()
+			broker := NewSimulatedBroker(10000)
+			broker.SetQuote("AAPL", 9.99, 10.01)
+
+			window := &types.AllocationWindow{
+				WindowStartDate:      date(t, "2026-11-23"),
+				AccountValue:         3000,
+				TotalSignalsInWindow: 3,
+				SlotsUsed:            1,
+				RemainingBudget:      3000,
+			}
+			signal := types.Signal{UUID: uuid.New(), Ticker: "AAPL", Status: types.SignalStatusPending}
+			if tt.holdsSlot {
+				window.SlotsUsed = 2
+				signal.AllocationWindowID = window.ID()
+			}
+			if err := dbService.SaveSignal(ctx, signal); err != nil {
+				t.Fatalf("SaveSignal() error = %v", err)
+			}
+
+			id := clientOrderID(&signal, OrderStepBuy)
+			intent := types.TransitionIntent{SignalUUID: signal.UUID, Ticker: "AAPL", Step: string(OrderStepBuy), FromStatus: signal.Status, ClientOrderID: id}
+			if err := dbService.SaveIntent(ctx, intent); err != nil {
+				t.Fatalf("SaveIntent() error = %v", err)
+			}
+			if tt.placed {
+				order, err := broker.BuyStock(ctx, "AAPL", 1000, 0, id)
+				if err != nil {
+					t.Fatalf("BuyStock() error = %v", err)
+				}
+				if tt.open {
+					// Undo the simulated fill, the order is still working
+					placed := broker.orders[order.ID]
+					placed.Status = "new"
+					placed.FilledQty = decimal.Zero
+					placed.FilledAvgPrice = nil
+					broker.positions["AAPL"] = 0
+					broker.cash = 10000
+				}
+			}
+
+			tb := testBot(dbService, broker)
+			tb.signals = []types.Signal{signal}
+			tb.allocationWindow = window
+
+			if err := tb.resumeIntents(ctx); err != nil {
+				t.Fatalf("resumeIntents() error = %v", err)
+			}
+			tb.loadRiskEngine(ctx)
+
+			resumed := tb.signals[0]
+			if (resumed.BuyOrderID != "") != tt.placed {
+				t.Errorf("buy order = %q, want attached %v", resumed.BuyOrderID, tt.placed)
+			}
+			if window.SlotsUsed != tt.wantSlotsUsed {
+				t.Errorf("window has %d slots used, want %d", window.SlotsUsed, tt.wantSlotsUsed)
+			}
+			if tt.placed && !window.HoldsSlot(&resumed) {
+				t.Errorf("resumed buy does not hold a slot in the window")
+			}
+			positions, err := broker.GetPositionValues(ctx)
+			if err != nil {
+				t.Fatalf("GetPositionValues() error = %v", err)
+			}
+			if got := tb.risk.positions["AAPL"] - positions["AAPL"]; math.Abs(got-tt.wantOpenBuy) > 0.01 {
+				t.Errorf("risk snapshot adds $%.2f of AAPL to the position, want $%.2f", got, tt.wantOpenBuy)
+			}
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// PlanAction is what a run would do with a signal
+type PlanAction string
+
+const (
+	PlanActionBuy         PlanAction = "BUY"
+	PlanActionSell        PlanAction = "SELL"
+	PlanActionFollowOrder PlanAction = "FOLLOW_ORDER" // An order is already working, it would be reconciled
+	PlanActionExpire      PlanAction = "EXPIRE"
+	PlanActionWait        PlanAction = "WAIT" // Buy or sell date not reached yet
+	PlanActionSkip        PlanAction = "SKIP" // Due, but the run could not act on it
+)
+
+// RunPlan is everything a run would do, computed without placing orders or writing data
+type RunPlan struct {
+	GeneratedAt         time.Time               `json:"generated_at"`
+	SessionDate         string                  `json:"session_date"`
+	SessionState        SessionState            `json:"session_state"`
+	PaperTrading        bool                    `json:"paper_trading"`
+	TradingMode         types.TradingMode       `json:"trading_mode"`
+	AccountValue        float64                 `json:"account_value"`
+	CashBalance         float64                 `json:"cash_balance"`
+	WindowRenewed       bool                    `json:"window_renewed"` // The allocation window would be replaced this run
+	AllocationWindow    *types.AllocationWindow `json:"allocation_window"`
+	AllocationPerSignal float64                 `json:"allocation_per_signal"`
+	OpenIntents         int                     `json:"open_intents"` // Unfinished transitions the run would repair first
+	BuysHalted          string                  `json:"buys_halted,omitempty"`
+	Actions             []PlannedAction         `json:"actions"`
+}
+
+// PlannedAction is the intended action for a single signal
+type PlannedAction struct {
+	SignalUUID   uuid.UUID           `json:"signal_uuid"`
+	Ticker       string              `json:"ticker"`
+	Status       types.SignalStatus  `json:"status"`
+	Action       PlanAction          `json:"action"`
+	Rank         int                 `json:"rank,omitempty"` // Funding rank of a due pending signal, 1 is funded first
+	Allocation   float64             `json:"allocation,omitempty"`
+	Quantity     float64             `json:"quantity,omitempty"`
+	Price        float64             `json:"price,omitempty"`       // Current ask for buys, bid for sells
+	LimitPrice   float64             `json:"limit_price,omitempty"` // Limit of a marketable limit order
+	Notional     float64             `json:"notional,omitempty"`
+	Fractionable *bool               `json:"fractionable,omitempty"`
+	OrderID      string              `json:"order_id,omitempty"`
+	RiskRule     RiskRule            `json:"risk_rule,omitempty"` // Risk rule the buy would break
+	Sizing       *types.PositionSize `json:"sizing,omitempty"`
+	Reason       string              `json:"reason,omitempty"`
+}
+
+// runPlan computes the plan of a run and emits it as JSON and as a Discord
+// summary. Nothing is written to the store and no order is placed, so it does
+// not take the run lock either.
+func (tb *TradingBot) runPlan(ctx context.Context) error {
+	log.Println("Dry run: computing the plan, no orders will be placed and nothing will be saved")
+
+	err := tb.loadCalendar(ctx)
+	if err != nil {
+		tb.notificationService.NotifyError("Trading Calendar", "Failed to load the trading calendar", err.Error())
+		return err
+	}
+
+	signals, allocationWindow, err := tb.dbService.LoadAllData(ctx)
+	if err != nil {
+		tb.notificationService.NotifyError("Data Load", "Failed to load data from DynamoDB", err.Error())
+		return fmt.Errorf("failed to load data: %w", err)
+	}
+	intents, err := tb.dbService.LoadIntents(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load intents: %w", err)
+	}
+	tb.signals = signals
+	tb.allocationWindow = allocationWindow
+
+	// The window update only changes the in-memory copy until saveData runs
+	previousWindow := tb.allocationWindow
+	_, err = tb.updateAllocationWindow(ctx)
+	if err != nil {
+		tb.notificationService.NotifyError("Allocation Window", "Failed to update allocation window", err.Error())
+		return err
+	}
+
+	// Promotions only change the loaded copies
+	waitlistChanges := tb.updateWaitlist(ctx, tb.calendar.Today(time.Now()), false)
+
+	allocationPerSignal, err := tb.getAllocationPerSignal()
+	if err != nil {
+		log.Printf("Warning: Failed to get allocation per signal: %v", err)
+		allocationPerSignal = tb.config.DefaultAllocationAmount
+	}
+
+	tb.loadRiskEngine(ctx)
+	tb.evaluateCircuitBreaker(ctx, false)
+
+	// Signals are planned in the order the run would process them
+	tb.rankSignals(tb.calendar.Today(time.Now()))
+
+	now := time.Now()
+	plan := &RunPlan{
+		GeneratedAt:         now,
+		SessionDate:         tb.calendar.Today(now).Format("2006-01-02"),
+		SessionState:        tb.sessionState,
+		PaperTrading:        tb.config.IsPaperTrading,
+		TradingMode:         tb.tradingMode(),
+		WindowRenewed:       tb.allocationWindow != previousWindow,
+		AllocationWindow:    tb.allocationWindow,
+		AllocationPerSignal: allocationPerSignal,
+		OpenIntents:         len(intents),
+		BuysHalted:          tb.buysHalted(),
+		Actions:             []PlannedAction{},
+	}
+	plan.AccountValue, _ = tb.broker.GetAccountValue(ctx)
+	plan.CashBalance, _ = tb.broker.GetCashBalance(ctx)
+
+	// Planned buys take their slots in a copy, the plan reports the window as the run starts
+	if tb.allocationWindow != nil {
+		plannedWindow := *tb.allocationWindow
+		tb.allocationWindow = &plannedWindow
+	}
+
+	for _, signal := range tb.signals {
+		switch waitlistChanges[signal.UUID] {
+		case types.SignalStatusExpired:
+			plan.Actions = append(plan.Actions, PlannedAction{
+				SignalUUID: signal.UUID,
+				Ticker:     signal.Ticker,
+				Status:     types.SignalStatusWaitlisted,
+				Action:     PlanActionExpire,
+				Reason:     signal.StatusReason,
+			})
+			continue
+		case types.SignalStatusPending:
+			action := tb.planSignal(ctx, signal, allocationPerSignal, tb.calendar.Today(now))
+			action.Status = types.SignalStatusWaitlisted
+			action.Reason = strings.TrimSpace("Promoted from the waitlist. " + action.Reason)
+			plan.Actions = append(plan.Actions, action)
+			continue
+		}
+
+		if !signal.Status.IsActive() {
+			continue
+		}
+		plan.Actions = append(plan.Actions, tb.planSignal(ctx, signal, allocationPerSignal, tb.calendar.Today(now)))
+	}
+
+	return tb.emitPlan(plan)
+}
+
+// planSignal decides what processSignal would do with a copy of the signal
+func (tb *TradingBot) planSignal(ctx context.Context, signal types.Signal, allocationPerSignal float64, currentDate time.Time) PlannedAction {
+	action := PlannedAction{
+		SignalUUID: signal.UUID,
+		Ticker:     signal.Ticker,
+		Status:     signal.Status,
+		Action:     PlanActionSkip,
+		Rank:       tb.fundingRanks[signal.UUID],
+	}
+
+	if tb.tradingMode() == types.TradingModePaused {
+		action.Reason = tb.tradingModeNote()
+		return action
+	}
+
+	switch signal.Status {
+	case types.SignalStatusWaitlisted:
+		action.Action = PlanActionWait
+		action.Reason = "Waitlisted until the allocation window has a free slot"
+		return action
+
+	case types.SignalStatusPending:
+		if signal.BuyOrderID != "" {
+			action.Action = PlanActionFollowOrder
+			action.OrderID = signal.BuyOrderID
+			action.Reason = "Buy order placed earlier"
+			return action
+		}
+
+		tb.rollSellDate(&signal)
+		buyDate := tb.calendar.NextTradingDay(signal.BuyDate)
+		if currentDate.Before(buyDate) {
+			action.Action = PlanActionWait
+			action.Reason = fmt.Sprintf("Buy date %s is in the future", buyDate.Format("2006-01-02"))
+			return action
+		}
+		if reason := tb.pendingExpiryReason(&signal, currentDate); reason != "" {
+			action.Action = PlanActionExpire
+			action.Reason = reason
+			return action
+		}
+		if !tb.canPlaceOrders() {
+			action.Reason = fmt.Sprintf("Market is %s", tb.sessionState)
+			return action
+		}
+		if reason := tb.buysHalted(); reason != "" {
+			action.Reason = "Buys halted: " + reason
+			return action
+		}
+		baseAllocation, windowFull, rejected := tb.windowAdmission(&signal, allocationPerSignal)
+		if windowFull != "" {
+			if rejected {
+				action.Action = PlanActionExpire
+			}
+			action.Reason = windowFull
+			return action
+		}
+		allocation, size := tb.sizeSignal(ctx, &signal, baseAllocation)
+		action = tb.planBuy(ctx, action, allocation)
+		action.Sizing = size
+		if action.Action != PlanActionBuy {
+			return action
+		}
+
+		quote, err := tb.checkQuote(ctx, signal.Ticker)
+		if err != nil {
+			action.Action = PlanActionSkip
+			action.Reason = "Quote check failed: " + err.Error()
+			return action
+		}
+		action.LimitPrice = tb.limitPrice(quote, OrderStepBuy)
+		if action.LimitPrice > 0 {
+			// A limit order is for the shares the allocation buys at the limit
+			action.Quantity = allocation / action.LimitPrice
+			if !*action.Fractionable {
+				action.Quantity = math.Floor(action.Quantity)
+			}
+			action.Notional = action.Quantity * action.Price
+		}
+
+		violation := &RiskViolation{RiskRuleUnavailable, "the account snapshot could not be loaded"}
+		if tb.risk != nil {
+			price, notional := buyRiskTerms(quote, action.LimitPrice, allocation)
+			violation = tb.risk.CheckBuy(signal.Ticker, price, notional)
+		}
+		if violation != nil {
+			action.Action = PlanActionSkip
+			action.RiskRule = violation.Rule
+			action.Reason = violation.Error()
+			return action
+		}
+		tb.risk.RecordBuy(signal.Ticker, allocation)
+		if tb.allocationWindow != nil {
+			tb.allocationWindow.TakeSlot(&signal, time.Now())
+		}
+
+		action.Reason = tb.approvalNote(&signal, OrderStepBuy, time.Now())
+		return action
+
+	case types.SignalStatusBuying, types.SignalStatusPartiallyFilled:
+		action.Action = PlanActionFollowOrder
+		action.OrderID = signal.BuyOrderID
+		action.Reason = "Buy order is working"
+		return action
+
+	case types.SignalStatusBought:
+		if signal.SellOrderID != "" {
+			action.Action = PlanActionFollowOrder
+			action.OrderID = signal.SellOrderID
+			action.Reason = "Sell order placed earlier"
+			return action
+		}
+
+		tb.rollSellDate(&signal)
+		sellDate := tb.calendar.NextTradingDay(signal.SellDate)
+		liquidating := tb.tradingMode() == types.TradingModeLiquidateAll
+		if currentDate.Before(sellDate) && !liquidating {
+			action.Action = PlanActionWait
+			action.Reason = fmt.Sprintf("Holding until sell date %s", sellDate.Format("2006-01-02"))
+			return action
+		}
+		if !tb.canPlaceOrders() {
+			action.Reason = fmt.Sprintf("Market is %s", tb.sessionState)
+			return action
+		}
+		quote, err := tb.checkQuote(ctx, signal.Ticker)
+		if err != nil {
+			action.Reason = "Quote check failed: " + err.Error()
+			return action
+		}
+		action = tb.planSell(ctx, action, signal.NumStocks)
+		action.LimitPrice = tb.limitPrice(quote, OrderStepSell)
+		if liquidating {
+			action.Reason = tb.tradingModeNote()
+			return action
+		}
+		action.Reason = tb.approvalNote(&signal, OrderStepSell, time.Now())
+		return action
+
+	case types.SignalStatusSelling:
+		action.Action = PlanActionFollowOrder
+		action.OrderID = signal.SellOrderID
+		action.Reason = "Sell order is working"
+		return action
+
+	default:
+		action.Reason = fmt.Sprintf("Unknown status %s", signal.Status)
+		return action
+	}
+}
+
+// planBuy sizes a buy the same way the broker would: a notional order for the
+// allocation rounded down to the cent for fractionable tickers, whole shares at
+// the current ask otherwise
+func (tb *TradingBot) planBuy(ctx context.Context, action PlannedAction, allocation float64) PlannedAction {
+	action.Allocation = allocation
+
+	fractionable, err := tb.broker.IsFractionable(ctx, action.Ticker)
+	if err != nil {
+		fractionable = true // The broker assumes fractional shares when it cannot check
+	}
+	action.Fractionable = &fractionable
+
+	price, err := tb.broker.GetCurrentPrice(ctx, action.Ticker)
+	if err == nil && price > 0 {
+		action.Price = price
+	}
+
+	if fractionable {
+		notional := notionalAmount(allocation)
+		if notional <= 0 {
+			action.Reason = fmt.Sprintf("Allocation $%.4f is less than a cent", allocation)
+			return action
+		}
+
+		// The shares follow from the fill price, the ask only estimates them
+		action.Action = PlanActionBuy
+		action.Notional = notional
+		if action.Price > 0 {
+			action.Quantity = notional / action.Price
+		}
+		return action
+	}
+
+	if err != nil {
+		action.Reason = fmt.Sprintf("Could not get the ask price: %v", err)
+		return action
+	}
+	if price <= 0 {
+		action.Reason = fmt.Sprintf("Invalid ask price $%.2f", price)
+		return action
+	}
+
+	shares := math.Floor(allocation / price)
+	if shares <= 0 {
+		action.Reason = fmt.Sprintf("Allocation $%.2f buys no whole share at $%.2f", allocation, price)
+		return action
+	}
+
+	action.Action = PlanActionBuy
+	action.Quantity = shares
+	action.Notional = shares * price
+	return action
+}
+
+// planSell prices a sell of the held shares at the current bid
+func (tb *TradingBot) planSell(ctx context.Context, action PlannedAction, quantity float64) PlannedAction {
+	action.Action = PlanActionSell
+	action.Quantity = quantity
+
+	price, err := tb.broker.GetBidPrice(ctx, action.Ticker)
+	if err != nil {
+		log.Printf("Warning: Could not get bid price for %s: %v", action.Ticker, err)
+		return action
+	}
+
+	action.Price = price
+	action.Notional = quantity * price
+	return action
+}
+
+// emitPlan writes the plan as JSON to stdout, and to PlanOutputPath when set,
+// and posts its summary to Discord
+func (tb *TradingBot) emitPlan(plan *RunPlan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+
+	fmt.Println(string(data))
+	if tb.config.PlanOutputPath != "" {
+		if err := os.WriteFile(tb.config.PlanOutputPath, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write plan: %w", err)
+		}
+		log.Printf("Wrote plan to %s", tb.config.PlanOutputPath)
+	}
+
+	var lines []string
+	counts := make(map[PlanAction]int)
+	for _, action := range plan.Actions {
+		counts[action.Action]++
+
+		switch action.Action {
+		case PlanActionBuy, PlanActionSell:
+			lines = append(lines, fmt.Sprintf("%s **%s** %.4f @ $%.2f ($%.2f)",
+				action.Action, action.Ticker, action.Quantity, action.Price, action.Notional))
+		case PlanActionExpire:
+			lines = append(lines, fmt.Sprintf("%s **%s**: %s", action.Action, action.Ticker, action.Reason))
+		case PlanActionSkip:
+			if action.Rank > 0 {
+				lines = append(lines, fmt.Sprintf("%s **%s** (rank %d): %s", action.Action, action.Ticker, action.Rank, action.Reason))
+			} else {
+				lines = append(lines, fmt.Sprintf("%s **%s**: %s", action.Action, action.Ticker, action.Reason))
+			}
+		}
+	}
+
+	tb.notificationService.NotifyRunPlan(plan.SessionDate, string(plan.SessionState), plan.AllocationPerSignal,
+		counts[PlanActionBuy], counts[PlanActionSell], counts[PlanActionExpire], counts[PlanActionSkip], lines)
+
+	log.Printf("Dry run plan: %d buys, %d sells, %d expiries, %d skipped, %d waiting, %d orders followed",
+		counts[PlanActionBuy], counts[PlanActionSell], counts[PlanActionExpire], counts[PlanActionSkip],
+		counts[PlanActionWait], counts[PlanActionFollowOrder])
+	return nil
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+func TestDryRunWritesNothing(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	storePath := filepath.Join(dir, "store.json")
+	calendarPath := filepath.Join(dir, "calendar.json")
+
+	dbService, err := store.NewFileStore(storePath)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	// A due signal, a signal left with an open intent and a window that has
+	// ended, so the plan would buy, repair an intent and renew the window
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	due := types.Signal{UUID: uuid.New(), Ticker: "AAPL", Status: types.SignalStatusPending,
+		BuyDate: today.AddDate(0, 0, -1), SellDate: today.AddDate(0, 0, 14)}
+	interrupted := types.Signal{UUID: uuid.New(), Ticker: "MSFT", Status: types.SignalStatusPending,
+		BuyDate: today.AddDate(0, 0, -1), SellDate: today.AddDate(0, 0, 14)}
+	for _, signal := range []types.Signal{due, interrupted} {
+		if err := dbService.SaveSignal(ctx, signal); err != nil {
+			t.Fatalf("SaveSignal() error = %v", err)
+		}
+	}
+	intent := types.TransitionIntent{SignalUUID: interrupted.UUID, Ticker: "MSFT", Step: string(OrderStepBuy),
+		FromStatus: types.SignalStatusPending, ClientOrderID: clientOrderID(&interrupted, OrderStepBuy)}
+	if err := dbService.SaveIntent(ctx, intent); err != nil {
+		t.Fatalf("SaveIntent() error = %v", err)
+	}
+	window := &types.AllocationWindow{
+		WindowStartDate:      today.AddDate(0, 0, -30),
+		WindowEndDate:        today.AddDate(0, 0, -10),
+		AccountValue:         10000,
+		TotalSignalsInWindow: 5,
+		RemainingBudget:      10000,
+	}
+	if err := dbService.SaveAllocationWindow(ctx, window); err != nil {
+		t.Fatalf("SaveAllocationWindow() error = %v", err)
+	}
+
+	stored, err := os.ReadFile(storePath)
+	if err != nil {
+		t.Fatalf("failed to read store: %v", err)
+	}
+
+	broker := NewSimulatedBroker(10000)
+	broker.SetQuote("AAPL", 9.99, 10.01)
+	broker.SetQuote("MSFT", 399.9, 400.1)
+
+	tb := testBot(dbService, broker)
+	tb.config = &Config{
+		DryRun:                true,
+		CalendarCachePath:     calendarPath,
+		CalendarCacheTTLHours: 24,
+		WindowDurationDays:    30,
+		MaxSignalsPerWindow:   5,
+		PlanOutputPath:        filepath.Join(dir, "plan.json"),
+	}
+	tb.sizing = fixedCountSizing{}
+
+	if err := tb.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	after, err := os.ReadFile(storePath)
+	if err != nil {
+		t.Fatalf("failed to read store: %v", err)
+	}
+	if !bytes.Equal(after, stored) {
+		t.Errorf("dry run changed the store")
+	}
+	if _, err := os.Stat(calendarPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("dry run wrote the calendar cache (stat error %v)", err)
+	}
+	if _, err := os.Stat(tb.config.PlanOutputPath); err != nil {
+		t.Errorf("dry run did not write its plan: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// parseSignalRanking splits the configured ranking into its keys, the default
+// ranking when none is configured
+func parseSignalRanking(ranking string) ([]string, error) {
+	if strings.TrimSpace(ranking) == "" {
+		ranking = DefaultSignalRanking
+	}
+
+	var keys []string
+	seen := make(map[string]bool)
+	for _, key := range strings.Split(ranking, ",") {
+		key = strings.ToLower(strings.TrimSpace(key))
+		switch key {
+		case RankByPriority, RankByConviction, RankByCreated, RankByBuyDate:
+		default:
+			return nil, fmt.Errorf("unknown signal ranking key: %q", key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("signal ranking key %s is listed more than once", key)
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// compareSignals returns a negative number when a is funded before b and a
+// positive one when b is. Signals that tie on every key are ordered by UUID, so
+// the order never depends on how the store returned them.
+func compareSignals(a, b *types.Signal, keys []string) int {
+	for _, key := range keys {
+		var c int
+		switch key {
+		case RankByPriority:
+			c = cmp.Compare(b.Priority, a.Priority)
+		case RankByConviction:
+			c = cmp.Compare(b.EffectiveConviction(), a.EffectiveConviction())
+		case RankByCreated:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		case RankByBuyDate:
+			c = a.BuyDate.Compare(b.BuyDate)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.UUID.String(), b.UUID.String())
+}
+
+// rankSignals orders the run's signals for processing. Signals that are not
+// pending come first, as their sells and fills free up cash and slots, followed
+// by the pending signals in funding order. Every pending signal whose buy date
+// has been reached gets its rank among them, 1 being funded first.
+func (tb *TradingBot) rankSignals(currentDate time.Time) {
+	sort.SliceStable(tb.signals, func(i, j int) bool {
+		a, b := &tb.signals[i], &tb.signals[j]
+		aPending := a.Status == types.SignalStatusPending
+		bPending := b.Status == types.SignalStatusPending
+		if aPending != bPending {
+			return bPending
+		}
+		return aPending && compareSignals(a, b, tb.ranking) < 0
+	})
+
+	tb.fundingRanks = make(map[uuid.UUID]int)
+	for i := range tb.signals {
+		signal := &tb.signals[i]
+		if signal.Status != types.SignalStatusPending || currentDate.Before(tb.calendar.NextTradingDay(signal.BuyDate)) {
+			continue
+		}
+		tb.fundingRanks[signal.UUID] = len(tb.fundingRanks) + 1
+	}
+
+	if len(tb.fundingRanks) > 1 {
+		log.Printf("Ranked %d due signals for funding by %s", len(tb.fundingRanks), strings.Join(tb.ranking, ", "))
+	}
+}
+
+// carryOver records why a due signal was not funded this run, detail being
+// the run's message for the log. It stays pending and competes for funds again
+// on the next run. The record only changes when the reason or risk rule does,
+// so a signal held back for the same reason run after run is not written
+// again. The sessions it is carried over in do not count toward its pending
+// limit.
+func (tb *TradingBot) carryOver(signal *types.Signal, carryOver types.CarryOver, detail string, currentDate time.Time) {
+	rank := tb.fundingRanks[signal.UUID]
+	previous := signal.CarryOver
+	if previous != nil && previous.Reason == carryOver.Reason && previous.Rule == carryOver.Rule {
+		log.Printf("Signal %s (rank %d) still carried over to the next run: %s", signal.UUID, rank, detail)
+		return
+	}
+
+	now := time.Now()
+	carryOver.Rank = rank
+	carryOver.Since = currentDate
+	carryOver.Sessions = excusedSessions(tb.calendar, previous, currentDate)
+	carryOver.At = now
+	signal.CarryOver = &carryOver
+	signal.UpdatedAt = now
+
+	log.Printf("Signal %s (rank %d) carried over to the next run: %s", signal.UUID, rank, detail)
+}
+
+// endCarryOver closes the signal's carry-over once a run gets as far as trying
+// to buy it. The sessions it was carried over in until then stay excused.
+func (tb *TradingBot) endCarryOver(signal *types.Signal, currentDate time.Time) {
+	carryOver := signal.CarryOver
+	if carryOver == nil || carryOver.Reason == "" {
+		return
+	}
+
+	now := time.Now()
+	signal.CarryOver = &types.CarryOver{
+		Sessions: excusedSessions(tb.calendar, carryOver, currentDate),
+		At:       now,
+	}
+	signal.UpdatedAt = now
+}
@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+func TestParseSignalRanking(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranking string
+		want    []string
+		wantErr bool
+	}{
+		{name: "default", want: []string{RankByPriority, RankByConviction, RankByCreated}},
+		{name: "spaces and case", ranking: " Buy_Date , priority", want: []string{RankByBuyDate, RankByPriority}},
+		{name: "unknown key", ranking: "priority,size", wantErr: true},
+		{name: "repeated key", ranking: "created,priority,created", wantErr: true},
+		{name: "empty key", ranking: "priority,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSignalRanking(tt.ranking)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseSignalRanking(%q) = %v, want an error", tt.ranking, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSignalRanking(%q) error = %v", tt.ranking, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSignalRanking(%q) = %v, want %v", tt.ranking, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankSignals(t *testing.T) {
+	calendar := testCalendar(t, "2026-11-01", "2026-12-31", "2026-11-26")
+	created := date(t, "2026-11-01")
+
+	// Each signal is named by its ticker, the UUIDs only break full ties
+	signal := func(ticker string, status types.SignalStatus, priority, conviction int, createdDay int, buyDate string) types.Signal {
+		return types.Signal{
+			UUID:       uuid.New(),
+			Ticker:     ticker,
+			Status:     status,
+			Priority:   priority,
+			Conviction: conviction,
+			CreatedAt:  created.AddDate(0, 0, createdDay),
+			BuyDate:    date(t, buyDate),
+		}
+	}
+
+	signals := []types.Signal{
+		signal("LOW", types.SignalStatusPending, 1, 5, 0, "2026-11-23"),
+		signal("HELD", types.SignalStatusBought, 0, 0, 0, "2026-11-02"),
+		signal("LATE", types.SignalStatusPending, 5, 3, 2, "2026-11-23"),
+		signal("EARLY", types.SignalStatusPending, 5, 3, 1, "2026-11-23"),
+		signal("STRONG", types.SignalStatusPending, 5, 5, 3, "2026-11-23"),
+		signal("FUTURE", types.SignalStatusPending, 9, 5, 0, "2026-11-30"),
+		signal("HOLIDAY", types.SignalStatusPending, 0, 1, 0, "2026-11-26"),
+		signal("SELLING", types.SignalStatusSelling, 0, 0, 0, "2026-11-02"),
+	}
+
+	tests := []struct {
+		name        string
+		ranking     []string
+		currentDate string
+		wantOrder   []string
+		wantRanks   map[string]int
+	}{
+		{
+			name:        "default ranking",
+			ranking:     []string{RankByPriority, RankByConviction, RankByCreated},
+			currentDate: "2026-11-25",
+			wantOrder:   []string{"HELD", "SELLING", "FUTURE", "STRONG", "EARLY", "LATE", "LOW", "HOLIDAY"},
+			wantRanks:   map[string]int{"STRONG": 1, "EARLY": 2, "LATE": 3, "LOW": 4},
+		},
+		{
+			name:        "buy date rolled past a holiday becomes due",
+			ranking:     []string{RankByPriority, RankByConviction, RankByCreated},
+			currentDate: "2026-11-27",
+			wantOrder:   []string{"HELD", "SELLING", "FUTURE", "STRONG", "EARLY", "LATE", "LOW", "HOLIDAY"},
+			wantRanks:   map[string]int{"STRONG": 1, "EARLY": 2, "LATE": 3, "LOW": 4, "HOLIDAY": 5},
+		},
+		{
+			name:        "conviction first",
+			ranking:     []string{RankByConviction, RankByPriority, RankByCreated},
+			currentDate: "2026-11-25",
+			wantOrder:   []string{"HELD", "SELLING", "FUTURE", "STRONG", "LOW", "EARLY", "LATE", "HOLIDAY"},
+			wantRanks:   map[string]int{"STRONG": 1, "LOW": 2, "EARLY": 3, "LATE": 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &TradingBot{
+				signals:  append([]types.Signal(nil), signals...),
+				ranking:  tt.ranking,
+				calendar: calendar,
+			}
+
+			tb.rankSignals(date(t, tt.currentDate))
+
+			var order []string
+			ranks := make(map[string]int)
+			for _, signal := range tb.signals {
+				order = append(order, signal.Ticker)
+				if rank, ok := tb.fundingRanks[signal.UUID]; ok {
+					ranks[signal.Ticker] = rank
+				}
+			}
+			if !reflect.DeepEqual(order, tt.wantOrder) {
+				t.Errorf("order = %v, want %v", order, tt.wantOrder)
+			}
+			if !reflect.DeepEqual(ranks, tt.wantRanks) {
+				t.Errorf("funding ranks = %v, want %v", ranks, tt.wantRanks)
+			}
+		})
+	}
+}
+
+func TestCarryOver(t *testing.T) {
+	tb := &TradingBot{calendar: testCalendar(t, "2026-11-01", "2026-12-31", "2026-11-26")}
+	signal := &types.Signal{UUID: uuid.New(), BuyDate: date(t, "2026-11-23")}
+	tb.fundingRanks = map[uuid.UUID]int{signal.UUID: 2}
+
+	steps := []struct {
+		name        string
+		reason      types.CarryOverReason // Empty ends the carry-over
+		rule        string
+		currentDate string
+		wantWrite   bool
+		wantSince   string
+		wantExcused int // Sessions excused before the current carry-over
+	}{
+		{name: "first carry-over", reason: types.CarryOverWindowFull, currentDate: "2026-11-23", wantWrite: true, wantSince: "2026-11-23"},
+		{name: "same reason is not rewritten", reason: types.CarryOverWindowFull, currentDate: "2026-11-24", wantSince: "2026-11-23"},
+		{name: "new reason keeps the excused sessions", reason: types.CarryOverBuysHalted, currentDate: "2026-11-25", wantWrite: true, wantSince: "2026-11-25", wantExcused: 2},
+		{name: "buy attempted", currentDate: "2026-11-30", wantWrite: true, wantExcused: 4},
+		{name: "carried over again", reason: types.CarryOverRiskLimit, rule: string(RiskRuleCashReserve), currentDate: "2026-12-01", wantWrite: true, wantSince: "2026-12-01", wantExcused: 4},
+		{name: "same rule is not rewritten", reason: types.CarryOverRiskLimit, rule: string(RiskRuleCashReserve), currentDate: "2026-12-02", wantSince: "2026-12-01", wantExcused: 4},
+		{name: "new rule is rewritten", reason: types.CarryOverRiskLimit, rule: string(RiskRulePriceCeiling), currentDate: "2026-12-03", wantWrite: true, wantSince: "2026-12-03", wantExcused: 6},
+	}
+
+	for _, step := range steps {
+		signal.UpdatedAt = time.Time{}
+
+		if step.reason == "" {
+			tb.endCarryOver(signal, date(t, step.currentDate))
+		} else {
+			tb.carryOver(signal, types.CarryOver{Reason: step.reason, Rule: step.rule}, step.name, date(t, step.currentDate))
+		}
+
+		if written := !signal.UpdatedAt.IsZero(); written != step.wantWrite {
+			t.Fatalf("%s: written = %v, want %v", step.name, written, step.wantWrite)
+		}
+		carryOver := signal.CarryOver
+		if carryOver.Reason != step.reason || carryOver.Rule != step.rule || carryOver.Sessions != step.wantExcused {
+			t.Errorf("%s: carry-over = %q %q with %d excused sessions, want %q %q with %d",
+				step.name, carryOver.Reason, carryOver.Rule, carryOver.Sessions, step.reason, step.rule, step.wantExcused)
+		}
+		if step.wantSince != "" && (!carryOver.Since.Equal(date(t, step.wantSince)) || carryOver.Rank != 2) {
+			t.Errorf("%s: carried over since %s at rank %d, want since %s at rank 2",
+				step.name, carryOver.Since.Format("2006-01-02"), carryOver.Rank, step.wantSince)
+		}
+	}
+}
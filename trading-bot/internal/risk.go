@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// RiskRule names a pre-trade risk check
+type RiskRule string
+
+const (
+	RiskRuleTickerEquity  RiskRule = "MAX_TICKER_EQUITY_PERCENT"
+	RiskRuleGrossExposure RiskRule = "MAX_GROSS_EXPOSURE_PERCENT"
+	RiskRuleCashReserve   RiskRule = "MIN_CASH_RESERVE"
+	RiskRuleOrdersPerRun  RiskRule = "MAX_NEW_ORDERS_PER_RUN"
+	RiskRulePriceFloor    RiskRule = "MIN_PRICE"
+	RiskRulePriceCeiling  RiskRule = "MAX_PRICE"
+	RiskRuleUnavailable   RiskRule = "ACCOUNT_UNAVAILABLE" // The account snapshot could not be loaded
+)
+
+// RiskLimits holds the pre-trade limits, a zero limit is not enforced
+type RiskLimits struct {
+	MaxTickerEquityPercent  float64 // Largest position in one ticker, as a percentage of equity
+	MaxGrossExposurePercent float64 // Largest total position value, as a percentage of equity
+	MinCashReserve          float64 // Cash that must remain after a buy
+	MaxNewOrdersPerRun      int     // Buy orders a single run may place
+	MinPrice                float64 // Lowest share price that may be bought
+	MaxPrice                float64 // Highest share price that may be bought
+}
+
+// RiskViolation is a buy that broke a risk rule
+type RiskViolation struct {
+	Rule   RiskRule
+	Reason string
+}
+
+// Error implements the error interface
+func (v *RiskViolation) Error() string {
+	return fmt.Sprintf("risk rule %s: %s", v.Rule, v.Reason)
+}
+
+// RiskEngine checks buys against the risk limits before they reach the broker.
+// It works from an account snapshot taken at the start of the run and adds
+// every buy it allows, so limits hold across all the orders of a run. Sells
+// only reduce risk and are never checked.
+type RiskEngine struct {
+	limits    RiskLimits
+	equity    float64
+	cash      float64
+	positions map[string]float64 // Market value by ticker
+	newOrders int
+}
+
+// newRiskEngine takes the account snapshot the risk checks are based on
+func newRiskEngine(ctx context.Context, broker Broker, limits RiskLimits) (*RiskEngine, error) {
+	equity, err := broker.GetAccountValue(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account value: %w", err)
+	}
+	cash, err := broker.GetCashBalance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cash balance: %w", err)
+	}
+	positions, err := broker.GetPositionValues(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+
+	return &RiskEngine{
+		limits:    limits,
+		equity:    equity,
+		cash:      cash,
+		positions: positions,
+	}, nil
+}
+
+// CheckBuy returns the first rule a buy of notional dollars at price would break, or nil
+func (r *RiskEngine) CheckBuy(ticker string, price, notional float64) *RiskViolation {
+	limits := r.limits
+	ticker = strings.ToUpper(ticker)
+
+	if limits.MaxNewOrdersPerRun > 0 && r.newOrders >= limits.MaxNewOrdersPerRun {
+		return &RiskViolation{RiskRuleOrdersPerRun,
+			fmt.Sprintf("%d buy orders already placed this run (max %d)", r.newOrders, limits.MaxNewOrdersPerRun)}
+	}
+
+	if limits.MinPrice > 0 && price < limits.MinPrice {
+		return &RiskViolation{RiskRulePriceFloor,
+			fmt.Sprintf("price $%.2f is below the floor of $%.2f", price, limits.MinPrice)}
+	}
+	if limits.MaxPrice > 0 && price > limits.MaxPrice {
+		return &RiskViolation{RiskRulePriceCeiling,
+			fmt.Sprintf("price $%.2f is above the ceiling of $%.2f", price, limits.MaxPrice)}
+	}
+
+	if limits.MinCashReserve > 0 && r.cash-notional < limits.MinCashReserve {
+		return &RiskViolation{RiskRuleCashReserve,
+			fmt.Sprintf("buying $%.2f would leave $%.2f cash, below the $%.2f reserve", notional, r.cash-notional, limits.MinCashReserve)}
+	}
+
+	if r.equity <= 0 {
+		return &RiskViolation{RiskRuleUnavailable, fmt.Sprintf("account equity is $%.2f", r.equity)}
+	}
+
+	if limits.MaxTickerEquityPercent > 0 {
+		tickerPercent := (r.positions[ticker] + notional) / r.equity * 100
+		if tickerPercent > limits.MaxTickerEquityPercent {
+			return &RiskViolation{RiskRuleTickerEquity,
+				fmt.Sprintf("%s would be %.2f%% of equity (max %.2f%%)", ticker, tickerPercent, limits.MaxTickerEquityPercent)}
+		}
+	}
+
+	if limits.MaxGrossExposurePercent > 0 {
+		gross := notional
+		for _, value := range r.positions {
+			gross += value
+		}
+		grossPercent := gross / r.equity * 100
+		if grossPercent > limits.MaxGrossExposurePercent {
+			return &RiskViolation{RiskRuleGrossExposure,
+				fmt.Sprintf("gross exposure would be %.2f%% of equity (max %.2f%%)", grossPercent, limits.MaxGrossExposurePercent)}
+		}
+	}
+
+	return nil
+}
+
+// RecordBuy adds a buy that was placed to the snapshot
+func (r *RiskEngine) RecordBuy(ticker string, notional float64) {
+	r.positions[strings.ToUpper(ticker)] += notional
+	r.cash -= notional
+	r.newOrders++
+}
+
+// RecordOpenBuy adds a buy an earlier run placed that has not filled yet, which
+// the account snapshot does not show. It does not count toward the run's orders.
+func (r *RiskEngine) RecordOpenBuy(ticker string, notional float64) {
+	r.positions[strings.ToUpper(ticker)] += notional
+	r.cash -= notional
+}
+
+// loadRiskEngine takes the account snapshot for the run's risk checks. Without
+// it every buy is blocked, while sells go ahead.
+func (tb *TradingBot) loadRiskEngine(ctx context.Context) {
+	limits := RiskLimits{
+		MaxTickerEquityPercent:  tb.config.MaxTickerEquityPercent,
+		MaxGrossExposurePercent: tb.config.MaxGrossExposurePercent,
+		MinCashReserve:          tb.config.MinCashReserve,
+		MaxNewOrdersPerRun:      tb.config.MaxNewOrdersPerRun,
+		MinPrice:                tb.config.MinPrice,
+		MaxPrice:                tb.config.MaxPrice,
+	}
+
+	risk, err := newRiskEngine(ctx, tb.broker, limits)
+	if err != nil {
+		log.Printf("Warning: Failed to load risk snapshot, no buys will be placed this run: %v", err)
+		tb.notificationService.NotifyError("Risk Engine", "Failed to load the account snapshot, buys are blocked this run", err.Error())
+		tb.risk = nil
+		return
+	}
+	for ticker, notional := range tb.resumedBuys {
+		risk.RecordOpenBuy(ticker, notional)
+	}
+	tb.risk = risk
+}
+
+// buyRiskTerms returns the share price and notional a buy of allocation is
+// checked at: the limit price of a limit order, otherwise the checked quote's
+// ask, and the most the order can spend whatever its share rounding.
+func buyRiskTerms(quote Quote, limitPrice, allocation float64) (float64, float64) {
+	price := quote.AskPrice
+	if limitPrice > 0 {
+		price = limitPrice
+	}
+	return price, notionalAmount(allocation)
+}
+
+// checkBuyRisk checks the signal's buy, priced from the quote it will be
+// placed on, against the risk limits. A violation is added to the run summary
+// and recorded on the signal unless the same rule already blocked it.
+func (tb *TradingBot) checkBuyRisk(signal *types.Signal, allocation float64, quote Quote, limitPrice float64) *RiskViolation {
+	violation := &RiskViolation{RiskRuleUnavailable, "the account snapshot could not be loaded this run"}
+	if tb.risk != nil {
+		price, notional := buyRiskTerms(quote, limitPrice, allocation)
+		violation = tb.risk.CheckBuy(signal.Ticker, price, notional)
+	}
+
+	if violation == nil {
+		return nil
+	}
+
+	if signal.RiskRejection == nil || signal.RiskRejection.Rule != string(violation.Rule) {
+		now := time.Now()
+		signal.RiskRejection = &types.RiskRejection{
+			Rule:       string(violation.Rule),
+			Reason:     violation.Reason,
+			RejectedAt: now,
+		}
+		signal.UpdatedAt = now
+	}
+	tb.riskRejections = append(tb.riskRejections, fmt.Sprintf("%s: %s", signal.Ticker, violation.Error()))
+	return violation
+}
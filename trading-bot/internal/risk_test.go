@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestRiskEngineCheckBuy(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    RiskLimits
+		equity    float64
+		cash      float64
+		positions map[string]float64
+		newOrders int
+		ticker    string
+		price     float64
+		notional  float64
+		want      RiskRule // Empty when the buy is allowed
+	}{
+		{
+			name:   "no limits",
+			equity: 10000, cash: 10000,
+			ticker: "AAPL", price: 0.5, notional: 9000,
+		},
+		{
+			name:   "orders per run used up",
+			limits: RiskLimits{MaxNewOrdersPerRun: 2}, newOrders: 2,
+			equity: 10000, cash: 10000,
+			ticker: "AAPL", price: 10, notional: 100,
+			want: RiskRuleOrdersPerRun,
+		},
+		{
+			name:   "below the price floor",
+			limits: RiskLimits{MinPrice: 1},
+			equity: 10000, cash: 10000,
+			ticker: "PENNY", price: 0.99, notional: 100,
+			want: RiskRulePriceFloor,
+		},
+		{
+			name:   "at the price floor",
+			limits: RiskLimits{MinPrice: 1},
+			equity: 10000, cash: 10000,
+			ticker: "PENNY", price: 1, notional: 100,
+		},
+		{
+			name:   "above the price ceiling",
+			limits: RiskLimits{MaxPrice: 500},
+			equity: 10000, cash: 10000,
+			ticker: "BRK", price: 600, notional: 600,
+			want: RiskRulePriceCeiling,
+		},
+		{
+			name:   "cash reserve",
+			limits: RiskLimits{MinCashReserve: 1000},
+			equity: 10000, cash: 1500,
+			ticker: "AAPL", price: 10, notional: 600,
+			want: RiskRuleCashReserve,
+		},
+		{
+			name:   "ticker concentration with an existing position",
+			limits: RiskLimits{MaxTickerEquityPercent: 10},
+			equity: 10000, cash: 5000, positions: map[string]float64{"AAPL": 600},
+			ticker: "aapl", price: 10, notional: 500,
+			want: RiskRuleTickerEquity,
+		},
+		{
+			name:   "ticker concentration within the limit",
+			limits: RiskLimits{MaxTickerEquityPercent: 10},
+			equity: 10000, cash: 5000, positions: map[string]float64{"MSFT": 600},
+			ticker: "AAPL", price: 10, notional: 1000,
+		},
+		{
+			name:   "gross exposure",
+			limits: RiskLimits{MaxGrossExposurePercent: 100},
+			equity: 10000, cash: 1000, positions: map[string]float64{"AAPL": 5000, "MSFT": 4500},
+			ticker: "NVDA", price: 10, notional: 600,
+			want: RiskRuleGrossExposure,
+		},
+		{
+			name:   "no equity",
+			limits: RiskLimits{MaxTickerEquityPercent: 10},
+			equity: 0, cash: 1000,
+			ticker: "AAPL", price: 10, notional: 100,
+			want: RiskRuleUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			positions := tt.positions
+			if positions == nil {
+				positions = make(map[string]float64)
+			}
+			engine := &RiskEngine{
+				limits:    tt.limits,
+				equity:    tt.equity,
+				cash:      tt.cash,
+				positions: positions,
+				newOrders: tt.newOrders,
+			}
+
+			violation := engine.CheckBuy(tt.ticker, tt.price, tt.notional)
+
+			var got RiskRule
+			if violation != nil {
+				got = violation.Rule
+			}
+			if got != tt.want {
+				t.Errorf("CheckBuy() = %v, want rule %q", violation, tt.want)
+			}
+		})
+	}
+}
+
+func TestRiskEngineRecordBuy(t *testing.T) {
+	engine := &RiskEngine{
+		limits:    RiskLimits{MaxNewOrdersPerRun: 2, MaxTickerEquityPercent: 10},
+		equity:    10000,
+		cash:      10000,
+		positions: make(map[string]float64),
+	}
+
+	steps := []struct {
+		ticker   string
+		notional float64
+		want     RiskRule
+	}{
+		{ticker: "AAPL", notional: 600},
+		{ticker: "aapl", notional: 600, want: RiskRuleTickerEquity},
+		{ticker: "MSFT", notional: 600},
+		{ticker: "NVDA", notional: 100, want: RiskRuleOrdersPerRun},
+	}
+
+	for i, step := range steps {
+		violation := engine.CheckBuy(step.ticker, 10, step.notional)
+
+		var got RiskRule
+		if violation != nil {
+			got = violation.Rule
+		}
+		if got != step.want {
+			t.Fatalf("step %d: CheckBuy(%s) = %v, want rule %q", i, step.ticker, violation, step.want)
+		}
+		if violation == nil {
+			engine.RecordBuy(step.ticker, step.notional)
+		}
+	}
+}
+
+func TestBuyRiskTerms(t *testing.T) {
+	quote := Quote{BidPrice: 9.95, AskPrice: 10.05}
+
+	tests := []struct {
+		name         string
+		limitPrice   float64
+		allocation   float64
+		wantPrice    float64
+		wantNotional float64
+	}{
+		{name: "market order at the ask", allocation: 1000.009, wantPrice: 10.05, wantNotional: 1000},
+		{name: "limit order at its limit", limitPrice: 10.10, allocation: 500, wantPrice: 10.10, wantNotional: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, notional := buyRiskTerms(quote, tt.limitPrice, tt.allocation)
+			if price != tt.wantPrice || notional != tt.wantNotional {
+				t.Errorf("buyRiskTerms() = (%.4f, %.4f), want (%.4f, %.4f)", price, notional, tt.wantPrice, tt.wantNotional)
+			}
+		})
+	}
+}
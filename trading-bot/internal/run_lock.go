@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+)
+
+// runLockName is the name of the lock that serializes trading bot executions
+const runLockName = "TRADING_BOT_RUN"
+
+// MinRunLockTTLSeconds is the shortest run lock lease accepted. The lease is
+// renewed every third of it, which must leave time for a renewal round trip.
+const MinRunLockTTLSeconds = 30
+
+// validateRunLockTTL checks the configured run lock lease
+func validateRunLockTTL(seconds int) error {
+	if seconds < MinRunLockTTLSeconds {
+		return fmt.Errorf("run lock TTL must be at least %d seconds, got %d", MinRunLockTTLSeconds, seconds)
+	}
+	return nil
+}
+
+// errRunSkipped is returned by acquireRunLock when another execution holds the lock
+var errRunSkipped = errors.New("another trading bot run is in progress")
+
+// acquireRunLock takes the run lock and keeps renewing it in the background.
+// The returned context is cancelled if the lease is lost, and the returned
+// function stops the renewal and releases the lock.
+func (tb *TradingBot) acquireRunLock(ctx context.Context) (context.Context, func(), error) {
+	owner := uuid.New().String()
+	ttl := time.Duration(tb.config.RunLockTTLSeconds) * time.Second
+
+	err := tb.dbService.AcquireLock(ctx, runLockName, owner, ttl)
+	if errors.Is(err, store.ErrLockHeld) {
+		return nil, nil, errRunSkipped
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to acquire run lock: %w", err)
+	}
+
+	log.Printf("Acquired run lock as %s for %s", owner, ttl)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	// Renew the lease well before it expires so long runs keep the lock
+	go func() {
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				err := tb.dbService.RenewLock(runCtx, runLockName, owner, ttl)
+				if errors.Is(err, store.ErrLockHeld) {
+					log.Printf("Run lock was lost, stopping this run")
+					cancel()
+					return
+				}
+				if err != nil {
+					log.Printf("Warning: Failed to renew run lock: %v", err)
+				}
+			}
+		}
+	}()
+
+	release := func() {
+		close(done)
+		cancel()
+
+		// Use a fresh context so the lock is released even if the run context expired
+		releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer releaseCancel()
+
+		err := tb.dbService.ReleaseLock(releaseCtx, runLockName, owner)
+		if err != nil {
+			log.Printf("Warning: Failed to release run lock, it will expire in %s: %v", ttl, err)
+			return
+		}
+		log.Println("Released run lock")
+	}
+
+	return runCtx, release, nil
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vignesh-goutham/artemis/pkg/store"
+)
+
+// lostLockStore refuses every renewal, as if another run took the lock over
+type lostLockStore struct {
+	*store.MemoryStore
+}
+
+func (s lostLockStore) RenewLock(ctx context.Context, name, owner string, ttl time.Duration) error {
+	return store.ErrLockHeld
+}
+
+func TestNewTradingBotRunLockTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds int
+		wantErr bool
+	}{
+		{name: "unset", seconds: 0, wantErr: true},
+		{name: "below the minimum", seconds: MinRunLockTTLSeconds - 1, wantErr: true},
+		{name: "minimum", seconds: MinRunLockTTLSeconds},
+		{name: "default", seconds: 120},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{RunLockTTLSeconds: tt.seconds}
+
+			_, err := NewTradingBotWithBroker(config, store.NewMemoryStore(), NewSimulatedBroker(1000))
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewTradingBotWithBroker() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAcquireRunLockContention(t *testing.T) {
+	ctx := context.Background()
+	dbService := store.NewMemoryStore()
+	first := testBot(dbService, nil)
+	first.config = &Config{RunLockTTLSeconds: MinRunLockTTLSeconds}
+	second := testBot(dbService, nil)
+	second.config = &Config{RunLockTTLSeconds: MinRunLockTTLSeconds}
+
+	_, release, err := first.acquireRunLock(ctx)
+	if err != nil {
+		t.Fatalf("first acquireRunLock() error = %v", err)
+	}
+
+	if _, _, err := second.acquireRunLock(ctx); !errors.Is(err, errRunSkipped) {
+		t.Fatalf("second acquireRunLock() error = %v, want the run skipped", err)
+	}
+
+	release()
+
+	_, releaseSecond, err := second.acquireRunLock(ctx)
+	if err != nil {
+		t.Fatalf("acquireRunLock() after release error = %v", err)
+	}
+	releaseSecond()
+}
+
+func TestRunLockRenewal(t *testing.T) {
+	ctx := context.Background()
+	dbService := store.NewMemoryStore()
+
+	// A one second lease is renewed every third of a second
+	tb := testBot(dbService, nil)
+	tb.config = &Config{RunLockTTLSeconds: 1}
+
+	runCtx, release, err := tb.acquireRunLock(ctx)
+	if err != nil {
+		t.Fatalf("acquireRunLock() error = %v", err)
+	}
+	defer release()
+
+	time.Sleep(1500 * time.Millisecond)
+
+	if err := dbService.AcquireLock(ctx, runLockName, "other-run", time.Second); !errors.Is(err, store.ErrLockHeld) {
+		t.Errorf("AcquireLock() by another run after the first lease = %v, want the lock still held", err)
+	}
+	if runCtx.Err() != nil {
+		t.Errorf("run context ended while the lock was renewed: %v", runCtx.Err())
+	}
+}
+
+func TestRunLockLost(t *testing.T) {
+	tb := testBot(lostLockStore{store.NewMemoryStore()}, nil)
+	tb.config = &Config{RunLockTTLSeconds: 1}
+
+	runCtx, release, err := tb.acquireRunLock(context.Background())
+	if err != nil {
+		t.Fatalf("acquireRunLock() error = %v", err)
+	}
+	defer release()
+
+	select {
+	case <-runCtx.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("run context was not cancelled after the lock was lost")
+	}
+}
@@ -0,0 +1,571 @@
+package internal
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	alpaca "github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+	"github.com/alpacahq/alpaca-trade-api-go/v2/marketdata"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// SimulatedBar represents a single daily bar in the local price feed
+type SimulatedBar struct {
+	Date  time.Time
+	Open  float64
+	High  float64
+	Low   float64
+	Close float64
+}
+
+// SimulatedQuote represents a bid/ask quote in the local price feed
+type SimulatedQuote struct {
+	BidPrice float64
+	AskPrice float64
+}
+
+// SimulatedBroker is an in-process Broker that fills orders from a local
+// bar/quote feed and keeps cash and positions in memory
+type SimulatedBroker struct {
+	mu sync.Mutex
+
+	cash      float64
+	positions map[string]float64
+	orders    map[string]*alpaca.Order
+
+	// clientOrderIDs maps client order IDs to broker order IDs
+	clientOrderIDs map[string]string
+
+	bars            map[string][]SimulatedBar
+	quotes          map[string]SimulatedQuote
+	nonFractionable map[string]bool
+
+	// previousCloseEquity is reported as the equity at the previous close
+	previousCloseEquity float64
+
+	marketOpen bool
+	now        func() time.Time
+}
+
+// NewSimulatedBroker creates a simulated broker with the given starting cash
+func NewSimulatedBroker(startingCash float64) *SimulatedBroker {
+	return &SimulatedBroker{
+		cash:                startingCash,
+		previousCloseEquity: startingCash,
+		positions:           make(map[string]float64),
+		orders:              make(map[string]*alpaca.Order),
+		clientOrderIDs:      make(map[string]string),
+		bars:                make(map[string][]SimulatedBar),
+		quotes:              make(map[string]SimulatedQuote),
+		nonFractionable:     make(map[string]bool),
+		marketOpen:          true,
+		now:                 time.Now,
+	}
+}
+
+// SetQuote sets the latest quote for a ticker, overriding any bar data
+func (s *SimulatedBroker) SetQuote(ticker string, bid, ask float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.quotes[strings.ToUpper(ticker)] = SimulatedQuote{BidPrice: bid, AskPrice: ask}
+}
+
+// AddBars adds daily bars for a ticker to the price feed
+func (s *SimulatedBroker) AddBars(ticker string, bars []SimulatedBar) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ticker = strings.ToUpper(ticker)
+	s.bars[ticker] = append(s.bars[ticker], bars...)
+	sort.Slice(s.bars[ticker], func(i, j int) bool {
+		return s.bars[ticker][i].Date.Before(s.bars[ticker][j].Date)
+	})
+}
+
+// LoadBarsFromCSV loads daily bars from a CSV file with the header
+// ticker,date,open,high,low,close
+func (s *SimulatedBroker) LoadBarsFromCSV(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open price feed %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+
+	// Skip header
+	if _, err := reader.Read(); err != nil {
+		return fmt.Errorf("failed to read price feed header: %w", err)
+	}
+
+	barsByTicker := make(map[string][]SimulatedBar)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read price feed: %w", err)
+		}
+		if len(record) < 6 {
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", record[1])
+		if err != nil {
+			log.Printf("Warning: Skipping bar with invalid date %s: %v", record[1], err)
+			continue
+		}
+
+		var prices [4]float64
+		valid := true
+		for i := range prices {
+			prices[i], err = strconv.ParseFloat(record[2+i], 64)
+			if err != nil {
+				valid = false
+				break
+			}
+		}
+		if !valid {
+			log.Printf("Warning: Skipping bar with invalid prices for %s on %s", record[0], record[1])
+			continue
+		}
+
+		barsByTicker[record[0]] = append(barsByTicker[record[0]], SimulatedBar{
+			Date:  date,
+			Open:  prices[0],
+			High:  prices[1],
+			Low:   prices[2],
+			Close: prices[3],
+		})
+	}
+
+	for ticker, bars := range barsByTicker {
+		s.AddBars(ticker, bars)
+	}
+
+	return nil
+}
+
+// SetNonFractionable marks a ticker as not supporting fractional shares
+func (s *SimulatedBroker) SetNonFractionable(ticker string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nonFractionable[strings.ToUpper(ticker)] = true
+}
+
+// SetMarketOpen sets whether the simulated market is open
+func (s *SimulatedBroker) SetMarketOpen(open bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.marketOpen = open
+}
+
+// SetClock overrides the simulated clock used to pick bars from the feed
+func (s *SimulatedBroker) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.now = now
+}
+
+// quote returns the latest quote for a ticker. Caller must hold the lock.
+func (s *SimulatedBroker) quote(ticker string) (SimulatedQuote, error) {
+	ticker = strings.ToUpper(ticker)
+
+	if quote, ok := s.quotes[ticker]; ok {
+		return quote, nil
+	}
+
+	// Fall back to the close of the latest bar on or before the simulated date
+	now := s.now()
+	var latest *SimulatedBar
+	for i := range s.bars[ticker] {
+		if s.bars[ticker][i].Date.After(now) {
+			break
+		}
+		latest = &s.bars[ticker][i]
+	}
+	if latest == nil {
+		return SimulatedQuote{}, fmt.Errorf("no price data for %s", ticker)
+	}
+
+	return SimulatedQuote{BidPrice: latest.Close, AskPrice: latest.Close}, nil
+}
+
+// GetAccountValue returns cash plus the market value of all positions
+func (s *SimulatedBroker) GetAccountValue(ctx context.Context) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value := s.cash
+	for ticker, qty := range s.positions {
+		quote, err := s.quote(ticker)
+		if err != nil {
+			return 0, fmt.Errorf("failed to value position in %s: %w", ticker, err)
+		}
+		value += qty * quote.BidPrice
+	}
+
+	return value, nil
+}
+
+// GetCashBalance returns the simulated cash balance
+func (s *SimulatedBroker) GetCashBalance(ctx context.Context) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cash, nil
+}
+
+// SetPreviousCloseEquity sets the equity reported for the previous close, the
+// starting cash until it is set
+func (s *SimulatedBroker) SetPreviousCloseEquity(equity float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.previousCloseEquity = equity
+}
+
+// GetPreviousCloseEquity returns the simulated equity at the previous close
+func (s *SimulatedBroker) GetPreviousCloseEquity(ctx context.Context) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.previousCloseEquity, nil
+}
+
+// GetCurrentPrice returns the simulated ask price for a ticker
+func (s *SimulatedBroker) GetCurrentPrice(ctx context.Context, ticker string) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quote, err := s.quote(ticker)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest quote for %s: %w", ticker, err)
+	}
+	return quote.AskPrice, nil
+}
+
+// GetBidPrice returns the simulated bid price for a ticker
+func (s *SimulatedBroker) GetBidPrice(ctx context.Context, ticker string) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quote, err := s.quote(ticker)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest quote for %s: %w", ticker, err)
+	}
+	return quote.BidPrice, nil
+}
+
+// GetQuote returns the simulated quote for a ticker. The feed has no quote
+// times, so every quote is as fresh as the call on the simulated clock.
+func (s *SimulatedBroker) GetQuote(ctx context.Context, ticker string) (Quote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quote, err := s.quote(ticker)
+	if err != nil {
+		return Quote{}, fmt.Errorf("failed to get latest quote for %s: %w", ticker, err)
+	}
+	return Quote{BidPrice: quote.BidPrice, AskPrice: quote.AskPrice, Timestamp: s.now()}, nil
+}
+
+// GetDailyBars returns the loaded bars between start and end
+func (s *SimulatedBroker) GetDailyBars(ctx context.Context, ticker string, start, end time.Time) ([]marketdata.Bar, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var bars []marketdata.Bar
+	for _, bar := range s.bars[strings.ToUpper(ticker)] {
+		if bar.Date.Before(start) || bar.Date.After(end) || bar.Date.After(s.now()) {
+			continue
+		}
+		bars = append(bars, marketdata.Bar{
+			Timestamp: bar.Date,
+			Open:      bar.Open,
+			High:      bar.High,
+			Low:       bar.Low,
+			Close:     bar.Close,
+		})
+	}
+	return bars, nil
+}
+
+// IsFractionable reports whether a ticker supports fractional shares
+func (s *SimulatedBroker) IsFractionable(ctx context.Context, ticker string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !s.nonFractionable[strings.ToUpper(ticker)], nil
+}
+
+// BuyStock fills a buy order at the simulated ask price. A market order is a
+// notional order for fractionable tickers and in whole shares otherwise, a
+// limit order buys the shares the allocation buys at the limit.
+func (s *SimulatedBroker) BuyStock(ctx context.Context, ticker string, allocation, limitPrice float64, clientOrderID string) (*alpaca.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkClientOrderID(clientOrderID); err != nil {
+		return nil, err
+	}
+
+	quote, err := s.quote(ticker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current price for %s: %w", ticker, err)
+	}
+	if quote.AskPrice <= 0 {
+		return nil, fmt.Errorf("invalid ask price %.2f for %s", quote.AskPrice, ticker)
+	}
+
+	var terms orderTerms
+	shares := math.Floor(allocation / quote.AskPrice)
+	switch {
+	case limitPrice > 0:
+		// The order rests instead of filling once the ask has moved past the limit
+		if quote.AskPrice > limitPrice {
+			return nil, fmt.Errorf("ask %.2f for %s is above the limit price %.2f", quote.AskPrice, ticker, limitPrice)
+		}
+		terms.limitPrice = limitPrice
+		shares = allocation / limitPrice
+		if s.nonFractionable[strings.ToUpper(ticker)] {
+			shares = math.Floor(shares)
+		}
+	case !s.nonFractionable[strings.ToUpper(ticker)]:
+		terms.notional = notionalAmount(allocation)
+		shares = terms.notional / quote.AskPrice
+	}
+	if shares <= 0 {
+		return nil, fmt.Errorf("allocation amount %.2f results in 0 shares for %s at price %.2f", allocation, ticker, quote.AskPrice)
+	}
+
+	cost := shares * quote.AskPrice
+	if cost > s.cash {
+		return nil, fmt.Errorf("insufficient buying power: need %.2f, have %.2f", cost, s.cash)
+	}
+
+	s.cash -= cost
+	s.positions[strings.ToUpper(ticker)] += shares
+
+	order := s.fillOrder(ticker, alpaca.Buy, shares, quote.AskPrice, terms, clientOrderID)
+	log.Printf("Simulated buy order for %s: %f shares at $%.2f", ticker, shares, quote.AskPrice)
+	return order, nil
+}
+
+// SellStock fills a sell order at the simulated bid price, a limit order only
+// while the bid is at or above the limit
+func (s *SimulatedBroker) SellStock(ctx context.Context, ticker string, quantity, limitPrice float64, clientOrderID string) (*alpaca.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkClientOrderID(clientOrderID); err != nil {
+		return nil, err
+	}
+
+	ticker = strings.ToUpper(ticker)
+	if s.positions[ticker] < quantity {
+		return nil, fmt.Errorf("insufficient shares to sell: have %.2f, trying to sell %.2f", s.positions[ticker], quantity)
+	}
+
+	quote, err := s.quote(ticker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bid price for %s: %w", ticker, err)
+	}
+	if limitPrice > 0 && quote.BidPrice < limitPrice {
+		return nil, fmt.Errorf("bid %.2f for %s is below the limit price %.2f", quote.BidPrice, ticker, limitPrice)
+	}
+
+	s.cash += quantity * quote.BidPrice
+	s.positions[ticker] -= quantity
+	if s.positions[ticker] <= 0 {
+		delete(s.positions, ticker)
+	}
+
+	order := s.fillOrder(ticker, alpaca.Sell, quantity, quote.BidPrice, orderTerms{limitPrice: limitPrice}, clientOrderID)
+	log.Printf("Simulated sell order for %s: %f shares at $%.2f", ticker, quantity, quote.BidPrice)
+	return order, nil
+}
+
+// checkClientOrderID rejects client order IDs that were already used, as Alpaca does.
+// Caller must hold the lock.
+func (s *SimulatedBroker) checkClientOrderID(clientOrderID string) error {
+	if clientOrderID == "" {
+		return nil
+	}
+	if _, exists := s.clientOrderIDs[clientOrderID]; exists {
+		return fmt.Errorf("client_order_id must be unique: %s", clientOrderID)
+	}
+	return nil
+}
+
+// orderTerms are the optional terms of a simulated order, 0 when not used
+type orderTerms struct {
+	notional   float64 // Dollar amount of a notional order
+	limitPrice float64 // Limit of a limit order
+}
+
+// fillOrder records a fully filled order. Caller must hold the lock.
+func (s *SimulatedBroker) fillOrder(ticker string, side alpaca.Side, shares, price float64, terms orderTerms, clientOrderID string) *alpaca.Order {
+	now := s.now()
+	qty := decimal.NewFromFloat(shares)
+	avgPrice := decimal.NewFromFloat(price)
+
+	order := &alpaca.Order{
+		ID:             uuid.New().String(),
+		ClientOrderID:  clientOrderID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		SubmittedAt:    now,
+		FilledAt:       &now,
+		Symbol:         ticker,
+		Qty:            &qty,
+		FilledQty:      qty,
+		FilledAvgPrice: &avgPrice,
+		Type:           alpaca.Market,
+		Side:           side,
+		TimeInForce:    alpaca.Day,
+		Status:         "filled",
+	}
+	if terms.notional > 0 {
+		// Alpaca reports the dollar amount instead of a quantity for notional orders
+		amount := decimal.NewFromFloat(terms.notional)
+		order.Qty = nil
+		order.Notional = &amount
+	}
+	if terms.limitPrice > 0 {
+		limit := decimal.NewFromFloat(terms.limitPrice)
+		order.Type = alpaca.Limit
+		order.LimitPrice = &limit
+	}
+	s.orders[order.ID] = order
+	if clientOrderID != "" {
+		s.clientOrderIDs[clientOrderID] = order.ID
+	}
+
+	// Return a copy so callers cannot mutate the broker's order book
+	orderCopy := *order
+	return &orderCopy
+}
+
+// GetOrderStatus returns a previously placed simulated order
+func (s *SimulatedBroker) GetOrderStatus(ctx context.Context, orderID string) (*alpaca.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get order status: order %s not found", orderID)
+	}
+
+	orderCopy := *order
+	return &orderCopy, nil
+}
+
+// FindOrderByClientOrderID returns the simulated order with the given client order ID, or nil
+func (s *SimulatedBroker) FindOrderByClientOrderID(ctx context.Context, clientOrderID string) (*alpaca.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orderID, ok := s.clientOrderIDs[clientOrderID]
+	if !ok {
+		return nil, nil
+	}
+
+	orderCopy := *s.orders[orderID]
+	return &orderCopy, nil
+}
+
+// GetPosition returns the simulated position for a ticker
+func (s *SimulatedBroker) GetPosition(ctx context.Context, ticker string) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	qty, ok := s.positions[strings.ToUpper(ticker)]
+	if !ok {
+		return 0, fmt.Errorf("failed to get position for %s: position does not exist", ticker)
+	}
+	return qty, nil
+}
+
+// GetPositionValues returns the simulated position values at the bid price
+func (s *SimulatedBroker) GetPositionValues(ctx context.Context) (map[string]float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values := make(map[string]float64, len(s.positions))
+	for ticker, qty := range s.positions {
+		quote, err := s.quote(ticker)
+		if err != nil {
+			return nil, fmt.Errorf("failed to value position in %s: %w", ticker, err)
+		}
+		values[ticker] = qty * quote.BidPrice
+	}
+
+	return values, nil
+}
+
+// IsMarketOpen reports whether the simulated market is open
+func (s *SimulatedBroker) IsMarketOpen(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.marketOpen, nil
+}
+
+// GetNextMarketOpen returns the next weekday at 9:30 America/New_York
+func (s *SimulatedBroker) GetNextMarketOpen(ctx context.Context) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load market timezone: %w", err)
+	}
+
+	now := s.now().In(loc)
+	next := time.Date(now.Year(), now.Month(), now.Day(), 9, 30, 0, 0, loc)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	for next.Weekday() == time.Saturday || next.Weekday() == time.Sunday {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next, nil
+}
+
+// GetCalendar returns every weekday between start and end as a regular
+// 9:30 to 16:00 session; the simulated market has no holidays
+func (s *SimulatedBroker) GetCalendar(ctx context.Context, start, end time.Time) ([]alpaca.CalendarDay, error) {
+	var days []alpaca.CalendarDay
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		days = append(days, alpaca.CalendarDay{
+			Date:  day.Format("2006-01-02"),
+			Open:  "09:30",
+			Close: "16:00",
+		})
+	}
+	return days, nil
+}
+
+// Ensure SimulatedBroker satisfies the Broker interface
+var _ Broker = (*SimulatedBroker)(nil)
@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	alpaca "github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+)
+
+func TestSimulatedBrokerBuyStock(t *testing.T) {
+	tests := []struct {
+		name            string
+		nonFractionable bool
+		allocation      float64
+		limitPrice      float64
+		wantErr         bool
+		wantShares      float64
+		wantType        alpaca.OrderType
+		wantNotional    float64 // 0 when the order is for a quantity
+	}{
+		{name: "notional market order", allocation: 100.009, wantShares: 2, wantType: alpaca.Market, wantNotional: 100},
+		{name: "whole shares when not fractionable", nonFractionable: true, allocation: 120, wantShares: 2, wantType: alpaca.Market},
+		{name: "limit order buys at the ask", allocation: 101, limitPrice: 50.5, wantShares: 2, wantType: alpaca.Limit},
+		{name: "limit order in whole shares", nonFractionable: true, allocation: 150, limitPrice: 50.5, wantShares: 2, wantType: alpaca.Limit},
+		{name: "ask above the limit", allocation: 100, limitPrice: 49.5, wantErr: true},
+		{name: "allocation below one share", nonFractionable: true, allocation: 40, wantErr: true},
+		{name: "insufficient cash", allocation: 2000, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			broker := NewSimulatedBroker(1000)
+			broker.SetQuote("aapl", 49.9, 50)
+			if tt.nonFractionable {
+				broker.SetNonFractionable("AAPL")
+			}
+
+			order, err := broker.BuyStock(ctx, "AAPL", tt.allocation, tt.limitPrice, "buy-1")
+
+			cash, _ := broker.GetCashBalance(ctx)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("BuyStock() = %+v, want an error", order)
+				}
+				if cash != 1000 {
+					t.Errorf("cash = %.2f after a failed buy, want 1000", cash)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuyStock() error = %v", err)
+			}
+
+			position, _ := broker.GetPosition(ctx, "AAPL")
+			if math.Abs(position-tt.wantShares) > 1e-9 || math.Abs(cash-(1000-tt.wantShares*50)) > 1e-9 {
+				t.Errorf("position = %f shares and cash = %.2f, want %f shares bought at the ask", position, cash, tt.wantShares)
+			}
+			if order.Type != tt.wantType || order.Status != "filled" || order.ClientOrderID != "buy-1" {
+				t.Errorf("order is %s %s with client order ID %q, want a filled %s order", order.Status, order.Type, order.ClientOrderID, tt.wantType)
+			}
+			if filled, _ := order.FilledQty.Float64(); math.Abs(filled-tt.wantShares) > 1e-9 {
+				t.Errorf("filled quantity = %f, want %f", filled, tt.wantShares)
+			}
+			if tt.wantNotional > 0 {
+				if order.Notional == nil || order.Qty != nil || order.Notional.InexactFloat64() != tt.wantNotional {
+					t.Errorf("order notional = %v with quantity %v, want notional %.2f without a quantity", order.Notional, order.Qty, tt.wantNotional)
+				}
+			} else if order.Notional != nil || order.Qty == nil {
+				t.Errorf("order notional = %v with quantity %v, want a quantity order", order.Notional, order.Qty)
+			}
+		})
+	}
+}
+
+func TestSimulatedBrokerSellStock(t *testing.T) {
+	tests := []struct {
+		name         string
+		quantity     float64
+		limitPrice   float64
+		wantErr      bool
+		wantPosition float64 // 0 when the position is closed
+	}{
+		{name: "partial sell", quantity: 1.5, wantPosition: 0.5},
+		{name: "whole position", quantity: 2},
+		{name: "limit at the bid", quantity: 2, limitPrice: 49.9},
+		{name: "bid below the limit", quantity: 2, limitPrice: 50, wantErr: true, wantPosition: 2},
+		{name: "more than held", quantity: 3, wantErr: true, wantPosition: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			broker := NewSimulatedBroker(1000)
+			broker.SetQuote("AAPL", 49.9, 50)
+			if _, err := broker.BuyStock(ctx, "AAPL", 100, 0, "buy-1"); err != nil {
+				t.Fatalf("BuyStock() error = %v", err)
+			}
+
+			order, err := broker.SellStock(ctx, "AAPL", tt.quantity, tt.limitPrice, "sell-1")
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("SellStock() = %+v, want an error", order)
+				}
+			} else if err != nil {
+				t.Fatalf("SellStock() error = %v", err)
+			}
+
+			position, positionErr := broker.GetPosition(ctx, "AAPL")
+			if tt.wantPosition == 0 {
+				if positionErr == nil {
+					t.Errorf("position = %f shares, want it closed", position)
+				}
+			} else if math.Abs(position-tt.wantPosition) > 1e-9 {
+				t.Errorf("position = %f shares, want %f", position, tt.wantPosition)
+			}
+
+			cash, _ := broker.GetCashBalance(ctx)
+			wantCash := 900.0
+			if !tt.wantErr {
+				wantCash += tt.quantity * 49.9
+			}
+			if math.Abs(cash-wantCash) > 1e-9 {
+				t.Errorf("cash = %.2f, want %.2f", cash, wantCash)
+			}
+		})
+	}
+}
+
+func TestSimulatedBrokerClientOrderID(t *testing.T) {
+	ctx := context.Background()
+	broker := NewSimulatedBroker(1000)
+	broker.SetQuote("AAPL", 49.9, 50)
+
+	order, err := broker.BuyStock(ctx, "AAPL", 100, 0, "signal-buy")
+	if err != nil {
+		t.Fatalf("BuyStock() error = %v", err)
+	}
+	if _, err := broker.BuyStock(ctx, "AAPL", 100, 0, "signal-buy"); err == nil {
+		t.Errorf("BuyStock() with a used client order ID succeeded, want an error")
+	}
+
+	found, err := broker.FindOrderByClientOrderID(ctx, "signal-buy")
+	if err != nil || found == nil || found.ID != order.ID {
+		t.Errorf("FindOrderByClientOrderID() = %v, %v, want order %s", found, err, order.ID)
+	}
+	if missing, err := broker.FindOrderByClientOrderID(ctx, "unknown"); err != nil || missing != nil {
+		t.Errorf("FindOrderByClientOrderID(unknown) = %v, %v, want no order", missing, err)
+	}
+	if cash, _ := broker.GetCashBalance(ctx); cash != 900 {
+		t.Errorf("cash = %.2f, want only the first buy paid", cash)
+	}
+}
+
+func TestSimulatedBrokerQuoteFromBars(t *testing.T) {
+	ctx := context.Background()
+	broker := NewSimulatedBroker(1000)
+	broker.AddBars("MSFT", []SimulatedBar{
+		{Date: date(t, "2026-11-24"), Open: 410, High: 415, Low: 405, Close: 412},
+		{Date: date(t, "2026-11-23"), Open: 400, High: 408, Low: 398, Close: 405},
+	})
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		want    float64
+		wantErr bool
+	}{
+		{name: "before the feed", now: date(t, "2026-11-20"), wantErr: true},
+		{name: "first day", now: date(t, "2026-11-23").Add(15 * time.Hour), want: 405},
+		{name: "latest bar", now: date(t, "2026-11-30"), want: 412},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker.SetClock(func() time.Time { return tt.now })
+
+			quote, err := broker.GetQuote(ctx, "msft")
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("GetQuote() = %+v, want an error", quote)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetQuote() error = %v", err)
+			}
+			if quote.BidPrice != tt.want || quote.AskPrice != tt.want {
+				t.Errorf("GetQuote() = %+v, want bid and ask at the close of %.2f", quote, tt.want)
+			}
+			if !quote.Timestamp.Equal(tt.now) {
+				t.Errorf("quote time = %s, want the simulated time %s", quote.Timestamp, tt.now)
+			}
+		})
+	}
+}
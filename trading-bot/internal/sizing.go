@@ -0,0 +1,318 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v2/marketdata"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// SizingInput is what a sizing strategy can base a signal's allocation on
+type SizingInput struct {
+	Signal         *types.Signal
+	BaseAllocation float64        // Equal split of the allocation window
+	Equity         float64        // Current account equity, 0 if it could not be read
+	Signals        []types.Signal // Active signals of the run, for relative weighting
+}
+
+// SizingStrategy decides how many dollars a signal's buy is allocated
+type SizingStrategy interface {
+	Name() string
+	// Size returns the allocation and the inputs it was computed from. An error
+	// means the strategy has nothing to go on, the fixed count size is used instead.
+	Size(ctx context.Context, input SizingInput) (float64, map[string]float64, error)
+}
+
+// newSizingStrategy creates the sizing strategy selected by the configuration
+func newSizingStrategy(config *Config, dbService store.SignalStore, broker Broker) (SizingStrategy, error) {
+	switch config.SizingStrategy {
+	case "", SizingStrategyFixedCount:
+		return fixedCountSizing{}, nil
+	case SizingStrategyEquityPercent:
+		return equityPercentSizing{percent: config.SizingEquityPercent}, nil
+	case SizingStrategyVolatility:
+		return volatilitySizing{
+			broker:        broker,
+			riskPercent:   config.SizingRiskPercent,
+			atrPeriod:     config.SizingATRPeriod,
+			atrMultiplier: config.SizingATRMultiplier,
+		}, nil
+	case SizingStrategyKelly:
+		return kellySizing{
+			dbService:    dbService,
+			fraction:     config.SizingKellyFraction,
+			lookbackDays: config.SizingKellyLookbackDays,
+			minTrades:    config.SizingKellyMinTrades,
+		}, nil
+	case SizingStrategyConviction:
+		return convictionSizing{}, nil
+	default:
+		return nil, fmt.Errorf("unknown sizing strategy: %s", config.SizingStrategy)
+	}
+}
+
+// errNoEquity is returned by strategies sized from equity when it could not be read
+var errNoEquity = errors.New("account equity is not available")
+
+// fixedCountSizing splits the window's account value equally across its signals
+type fixedCountSizing struct{}
+
+// Name implements SizingStrategy
+func (fixedCountSizing) Name() string { return SizingStrategyFixedCount }
+
+// Size implements SizingStrategy
+func (fixedCountSizing) Size(ctx context.Context, input SizingInput) (float64, map[string]float64, error) {
+	return input.BaseAllocation, map[string]float64{"base_allocation": input.BaseAllocation}, nil
+}
+
+// equityPercentSizing allocates a fixed percentage of current equity to each signal
+type equityPercentSizing struct {
+	percent float64
+}
+
+// Name implements SizingStrategy
+func (equityPercentSizing) Name() string { return SizingStrategyEquityPercent }
+
+// Size implements SizingStrategy
+func (s equityPercentSizing) Size(ctx context.Context, input SizingInput) (float64, map[string]float64, error) {
+	if input.Equity <= 0 {
+		return 0, nil, errNoEquity
+	}
+
+	allocation := input.Equity * s.percent / 100
+	return allocation, map[string]float64{
+		"equity":         input.Equity,
+		"equity_percent": s.percent,
+	}, nil
+}
+
+// volatilitySizing risks the same share of equity on every signal. The stop
+// distance is a multiple of the ticker's average true range, so volatile
+// tickers get smaller positions.
+type volatilitySizing struct {
+	broker        Broker
+	riskPercent   float64
+	atrPeriod     int
+	atrMultiplier float64
+}
+
+// Name implements SizingStrategy
+func (volatilitySizing) Name() string { return SizingStrategyVolatility }
+
+// Size implements SizingStrategy
+func (s volatilitySizing) Size(ctx context.Context, input SizingInput) (float64, map[string]float64, error) {
+	if input.Equity <= 0 {
+		return 0, nil, errNoEquity
+	}
+
+	// Enough calendar days to cover the period plus weekends and holidays
+	end := time.Now()
+	start := end.AddDate(0, 0, -(s.atrPeriod*2 + 10))
+	bars, err := s.broker.GetDailyBars(ctx, input.Signal.Ticker, start, end)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	atr, err := averageTrueRange(bars, s.atrPeriod)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to compute ATR for %s: %w", input.Signal.Ticker, err)
+	}
+
+	price := bars[len(bars)-1].Close
+	stopDistance := atr * s.atrMultiplier
+	riskBudget := input.Equity * s.riskPercent / 100
+	allocation := riskBudget / stopDistance * price
+
+	return allocation, map[string]float64{
+		"equity":         input.Equity,
+		"risk_percent":   s.riskPercent,
+		"atr":            atr,
+		"atr_period":     float64(s.atrPeriod),
+		"atr_multiplier": s.atrMultiplier,
+		"price":          price,
+	}, nil
+}
+
+// averageTrueRange returns the simple average of the last period true ranges
+func averageTrueRange(bars []marketdata.Bar, period int) (float64, error) {
+	if period <= 0 {
+		return 0, fmt.Errorf("invalid ATR period %d", period)
+	}
+	if len(bars) < period+1 {
+		return 0, fmt.Errorf("need %d daily bars, got %d", period+1, len(bars))
+	}
+
+	var sum float64
+	for i := len(bars) - period; i < len(bars); i++ {
+		previousClose := bars[i-1].Close
+		trueRange := math.Max(bars[i].High-bars[i].Low,
+			math.Max(math.Abs(bars[i].High-previousClose), math.Abs(bars[i].Low-previousClose)))
+		sum += trueRange
+	}
+
+	atr := sum / float64(period)
+	if atr <= 0 {
+		return 0, fmt.Errorf("average true range is %.4f", atr)
+	}
+	return atr, nil
+}
+
+// kellySizing allocates a fraction of the Kelly bet computed from the win rate
+// and payoff of the trades in the trade history
+type kellySizing struct {
+	dbService    store.SignalStore
+	fraction     float64
+	lookbackDays int
+	minTrades    int
+}
+
+// Name implements SizingStrategy
+func (kellySizing) Name() string { return SizingStrategyKelly }
+
+// Size implements SizingStrategy. Without an edge in the history the Kelly
+// bet is 0 and nothing is bought.
+func (s kellySizing) Size(ctx context.Context, input SizingInput) (float64, map[string]float64, error) {
+	if input.Equity <= 0 {
+		return 0, nil, errNoEquity
+	}
+
+	now := time.Now()
+	trades, err := s.dbService.LoadTradeHistory(ctx, now.AddDate(0, 0, -s.lookbackDays), now)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to load trade history: %w", err)
+	}
+	if len(trades) == 0 || len(trades) < s.minTrades {
+		return 0, nil, fmt.Errorf("%d trades in the last %d days, need %d", len(trades), s.lookbackDays, s.minTrades)
+	}
+
+	var wins, losses int
+	var winPercent, lossPercent float64
+	for _, trade := range trades {
+		if trade.RealizedPnLPercent > 0 {
+			wins++
+			winPercent += trade.RealizedPnLPercent
+		} else if trade.RealizedPnLPercent < 0 {
+			losses++
+			lossPercent -= trade.RealizedPnLPercent
+		}
+	}
+
+	winRate := float64(wins) / float64(len(trades))
+	var avgWin, avgLoss float64
+	if wins > 0 {
+		avgWin = winPercent / float64(wins)
+	}
+	if losses > 0 {
+		avgLoss = lossPercent / float64(losses)
+	}
+
+	// f = p - (1 - p) / b with b the ratio of the average win to the average loss
+	kelly := winRate
+	if avgLoss > 0 {
+		if avgWin == 0 {
+			kelly = 0
+		} else {
+			kelly = winRate - (1-winRate)/(avgWin/avgLoss)
+		}
+	}
+	kelly = math.Max(0, math.Min(1, kelly))
+
+	allocation := input.Equity * s.fraction * kelly
+	return allocation, map[string]float64{
+		"equity":           input.Equity,
+		"trades":           float64(len(trades)),
+		"win_rate":         winRate,
+		"avg_win_percent":  avgWin,
+		"avg_loss_percent": avgLoss,
+		"kelly":            kelly,
+		"kelly_fraction":   s.fraction,
+	}, nil
+}
+
+// convictionSizing scales the equal split by the signal's conviction relative
+// to the average conviction of the active signals that are not waitlisted, so
+// the window's budget is shifted towards the strongest signals without growing
+type convictionSizing struct{}
+
+// Name implements SizingStrategy
+func (convictionSizing) Name() string { return SizingStrategyConviction }
+
+// Size implements SizingStrategy
+func (convictionSizing) Size(ctx context.Context, input SizingInput) (float64, map[string]float64, error) {
+	conviction := float64(input.Signal.EffectiveConviction())
+
+	total := conviction
+	count := 1
+	for _, signal := range input.Signals {
+		if signal.UUID == input.Signal.UUID || signal.Status == types.SignalStatusWaitlisted {
+			continue
+		}
+		total += float64(signal.EffectiveConviction())
+		count++
+	}
+	meanConviction := total / float64(count)
+
+	allocation := input.BaseAllocation * conviction / meanConviction
+	return allocation, map[string]float64{
+		"base_allocation": input.BaseAllocation,
+		"conviction":      conviction,
+		"mean_conviction": meanConviction,
+	}, nil
+}
+
+// sizeSignal sizes the signal's buy with the configured strategy, falling back
+// to the equal split when the strategy cannot size it, and returns the
+// allocation with the size and its inputs. No size exceeds the window's
+// remaining budget. The size is only recorded on the signal once the buy is
+// placed or sent for approval.
+func (tb *TradingBot) sizeSignal(ctx context.Context, signal *types.Signal, baseAllocation float64) (float64, *types.PositionSize) {
+	input := SizingInput{
+		Signal:         signal,
+		BaseAllocation: baseAllocation,
+		Signals:        tb.signals,
+	}
+	equity, err := tb.broker.GetAccountValue(ctx)
+	if err != nil {
+		log.Printf("Warning: Could not get account value for sizing: %v", err)
+	} else {
+		input.Equity = equity
+	}
+
+	size := &types.PositionSize{
+		Strategy: tb.sizing.Name(),
+		SizedAt:  time.Now(),
+	}
+
+	allocation, inputs, err := tb.sizing.Size(ctx, input)
+	if err != nil {
+		log.Printf("Warning: %s sizing is not available for signal %s, using the fixed count size: %v",
+			tb.sizing.Name(), signal.UUID, err)
+		size.Fallback = err.Error()
+		allocation, inputs, _ = fixedCountSizing{}.Size(ctx, input)
+	}
+
+	if window := tb.allocationWindow; window != nil {
+		remaining := math.Max(window.RemainingBudget, 0)
+		if allocation > remaining {
+			log.Printf("Signal %s was sized to $%.2f, capped at the window's remaining budget of $%.2f",
+				signal.UUID, allocation, remaining)
+			if inputs == nil {
+				inputs = make(map[string]float64)
+			}
+			inputs["uncapped_allocation"] = allocation
+			inputs["remaining_budget"] = remaining
+			allocation = math.Min(allocation, remaining)
+		}
+	}
+
+	size.Allocation = allocation
+	size.Inputs = inputs
+
+	return allocation, size
+}
@@ -0,0 +1,181 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v2/marketdata"
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// tradeHistoryStore serves a fixed trade history, the rest of the store is not used
+type tradeHistoryStore struct {
+	store.SignalStore
+	trades []types.TradeRecord
+	err    error
+}
+
+func (s tradeHistoryStore) LoadTradeHistory(ctx context.Context, from, to time.Time) ([]types.TradeRecord, error) {
+	return s.trades, s.err
+}
+
+// tradesWithReturns returns one trade per realized return percentage
+func tradesWithReturns(returns ...float64) []types.TradeRecord {
+	trades := make([]types.TradeRecord, len(returns))
+	for i, pnlPercent := range returns {
+		trades[i] = types.TradeRecord{SignalUUID: uuid.New(), RealizedPnLPercent: pnlPercent}
+	}
+	return trades
+}
+
+func TestAverageTrueRange(t *testing.T) {
+	bars := []marketdata.Bar{
+		{High: 11, Low: 9, Close: 10},
+		{High: 12, Low: 10, Close: 11},   // High-low range of 2
+		{High: 15, Low: 13, Close: 14},   // Gap up, 4 from the previous close
+		{High: 14.5, Low: 10, Close: 11}, // Gap down, 4.5 from high to low
+	}
+
+	tests := []struct {
+		name    string
+		bars    []marketdata.Bar
+		period  int
+		want    float64
+		wantErr bool
+	}{
+		{name: "single range", bars: bars[:2], period: 1, want: 2},
+		{name: "gaps use the previous close", bars: bars, period: 2, want: 4.25},
+		{name: "full period", bars: bars, period: 3, want: 3.5},
+		{name: "only the last bars count", bars: bars, period: 1, want: 4.5},
+		{name: "too few bars", bars: bars, period: 4, wantErr: true},
+		{name: "invalid period", bars: bars, period: 0, wantErr: true},
+		{
+			name:    "flat prices",
+			bars:    []marketdata.Bar{{High: 10, Low: 10, Close: 10}, {High: 10, Low: 10, Close: 10}},
+			period:  1,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := averageTrueRange(tt.bars, tt.period)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("averageTrueRange() = %.4f, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("averageTrueRange() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("averageTrueRange() = %.4f, want %.4f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKellySizing(t *testing.T) {
+	loadErr := errors.New("table not found")
+
+	tests := []struct {
+		name      string
+		trades    []types.TradeRecord
+		loadErr   error
+		minTrades int
+		equity    float64
+		want      float64
+		wantErr   bool
+	}{
+		{name: "edge from wins twice the losses", trades: tradesWithReturns(10, 10, -5, -5), equity: 10000, want: 1250},
+		{name: "only wins", trades: tradesWithReturns(4, 6), equity: 10000, want: 5000},
+		{name: "only losses", trades: tradesWithReturns(-4, -6), equity: 10000, want: 0},
+		{name: "no edge", trades: tradesWithReturns(5, -10), equity: 10000, want: 0},
+		{name: "flat trades count against the win rate", trades: tradesWithReturns(10, 0, 0, 0), equity: 10000, want: 1250},
+		{name: "too few trades", trades: tradesWithReturns(10, -5), minTrades: 3, equity: 10000, wantErr: true},
+		{name: "no trades", equity: 10000, wantErr: true},
+		{name: "history not available", loadErr: loadErr, equity: 10000, wantErr: true},
+		{name: "no equity", trades: tradesWithReturns(10, -5), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sizing := kellySizing{
+				dbService:    tradeHistoryStore{trades: tt.trades, err: tt.loadErr},
+				fraction:     0.5,
+				lookbackDays: 90,
+				minTrades:    tt.minTrades,
+			}
+
+			got, inputs, err := sizing.Size(context.Background(), SizingInput{
+				Signal: &types.Signal{UUID: uuid.New(), Ticker: "AAPL"},
+				Equity: tt.equity,
+			})
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Size() = %.2f, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Size() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("Size() = %.2f (inputs %v), want %.2f", got, inputs, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvictionSizing(t *testing.T) {
+	signal := func(conviction int, status types.SignalStatus) types.Signal {
+		return types.Signal{UUID: uuid.New(), Conviction: conviction, Status: status}
+	}
+
+	tests := []struct {
+		name       string
+		conviction int
+		others     []types.Signal
+		want       float64
+	}{
+		{name: "only signal", conviction: 5, want: 1000},
+		{name: "above the mean", conviction: 5, others: []types.Signal{signal(1, types.SignalStatusPending)}, want: 1666.67},
+		{name: "below the mean", conviction: 1, others: []types.Signal{signal(5, types.SignalStatusBought)}, want: 333.33},
+		{name: "unset conviction is the default", others: []types.Signal{signal(types.DefaultConviction, types.SignalStatusPending)}, want: 1000},
+		{
+			name:       "waitlisted signals are not counted",
+			conviction: 4,
+			others:     []types.Signal{signal(2, types.SignalStatusPending), signal(1, types.SignalStatusWaitlisted)},
+			want:       1333.33,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sized := signal(tt.conviction, types.SignalStatusPending)
+			// The signal being sized is part of the run's signals as well
+			signals := append([]types.Signal{sized}, tt.others...)
+
+			got, _, err := convictionSizing{}.Size(context.Background(), SizingInput{
+				Signal:         &sized,
+				BaseAllocation: 1000,
+				Signals:        signals,
+			})
+
+			if err != nil {
+				t.Fatalf("Size() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("Size() = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// pendingArchive is a completed signal whose archive write failed during the run
+type pendingArchive struct {
+	signal         types.Signal
+	previousStatus types.SignalStatus
+}
+
+// newTradeRecord builds the trade history record of a completed signal from
+// its accumulated fills
+func (tb *TradingBot) newTradeRecord(signal *types.Signal) types.TradeRecord {
+	now := time.Now()
+
+	sellPrice := 0.0
+	if signal.SoldQuantity > 0 {
+		sellPrice = signal.SellProceeds / signal.SoldQuantity
+	}
+	costBasis := signal.BuyPrice * signal.SoldQuantity
+
+	pnlPercent := 0.0
+	if costBasis > 0 {
+		pnlPercent = (signal.SellProceeds - costBasis) / costBasis * 100
+	}
+
+	// Signals bought before fill timestamps were recorded fall back to the planned buy date
+	boughtAt := signal.BoughtAt
+	if boughtAt.IsZero() {
+		boughtAt = signal.BuyDate
+	}
+	soldAt := signal.SoldAt
+	if soldAt.IsZero() {
+		soldAt = now
+	}
+
+	exitReason := signal.ExitReason
+	if exitReason == "" {
+		exitReason = types.ExitReasonSellDate
+	}
+
+	trade := types.TradeRecord{
+		SignalUUID:         signal.UUID,
+		Ticker:             signal.Ticker,
+		BuyDate:            signal.BuyDate,
+		SellDate:           signal.SellDate,
+		BoughtAt:           boughtAt,
+		SoldAt:             soldAt,
+		Quantity:           signal.SoldQuantity,
+		BuyPrice:           signal.BuyPrice,
+		SellPrice:          sellPrice,
+		CostBasis:          costBasis,
+		Proceeds:           signal.SellProceeds,
+		RealizedPnL:        signal.SellProceeds - costBasis,
+		RealizedPnLPercent: pnlPercent,
+		HoldingDays:        int(soldAt.Sub(boughtAt).Hours() / 24),
+		ExitReason:         exitReason,
+		BuyOrderID:         signal.BuyOrderID,
+		SellOrderID:        signal.SellOrderID,
+		CreatedAt:          now,
+	}
+
+	if tb.config.TradeHistoryRetentionDays > 0 {
+		expiresAt := soldAt.AddDate(0, 0, tb.config.TradeHistoryRetentionDays)
+		trade.ExpiresAt = &expiresAt
+	}
+
+	return trade
+}
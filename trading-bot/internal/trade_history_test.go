@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+func TestNewTradeRecord(t *testing.T) {
+	boughtAt := time.Date(2026, 11, 23, 15, 0, 0, 0, time.UTC)
+	soldAt := time.Date(2026, 12, 3, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		signal          types.Signal
+		retentionDays   int
+		wantPnL         float64
+		wantPnLPercent  float64
+		wantHoldingDays int
+		wantExitReason  types.ExitReason
+		wantExpiresAt   time.Time // Zero when the trade is kept forever
+	}{
+		{
+			name: "sold at a gain",
+			signal: types.Signal{BuyPrice: 10, BoughtAt: boughtAt, SoldAt: soldAt,
+				SoldQuantity: 100, SellProceeds: 1100},
+			wantPnL: 100, wantPnLPercent: 10, wantHoldingDays: 10, wantExitReason: types.ExitReasonSellDate,
+		},
+		{
+			name: "liquidated at a loss",
+			signal: types.Signal{BuyPrice: 10, BoughtAt: boughtAt, SoldAt: soldAt,
+				SoldQuantity: 50, SellProceeds: 450, ExitReason: types.ExitReasonLiquidation},
+			wantPnL: -50, wantPnLPercent: -10, wantHoldingDays: 10, wantExitReason: types.ExitReasonLiquidation,
+		},
+		{
+			name: "bought before fill times were recorded",
+			signal: types.Signal{BuyDate: time.Date(2026, 11, 20, 0, 0, 0, 0, time.UTC), BuyPrice: 10, SoldAt: soldAt,
+				SoldQuantity: 100, SellProceeds: 1000},
+			wantHoldingDays: 13, wantExitReason: types.ExitReasonSellDate,
+		},
+		{
+			name: "kept for the retention period",
+			signal: types.Signal{BuyPrice: 10, BoughtAt: boughtAt, SoldAt: soldAt,
+				SoldQuantity: 100, SellProceeds: 1000},
+			retentionDays:   30,
+			wantHoldingDays: 10, wantExitReason: types.ExitReasonSellDate,
+			wantExpiresAt: soldAt.AddDate(0, 0, 30),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &TradingBot{config: &Config{TradeHistoryRetentionDays: tt.retentionDays}}
+			signal := tt.signal
+			signal.UUID = uuid.New()
+
+			trade := tb.newTradeRecord(&signal)
+
+			if math.Abs(trade.RealizedPnL-tt.wantPnL) > 1e-9 || math.Abs(trade.RealizedPnLPercent-tt.wantPnLPercent) > 1e-9 {
+				t.Errorf("realized P&L = $%.2f (%.2f%%), want $%.2f (%.2f%%)",
+					trade.RealizedPnL, trade.RealizedPnLPercent, tt.wantPnL, tt.wantPnLPercent)
+			}
+			if trade.HoldingDays != tt.wantHoldingDays || trade.ExitReason != tt.wantExitReason {
+				t.Errorf("held %d days, exit %s, want %d days, exit %s",
+					trade.HoldingDays, trade.ExitReason, tt.wantHoldingDays, tt.wantExitReason)
+			}
+			if tt.wantExpiresAt.IsZero() != (trade.ExpiresAt == nil) ||
+				(trade.ExpiresAt != nil && !trade.ExpiresAt.Equal(tt.wantExpiresAt)) {
+				t.Errorf("expires at %v, want %v", trade.ExpiresAt, tt.wantExpiresAt)
+			}
+		})
+	}
+}
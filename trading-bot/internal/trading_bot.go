@@ -2,11 +2,14 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/vignesh-goutham/artemis/pkg/dynamodb"
+	"github.com/vignesh-goutham/artemis/pkg/store"
 	"github.com/vignesh-goutham/artemis/pkg/types"
 	"github.com/vignesh-goutham/artemis/trading-bot/pkg/notification"
 )
@@ -14,73 +17,206 @@ import (
 // TradingBot orchestrates the trading operations
 type TradingBot struct {
 	config              *Config
-	dbService           *dynamodb.Service
-	alpacaService       *AlpacaService
+	dbService           store.Store
+	broker              Broker
+	sizing              SizingStrategy
+	ranking             []string // Keys due signals are ranked by for funding
 	notificationService *notification.DiscordNotificationService
 	signals             []types.Signal
-	signalsToDelete     []types.Signal // Track signals that need to be deleted
+	unsavedSignals      map[uuid.UUID]types.SignalStatus // Signals whose write failed, with the status they are still stored under
+	pendingArchives     []pendingArchive                 // Completed signals whose archive write must be retried
+	approvalRequests    []approvalRequest
+	fundingRanks        map[uuid.UUID]int // Funding rank of each due signal this run
+	risk                *RiskEngine
+	resumedBuys         map[string]float64    // Unfilled notional of buy orders resumed from an unfinished run, by ticker
+	riskRejections      []string              // Buys blocked by a risk rule this run, for the run summary
+	circuitBreaker      *types.CircuitBreaker // nil when the breaker could not be evaluated, which blocks buys
+	tradingControl      *types.TradingControl
+	openIntents         map[string]bool
 	allocationWindow    *types.AllocationWindow
+	windowChanged       bool      // The window's accounting changed since it was last saved
+	windowStoredAt      time.Time // UpdatedAt of the window as it was last loaded or saved
+	calendar            *TradingCalendar
+	sessionState        SessionState
 	errorCount          int
 	processedCount      int
 }
 
 // NewTradingBot creates a new trading bot instance
 func NewTradingBot(config *Config) (*TradingBot, error) {
-	dbService, err := dynamodb.NewService(config.DynamoDBRegion, config.TableName)
+	dbService, err := newStore(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create DynamoDB service: %w", err)
+		return nil, err
 	}
 
-	alpacaService, err := NewAlpacaService(config)
+	broker, err := newBroker(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Alpaca service: %w", err)
+		return nil, err
+	}
+
+	return NewTradingBotWithBroker(config, dbService, broker)
+}
+
+// NewTradingBotWithBroker creates a new trading bot instance using the given broker
+func NewTradingBotWithBroker(config *Config, dbService store.Store, broker Broker) (*TradingBot, error) {
+	sizing, err := newSizingStrategy(config, dbService, broker)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWindowFullPolicy(config.WindowFullPolicy); err != nil {
+		return nil, err
+	}
+	if err := validateExecution(config); err != nil {
+		return nil, err
+	}
+	if err := validateRunLockTTL(config.RunLockTTLSeconds); err != nil {
+		return nil, err
+	}
+	ranking, err := parseSignalRanking(config.SignalRanking)
+	if err != nil {
+		return nil, err
 	}
 
 	notificationService := notification.NewDiscordNotificationService(config.DiscordWebhookURL)
+	notificationService.SetApprovalChannel(config.DiscordBotToken, config.DiscordApprovalChannelID)
 
 	return &TradingBot{
 		config:              config,
 		dbService:           dbService,
-		alpacaService:       alpacaService,
+		broker:              broker,
+		sizing:              sizing,
+		ranking:             ranking,
 		notificationService: notificationService,
 		signals:             []types.Signal{},
-		signalsToDelete:     []types.Signal{},
+		unsavedSignals:      make(map[uuid.UUID]types.SignalStatus),
+		pendingArchives:     []pendingArchive{},
+		openIntents:         make(map[string]bool),
 		allocationWindow:    nil,
 		errorCount:          0,
 		processedCount:      0,
 	}, nil
 }
 
+// newStore creates the storage backend selected by the configuration
+func newStore(config *Config) (store.Store, error) {
+	switch config.StoreBackend {
+	case "", store.BackendDynamoDB:
+		dbService, err := dynamodb.NewServiceWithOptions(dynamodb.Options{
+			Region:          config.DynamoDBRegion,
+			TableName:       config.TableName,
+			Endpoint:        config.DynamoDBEndpoint,
+			AccessKeyID:     config.DynamoDBAccessKeyID,
+			SecretAccessKey: config.DynamoDBSecretAccessKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create DynamoDB service: %w", err)
+		}
+		return dbService, nil
+	case store.BackendMemory:
+		log.Println("Using in-memory store, nothing will be persisted")
+		return store.NewMemoryStore(), nil
+	case store.BackendFile:
+		fileStore, err := store.NewFileStore(config.StoreFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file store: %w", err)
+		}
+		log.Printf("Using file store at %s", config.StoreFilePath)
+		return fileStore, nil
+	default:
+		return nil, fmt.Errorf("unknown store backend: %s", config.StoreBackend)
+	}
+}
+
+// newBroker creates the broker selected by the configuration
+func newBroker(config *Config) (Broker, error) {
+	switch config.BrokerMode {
+	case "", BrokerModeAlpaca:
+		alpacaService, err := NewAlpacaService(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Alpaca service: %w", err)
+		}
+		return alpacaService, nil
+	case BrokerModeSimulated:
+		simulatedBroker := NewSimulatedBroker(config.SimulatedStartingCash)
+		if config.SimulatedPriceFeedPath != "" {
+			err := simulatedBroker.LoadBarsFromCSV(config.SimulatedPriceFeedPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load simulated price feed: %w", err)
+			}
+		}
+		log.Printf("Using simulated broker with $%.2f starting cash", config.SimulatedStartingCash)
+		return simulatedBroker, nil
+	default:
+		return nil, fmt.Errorf("unknown broker mode: %s", config.BrokerMode)
+	}
+}
+
 // Run executes the main trading bot logic
 func (tb *TradingBot) Run(ctx context.Context) error {
 	log.Println("Starting Artemis Trading Bot...")
 
-	// Check if market is open
-	// isOpen, err := tb.alpacaService.IsMarketOpen(ctx)
-	// if err != nil {
-	// 	log.Printf("Warning: Could not check market status: %v", err)
-	// 	tb.notificationService.NotifyError("Market Status Check", "Could not check market status", err.Error())
-	// } else if !isOpen {
-	// 	log.Println("Market is closed. Skipping trading operations.")
-	// 	tb.notificationService.NotifyMarketClosed()
-	// 	return nil
-	// }
+	// The kill switch is checked before anything else
+	err := tb.loadTradingControl(ctx)
+	if err != nil {
+		tb.notificationService.NotifyError("Trading Control", "Failed to load the trading mode, not trading this run", err.Error())
+		return err
+	}
+	if tb.tradingMode() == types.TradingModePaused && !tb.config.DryRun {
+		log.Println("Trading is paused. Skipping this run.")
+		tb.notificationService.NotifyRunSkipped(tb.tradingModeNote())
+		return nil
+	}
+
+	if tb.config.DryRun {
+		return tb.runPlan(ctx)
+	}
+
+	// Make sure no other execution is trading the same signals
+	ctx, releaseLock, err := tb.acquireRunLock(ctx)
+	if errors.Is(err, errRunSkipped) {
+		log.Println("Another trading bot run holds the run lock. Skipping this run.")
+		tb.notificationService.NotifyRunSkipped("Another trading bot run is already in progress")
+		return nil
+	}
+	if err != nil {
+		tb.notificationService.NotifyError("Run Lock", "Failed to acquire run lock", err.Error())
+		return err
+	}
+	defer releaseLock()
+
+	// Evaluate dates and the session against the trading calendar
+	err = tb.loadCalendar(ctx)
+	if err != nil {
+		tb.notificationService.NotifyError("Trading Calendar", "Failed to load the trading calendar", err.Error())
+		return err
+	}
 
 	// Load all data from DynamoDB into memory
-	err := tb.loadData(ctx)
+	err = tb.loadData(ctx)
 	if err != nil {
 		tb.notificationService.NotifyError("Data Load", "Failed to load data from DynamoDB", err.Error())
 		return fmt.Errorf("failed to load data: %w", err)
 	}
 
-	// Update allocation window if needed
-	err = tb.updateAllocationWindow(ctx)
+	// Update allocation window if needed, keeping the one it replaces in the history
+	// A new window is saved before any signal is admitted into it
+	closedWindow, err := tb.updateAllocationWindow(ctx)
+	if err == nil {
+		if closedWindow != nil {
+			err = tb.archiveAllocationWindow(ctx, closedWindow)
+		} else {
+			err = tb.saveAllocationWindow(ctx)
+		}
+	}
 	if err != nil {
 		log.Printf("Warning: Failed to update allocation window: %v", err)
 		tb.notificationService.NotifyError("Allocation Window", "Failed to update allocation window", err.Error())
 		return err
 	}
 
+	// Move waitlisted signals into the slots the window has free
+	tb.updateWaitlist(ctx, tb.calendar.Today(time.Now()), true)
+
 	// Get current allocation per signal
 	allocationPerSignal, err := tb.getAllocationPerSignal()
 	if err != nil {
@@ -88,11 +224,30 @@ func (tb *TradingBot) Run(ctx context.Context) error {
 		allocationPerSignal = tb.config.DefaultAllocationAmount
 	}
 
+	// Snapshot the account for the pre-trade risk checks
+	tb.loadRiskEngine(ctx)
+
+	// Halt new buys if the account has lost too much
+	tb.evaluateCircuitBreaker(ctx, true)
+
 	log.Printf("Found %d active signals", len(tb.signals))
 
+	// Fund the due signals in rank order when there is not enough for all of them
+	tb.rankSignals(tb.calendar.Today(time.Now()))
+
 	// Process each signal
 	for i := range tb.signals {
+		previousStatus := tb.signals[i].Status
+		previousUpdatedAt := tb.signals[i].UpdatedAt
+
 		err := tb.processSignal(ctx, &tb.signals[i], allocationPerSignal)
+
+		// Persist any change right away so a timeout later in the run cannot lose it
+		if !tb.signals[i].UpdatedAt.Equal(previousUpdatedAt) {
+			tb.persistSignal(ctx, &tb.signals[i], previousStatus)
+		}
+		tb.persistAllocationWindow(ctx)
+
 		if err != nil {
 			log.Printf("Error processing signal %s: %v", tb.signals[i].UUID, err)
 			tb.errorCount++
@@ -102,17 +257,27 @@ func (tb *TradingBot) Run(ctx context.Context) error {
 		tb.processedCount++
 	}
 
-	// Save all changes back to DynamoDB
+	// Save the allocation window and retry any signal writes that failed during the run
 	err = tb.saveData(ctx)
 	if err != nil {
-		tb.notificationService.NotifyError("Data Save", "Failed to save data to DynamoDB", err.Error())
+		var partialErr *store.PartialWriteError
+		if errors.As(err, &partialErr) {
+			tb.notificationService.NotifyError("Data Save",
+				fmt.Sprintf("%d signal changes could not be written to DynamoDB", len(partialErr.FailedSignals)),
+				partialErr.Error())
+		} else {
+			tb.notificationService.NotifyError("Data Save", "Failed to save data to DynamoDB", err.Error())
+		}
 		return fmt.Errorf("failed to save data: %w", err)
 	}
 
+	// Ask for decisions on the orders that need approval
+	tb.postApprovalRequests()
+
 	// Send bot completion notification with account summary (only notification with @everyone)
-	accountValue, _ := tb.alpacaService.GetAccountValue(ctx)
-	cashBalance, _ := tb.alpacaService.GetCashBalance(ctx)
-	tb.notificationService.NotifyBotComplete(tb.processedCount, tb.errorCount, accountValue, cashBalance, len(tb.signals))
+	accountValue, _ := tb.broker.GetAccountValue(ctx)
+	cashBalance, _ := tb.broker.GetCashBalance(ctx)
+	tb.notificationService.NotifyBotComplete(tb.processedCount, tb.errorCount, accountValue, cashBalance, len(tb.signals), tb.riskRejections)
 
 	log.Println("Trading bot run completed")
 	return nil
@@ -120,37 +285,27 @@ func (tb *TradingBot) Run(ctx context.Context) error {
 
 // processSignal handles a single signal based on its status
 func (tb *TradingBot) processSignal(ctx context.Context, signal *types.Signal, allocationPerSignal float64) error {
-	currentDate := time.Now().UTC().Truncate(24 * time.Hour)
-	oldStatus := signal.Status
+	currentDate := tb.calendar.Today(time.Now())
+
+	// Signals expired from the waitlist earlier in the run need nothing more
+	if signal.Status.IsTerminal() {
+		return nil
+	}
 
 	switch signal.Status {
-	case types.SignalStatusPending:
-		err := tb.processPendingSignal(ctx, signal, allocationPerSignal, currentDate)
-		if err != nil {
-			return err
-		}
-		// If status changed, track the old state for deletion
-		if signal.Status != oldStatus {
-			// Create a copy of the signal with the old status for deletion
-			oldSignal := *signal
-			oldSignal.Status = oldStatus
-			tb.signalsToDelete = append(tb.signalsToDelete, oldSignal)
-			log.Printf("Signal %s status changed from %s to %s, will delete old record", signal.UUID, oldStatus, signal.Status)
-		}
+	case types.SignalStatusWaitlisted:
+		// Still waiting for a slot, updateWaitlist promotes it once one is free
 		return nil
+	case types.SignalStatusPending:
+		tb.rollSellDate(signal)
+		return tb.processPendingSignal(ctx, signal, allocationPerSignal, currentDate)
+	case types.SignalStatusBuying, types.SignalStatusPartiallyFilled:
+		return tb.reconcileBuyOrder(ctx, signal)
 	case types.SignalStatusBought:
-		err := tb.processBoughtSignal(ctx, signal, currentDate)
-		if err != nil {
-			return err
-		}
-		// If status changed to completed, track for deletion
-		if signal.Status == types.SignalStatusCompleted {
-			// Create a copy of the signal with the current status for deletion
-			signalToDelete := *signal
-			tb.signalsToDelete = append(tb.signalsToDelete, signalToDelete)
-			log.Printf("Signal %s completed, will delete from database", signal.UUID)
-		}
-		return nil
+		tb.rollSellDate(signal)
+		return tb.processBoughtSignal(ctx, signal, currentDate)
+	case types.SignalStatusSelling:
+		return tb.reconcileSellOrder(ctx, signal)
 	default:
 		log.Printf("Unknown signal status: %s for signal %s", signal.Status, signal.UUID)
 		return nil
@@ -159,121 +314,329 @@ func (tb *TradingBot) processSignal(ctx context.Context, signal *types.Signal, a
 
 // processPendingSignal handles signals that are pending execution
 func (tb *TradingBot) processPendingSignal(ctx context.Context, signal *types.Signal, allocationPerSignal float64, currentDate time.Time) error {
-	buyDate := signal.BuyDate.UTC().Truncate(24 * time.Hour)
+	// A buy order was attached while the signal was still pending, keep following it
+	if signal.BuyOrderID != "" {
+		err := signal.Transition(types.SignalStatusBuying, fmt.Sprintf("Following buy order %s placed earlier", signal.BuyOrderID))
+		if err != nil {
+			return err
+		}
+		return tb.reconcileBuyOrder(ctx, signal)
+	}
+
+	// A buy date on a market holiday or weekend is bought on the next session
+	buyDate := tb.calendar.NextTradingDay(signal.BuyDate)
 
 	if currentDate.Before(buyDate) {
 		log.Printf("Signal %s buy date %s is in the future, skipping", signal.UUID, buyDate.Format("2006-01-02"))
 		return nil
 	}
 
+	if reason := tb.pendingExpiryReason(signal, currentDate); reason != "" {
+		return tb.expireSignal(signal, reason)
+	}
+
+	if !tb.canPlaceOrders() {
+		log.Printf("Market is %s, deferring buy for signal %s", tb.sessionState, signal.UUID)
+		return nil
+	}
+
+	if reason := tb.buysHalted(); reason != "" {
+		log.Printf("Buy for signal %s halted: %s", signal.UUID, reason)
+		tb.carryOver(signal, types.CarryOver{Reason: types.CarryOverBuysHalted}, "Buys are halted: "+reason, currentDate)
+		return nil
+	}
+
+	baseAllocation, windowFull, rejected := tb.windowAdmission(signal, allocationPerSignal)
+	if windowFull != "" {
+		if rejected {
+			return tb.expireSignal(signal, windowFull)
+		}
+		tb.queueForWindow(signal, windowFull)
+		tb.carryOver(signal, types.CarryOver{Reason: types.CarryOverWindowFull}, windowFull, currentDate)
+		return nil
+	}
+
+	allocation, size := tb.sizeSignal(ctx, signal, baseAllocation)
+	if allocation <= 0 {
+		log.Printf("Signal %s was sized to $%.2f by %s sizing, not buying", signal.UUID, allocation, size.Strategy)
+		tb.carryOver(signal, types.CarryOver{Reason: types.CarryOverNotSized, Allocation: allocation},
+			fmt.Sprintf("Sized to $%.2f by %s sizing", allocation, size.Strategy), currentDate)
+		return nil
+	}
+
+	// Never place a buy on a quote that cannot be trusted
+	quote, err := tb.checkQuote(ctx, signal.Ticker)
+	if err != nil {
+		log.Printf("Buy for signal %s deferred: %v", signal.UUID, err)
+		tb.carryOver(signal, types.CarryOver{Reason: types.CarryOverQuoteRejected, BidPrice: quote.BidPrice, AskPrice: quote.AskPrice},
+			"Quote check failed: "+err.Error(), currentDate)
+		return nil
+	}
+
+	limitPrice := tb.limitPrice(quote, OrderStepBuy)
+	if violation := tb.checkBuyRisk(signal, allocation, quote, limitPrice); violation != nil {
+		log.Printf("Buy for signal %s blocked: %v", signal.UUID, violation)
+		tb.carryOver(signal, types.CarryOver{Reason: types.CarryOverRiskLimit, Rule: string(violation.Rule)}, violation.Error(), currentDate)
+		return nil
+	}
+
+	// Nothing holds the buy back any more, sessions from here on count again
+	tb.endCarryOver(signal, currentDate)
+
+	approval := signal.Approval
+	approved, err := tb.awaitApproval(ctx, signal, OrderStepBuy, allocation)
+	if !approved {
+		// A new approval request is for this size
+		if signal.Approval != approval {
+			signal.Sizing = size
+		}
+		return err
+	}
+
 	log.Printf("Processing pending signal %s for %s", signal.UUID, signal.Ticker)
 
-	// Execute buy order
-	order, err := tb.alpacaService.BuyStock(ctx, signal.Ticker, allocationPerSignal)
+	// Execute buy order, adopting any order already placed for this attempt
+	order, err := tb.placeBuyOrder(ctx, signal, allocation, limitPrice)
 	if err != nil {
+		recordFailedBuyAttempt(signal, err.Error())
+
+		// Give up quietly once the attempts are used up, the expiry is announced instead
+		if reason := tb.pendingExpiryReason(signal, currentDate); reason != "" {
+			log.Printf("Buy for signal %s failed: %v", signal.UUID, err)
+			return tb.expireSignal(signal, reason)
+		}
 		return fmt.Errorf("failed to buy stock for signal %s: %w", signal.UUID, err)
 	}
 
-	// Get order details
-	shares, _ := order.Qty.Float64()
+	// Count the order against the run's limits, at most the full allocation is spent
+	tb.risk.RecordBuy(signal.Ticker, allocation)
+	signal.Sizing = size
+	signal.RiskRejection = nil
+	signal.CarryOver = nil
 
-	// For market orders, get the actual execution price from the order
-	// Market orders execute immediately, so the filled average price should be available
-	var executionPrice float64
-	if order.FilledAvgPrice != nil {
-		executionPrice, _ = order.FilledAvgPrice.Float64()
-	} else {
-		// Fallback to current price if filled average price is not available yet
-		executionPrice, err = tb.alpacaService.GetCurrentPrice(ctx, signal.Ticker)
-		if err != nil {
-			log.Printf("Warning: Could not get execution price for %s: %v", signal.Ticker, err)
-			executionPrice = 0
+	// The approval was used by this order, a retry needs a new one
+	signal.Approval = nil
+
+	// The order takes a slot in the allocation window until it ends without fills
+	tb.takeWindowSlot(signal)
+
+	// Remember the order so later runs can follow it if it does not fill right away
+	signal.BuyOrderID = order.ID
+	err = signal.Transition(types.SignalStatusBuying, fmt.Sprintf("Buy order %s placed", order.ID))
+	if err != nil {
+		return err
+	}
+
+	return tb.reconcileBuyOrder(ctx, signal)
+}
+
+// reconcileBuyOrder follows the signal's buy order and records the actual fills
+// once the order has reached a terminal state
+func (tb *TradingBot) reconcileBuyOrder(ctx context.Context, signal *types.Signal) error {
+	order, err := tb.waitForOrder(ctx, signal.BuyOrderID)
+	if err != nil {
+		return fmt.Errorf("failed to reconcile buy order for signal %s: %w", signal.UUID, err)
+	}
+
+	shares, executionPrice := orderFill(order)
+
+	if !isOrderTerminal(order) {
+		log.Printf("Buy order %s for signal %s is %s (%f shares filled so far), will check again on next run",
+			order.ID, signal.UUID, order.Status, shares)
+
+		if shares > 0 && signal.Status == types.SignalStatusBuying {
+			return signal.Transition(types.SignalStatusPartiallyFilled,
+				fmt.Sprintf("Buy order %s has filled %f shares so far", order.ID, shares))
 		}
+		return nil
 	}
 
-	if shares > 0 {
-		signal.NumStocks = shares
-		signal.BuyPrice = executionPrice
-		signal.Status = types.SignalStatusBought
-		signal.UpdatedAt = time.Now()
+	if shares <= 0 {
+		orderID := signal.BuyOrderID
+		reason := fmt.Sprintf("Buy order %s was %s without any fills", orderID, order.Status)
+		tb.releaseWindowSlot(signal)
+
+		// A rejected order will not succeed on a retry either
+		if order.Status == OrderStatusRejected {
+			if err := signal.Transition(types.SignalStatusFailed, reason); err != nil {
+				return err
+			}
+			tb.notificationService.NotifySignalFailed(signal.Ticker, signal.UUID.String(), reason)
+			return fmt.Errorf("buy order %s for signal %s was rejected", orderID, signal.UUID)
+		}
 
-		// Signal is already updated in memory, will be saved at the end
+		// Nothing was bought, clear the order so the buy is retried on the next run
+		// under a fresh client order ID
+		signal.BuyOrderID = ""
+		signal.BuyOrderAttempt++
+		recordFailedBuyAttempt(signal, reason)
+		if err := signal.Transition(types.SignalStatusPending, reason+", will retry"); err != nil {
+			return err
+		}
+		return fmt.Errorf("buy order %s for signal %s was %s without any fills", orderID, signal.UUID, order.Status)
+	}
 
-		// Send Discord notification
-		tb.notificationService.NotifySignalBought(signal.Ticker, shares, executionPrice, signal.BuyDate, signal.SellDate)
+	reason := fmt.Sprintf("Buy order %s filled %f shares at $%.2f", order.ID, shares, executionPrice)
+	if order.Status != OrderStatusFilled {
+		log.Printf("Warning: Buy order %s for signal %s was %s after a partial fill of %f shares",
+			order.ID, signal.UUID, order.Status, shares)
+		reason = fmt.Sprintf("Buy order %s was %s after filling %f shares at $%.2f", order.ID, order.Status, shares, executionPrice)
+	}
 
-		log.Printf("Successfully placed market buy order for %f shares of %s at $%.2f for signal %s",
-			shares, signal.Ticker, executionPrice, signal.UUID)
-	} else {
-		log.Printf("Warning: Could not determine shares from order for signal %s", signal.UUID)
+	signal.NumStocks = shares
+	signal.BuyPrice = executionPrice
+	signal.BoughtAt = time.Now()
+	if err := signal.Transition(types.SignalStatusBought, reason); err != nil {
+		return err
 	}
+	tb.recordWindowDeployed(shares * executionPrice)
+	if order.Status == OrderStatusFilled {
+		tb.recordLeftoverCash(signal, shares*executionPrice)
+	}
+
+	// Send Discord notification
+	tb.notificationService.NotifySignalBought(signal.Ticker, shares, executionPrice, signal.BuyDate, signal.SellDate)
+
+	log.Printf("Buy order %s filled: %f shares of %s at $%.2f for signal %s",
+		order.ID, shares, signal.Ticker, executionPrice, signal.UUID)
 
 	return nil
 }
 
 // processBoughtSignal handles signals that have been bought and need to be sold
 func (tb *TradingBot) processBoughtSignal(ctx context.Context, signal *types.Signal, currentDate time.Time) error {
-	sellDate := signal.SellDate.UTC().Truncate(24 * time.Hour)
+	// A sell order was attached while the signal was still bought, keep following it
+	if signal.SellOrderID != "" {
+		err := signal.Transition(types.SignalStatusSelling, fmt.Sprintf("Following sell order %s placed earlier", signal.SellOrderID))
+		if err != nil {
+			return err
+		}
+		return tb.reconcileSellOrder(ctx, signal)
+	}
+
+	sellDate := tb.calendar.NextTradingDay(signal.SellDate)
 
-	if currentDate.Before(sellDate) {
+	// Liquidation sells every position now, it was already decided by whoever set the mode
+	liquidating := tb.tradingMode() == types.TradingModeLiquidateAll
+
+	if currentDate.Before(sellDate) && !liquidating {
 		log.Printf("Signal %s sell date %s is in the future, skipping", signal.UUID, sellDate.Format("2006-01-02"))
 		return nil
 	}
 
+	if !tb.canPlaceOrders() {
+		log.Printf("Market is %s, deferring sell for signal %s", tb.sessionState, signal.UUID)
+		return nil
+	}
+
+	// A sell placed on a bad quote can fill far from the market, it waits for a sane one
+	quote, err := tb.checkQuote(ctx, signal.Ticker)
+	if err != nil {
+		log.Printf("Sell for signal %s deferred to the next run: %v", signal.UUID, err)
+		return nil
+	}
+
+	if !liquidating {
+		approved, err := tb.awaitApproval(ctx, signal, OrderStepSell, 0)
+		if !approved {
+			return err
+		}
+	}
+
 	log.Printf("Processing bought signal %s for %s", signal.UUID, signal.Ticker)
 
-	// Execute sell order
-	order, err := tb.alpacaService.SellStock(ctx, signal.Ticker, signal.NumStocks)
+	if signal.ExitReason == "" {
+		signal.ExitReason = types.ExitReasonSellDate
+		if liquidating {
+			signal.ExitReason = types.ExitReasonLiquidation
+		}
+	}
+
+	// Execute sell order, adopting any order already placed for this attempt
+	order, err := tb.placeSellOrder(ctx, signal, tb.limitPrice(quote, OrderStepSell))
 	if err != nil {
 		return fmt.Errorf("failed to sell stock for signal %s: %w", signal.UUID, err)
 	}
 
-	// Get order details - for market orders, get the actual execution price from the order
-	var executionPrice float64
-	if order.FilledAvgPrice != nil {
-		executionPrice, _ = order.FilledAvgPrice.Float64()
-	} else {
-		// Fallback to current price if filled average price is not available yet
-		executionPrice, err = tb.alpacaService.GetCurrentPrice(ctx, signal.Ticker)
-		if err != nil {
-			log.Printf("Warning: Could not get execution price for %s: %v", signal.Ticker, err)
-			executionPrice = 0
-		}
+	// The approval was used by this order, a retry needs a new one
+	signal.Approval = nil
+
+	// Remember the order so later runs can follow it if it does not fill right away
+	signal.SellOrderID = order.ID
+	err = signal.Transition(types.SignalStatusSelling, fmt.Sprintf("Sell order %s placed (%s)", order.ID, signal.ExitReason))
+	if err != nil {
+		return err
 	}
 
-	// Calculate profit/loss
-	var profitLoss, profitLossPct float64
-	if signal.BuyPrice > 0 && executionPrice > 0 {
-		profitLoss = (executionPrice - signal.BuyPrice) * signal.NumStocks
-		profitLossPct = ((executionPrice - signal.BuyPrice) / signal.BuyPrice) * 100
+	return tb.reconcileSellOrder(ctx, signal)
+}
+
+// reconcileSellOrder follows the signal's sell order and completes the signal
+// from the actual fills once the order has reached a terminal state
+func (tb *TradingBot) reconcileSellOrder(ctx context.Context, signal *types.Signal) error {
+	order, err := tb.waitForOrder(ctx, signal.SellOrderID)
+	if err != nil {
+		return fmt.Errorf("failed to reconcile sell order for signal %s: %w", signal.UUID, err)
 	}
 
-	// Calculate duration
-	duration := int(currentDate.Sub(signal.BuyDate).Hours() / 24)
+	soldShares, executionPrice := orderFill(order)
+
+	if !isOrderTerminal(order) {
+		log.Printf("Sell order %s for signal %s is %s (%f shares filled so far), will check again on next run",
+			order.ID, signal.UUID, order.Status, soldShares)
+		return nil
+	}
+
+	// Accumulate fills across sell orders so the trade record covers every share
+	signal.SoldQuantity += soldShares
+	signal.SellProceeds += soldShares * executionPrice
+	tb.recordWindowReturned(soldShares * executionPrice)
+
+	if order.Status != OrderStatusFilled {
+		// Keep whatever was not sold and retry the remainder on the next run
+		orderID := signal.SellOrderID
+		signal.NumStocks -= soldShares
+		signal.SellOrderID = ""
+		signal.SellOrderAttempt++
+		reason := fmt.Sprintf("Sell order %s was %s after selling %f shares, %f shares remain", orderID, order.Status, soldShares, signal.NumStocks)
+		if err := signal.Transition(types.SignalStatusBought, reason); err != nil {
+			return err
+		}
+		return fmt.Errorf("sell order %s for signal %s was %s after selling %f shares, %f shares remain",
+			orderID, signal.UUID, order.Status, soldShares, signal.NumStocks)
+	}
+
+	signal.SellPrice = signal.SellProceeds / signal.SoldQuantity
+	signal.SoldAt = time.Now()
+
+	trade := tb.newTradeRecord(signal)
 
 	// Send Discord notification
-	tb.notificationService.NotifySignalSold(signal.Ticker, signal.NumStocks, executionPrice, signal.BuyPrice, profitLoss, profitLossPct, duration)
+	tb.notificationService.NotifySignalSold(signal.Ticker, trade.Quantity, trade.SellPrice, trade.BuyPrice,
+		trade.RealizedPnL, trade.RealizedPnLPercent, trade.HoldingDays)
 
 	// Log the trade result
 	log.Printf("Trade completed - Signal: %s, Ticker: %s, P&L: $%.2f (%.2f%%), Duration: %d days",
-		signal.UUID, signal.Ticker, profitLoss, profitLossPct, duration)
-
-	// Mark signal for deletion (will be removed when saving)
-	signal.Status = types.SignalStatusCompleted
+		signal.UUID, signal.Ticker, trade.RealizedPnL, trade.RealizedPnLPercent, trade.HoldingDays)
 
-	return nil
+	// Completed signals are moved to the trade history when the signal is persisted
+	return signal.Transition(types.SignalStatusCompleted,
+		fmt.Sprintf("Sell order %s filled %f shares at $%.2f", order.ID, soldShares, executionPrice))
 }
 
-// updateAllocationWindow updates the allocation window if needed
-func (tb *TradingBot) updateAllocationWindow(ctx context.Context) error {
-	currentDate := time.Now().UTC().Truncate(24 * time.Hour)
+// updateAllocationWindow replaces the allocation window once it has expired
+// and returns the window it replaced, if any
+func (tb *TradingBot) updateAllocationWindow(ctx context.Context) (*types.AllocationWindow, error) {
+	currentDate := tb.calendar.Today(time.Now())
 
 	// If no window exists or current window has expired, create/update it
-	if tb.allocationWindow == nil || currentDate.After(tb.allocationWindow.WindowEndDate) {
+	if tb.allocationWindow == nil || tb.allocationWindow.IsExpired(currentDate) {
+		closedWindow := tb.allocationWindow
+
 		// Get account value
-		accountValue, err := tb.alpacaService.GetAccountValue(ctx)
+		accountValue, err := tb.broker.GetAccountValue(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to get account value: %w", err)
+			return nil, fmt.Errorf("failed to get account value: %w", err)
 		}
 
 		// Calculate new window dates
@@ -289,14 +652,18 @@ func (tb *TradingBot) updateAllocationWindow(ctx context.Context) error {
 			AccountValue:         accountValue,
 			AllocationPerSignal:  allocationPerSignal,
 			TotalSignalsInWindow: tb.config.MaxSignalsPerWindow,
+			RemainingBudget:      accountValue,
 			UpdatedAt:            time.Now(),
 		}
 
+		tb.windowChanged = true
+
 		log.Printf("Updated allocation window: $%.2f per signal for max %d signals",
 			allocationPerSignal, tb.config.MaxSignalsPerWindow)
+		return closedWindow, nil
 	}
 
-	return nil
+	return nil, nil
 }
 
 // loadData loads all data from DynamoDB into memory
@@ -309,7 +676,7 @@ func (tb *TradingBot) loadData(ctx context.Context) error {
 	// Filter to only active signals (exclude completed signals)
 	var activeSignals []types.Signal
 	for _, signal := range signals {
-		if signal.Status == types.SignalStatusPending || signal.Status == types.SignalStatusBought {
+		if signal.Status.IsActive() {
 			activeSignals = append(activeSignals, signal)
 		} else {
 			log.Printf("Skipping signal %s with status %s", signal.UUID, signal.Status)
@@ -317,29 +684,44 @@ func (tb *TradingBot) loadData(ctx context.Context) error {
 	}
 
 	tb.signals = activeSignals
-	tb.signalsToDelete = []types.Signal{} // Clear signals to delete at start of each run
+	tb.unsavedSignals = make(map[uuid.UUID]types.SignalStatus) // Clear queued writes at start of each run
+	tb.pendingArchives = []pendingArchive{}
+	tb.approvalRequests = []approvalRequest{}
+	tb.riskRejections = []string{}
+	tb.openIntents = make(map[string]bool)
+	tb.resumedBuys = make(map[string]float64)
 	tb.allocationWindow = allocationWindow
+	tb.windowChanged = false
+	tb.windowStoredAt = time.Time{}
+	if allocationWindow != nil {
+		tb.windowStoredAt = allocationWindow.UpdatedAt
+	}
 
 	log.Printf("Loaded %d active signals and allocation window", len(activeSignals))
-	return nil
+
+	// Repair any transition a previous run started but did not finish saving
+	return tb.resumeIntents(ctx)
 }
 
-// saveData saves all data back to DynamoDB
+// saveData saves the allocation window and retries the signal writes that
+// failed during the run
 func (tb *TradingBot) saveData(ctx context.Context) error {
-	// Filter out completed signals (they should be removed from the database)
-	var activeSignals []types.Signal
-	for _, signal := range tb.signals {
-		if signal.Status != types.SignalStatusCompleted {
-			activeSignals = append(activeSignals, signal)
-		}
-	}
+	archiveErr := tb.retryArchives(ctx)
+	writeErr := tb.retrySignalWrites(ctx)
 
-	err := tb.dbService.SaveAllData(ctx, activeSignals, tb.signalsToDelete, tb.allocationWindow)
+	err := tb.saveAllocationWindow(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to save data to DynamoDB: %w", err)
+		return fmt.Errorf("failed to save allocation window: %w", err)
+	}
+
+	if writeErr != nil {
+		return writeErr
+	}
+	if archiveErr != nil {
+		return archiveErr
 	}
 
-	log.Printf("Saved %d active signals, deleted %d signals, and allocation window to DynamoDB", len(activeSignals), len(tb.signalsToDelete))
+	log.Printf("Saved allocation window to DynamoDB")
 	return nil
 }
 
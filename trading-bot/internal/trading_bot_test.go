@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	alpaca "github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/vignesh-goutham/artemis/pkg/store"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// pendingTestBot returns a trading bot that may buy on any session of November
+// and December 2026, with a $10 quote for AAPL and no risk limits
+func pendingTestBot(t *testing.T, config *Config) (*TradingBot, *SimulatedBroker) {
+	t.Helper()
+
+	broker := NewSimulatedBroker(10000)
+	broker.SetQuote("AAPL", 9.99, 10.01)
+
+	tb := testBot(store.NewMemoryStore(), broker)
+	tb.config = config
+	tb.calendar = testCalendar(t, "2026-11-01", "2026-12-31")
+	tb.sizing = fixedCountSizing{}
+	tb.circuitBreaker = &types.CircuitBreaker{}
+	tb.risk = &RiskEngine{equity: 10000, cash: 10000, positions: make(map[string]float64)}
+	return tb, broker
+}
+
+// dueSignal returns a pending AAPL signal due on 2026-11-23
+func dueSignal(t *testing.T) *types.Signal {
+	return &types.Signal{
+		UUID:     uuid.New(),
+		Ticker:   "AAPL",
+		BuyDate:  date(t, "2026-11-23"),
+		SellDate: date(t, "2026-11-30"),
+		Status:   types.SignalStatusPending,
+	}
+}
+
+func TestProcessPendingSignalRecordsSize(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       Config
+		noQuote      bool
+		wantSized    bool
+		wantStatus   types.SignalStatus
+		wantApproval bool
+	}{
+		{name: "buy placed", wantSized: true, wantStatus: types.SignalStatusBought},
+		{name: "sent for approval", config: Config{RequireApproval: true}, wantSized: true, wantStatus: types.SignalStatusPending, wantApproval: true},
+		{name: "quote rejected", noQuote: true, wantStatus: types.SignalStatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			tb, broker := pendingTestBot(t, &config)
+			if tt.noQuote {
+				broker.SetQuote("AAPL", 0, 0)
+			}
+			signal := dueSignal(t)
+
+			err := tb.processPendingSignal(context.Background(), signal, 1000, date(t, "2026-11-23"))
+			if err != nil {
+				t.Fatalf("processPendingSignal() error = %v", err)
+			}
+
+			if signal.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", signal.Status, tt.wantStatus)
+			}
+			if (signal.Approval != nil) != tt.wantApproval {
+				t.Errorf("approval = %+v, want requested %v", signal.Approval, tt.wantApproval)
+			}
+			if !tt.wantSized {
+				if signal.Sizing != nil {
+					t.Errorf("size %+v recorded on a signal that was not bought", signal.Sizing)
+				}
+				return
+			}
+			if signal.Sizing == nil || signal.Sizing.Allocation != 1000 {
+				t.Errorf("size = %+v, want an allocation of $1000", signal.Sizing)
+			}
+		})
+	}
+}
+
+func TestProcessPendingSignalCarriedOverOnce(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		base       float64
+		secondBid  float64 // The quote of the second run, the first is $9.99/$10.01
+		secondAsk  float64
+		wantReason types.CarryOverReason
+	}{
+		{name: "risk limit", config: Config{MaxPrice: 5}, base: 1000, secondBid: 9.98, secondAsk: 10.02, wantReason: types.CarryOverRiskLimit},
+		{name: "quote rejected", config: Config{QuoteMaxSpreadBps: 1}, base: 1000, secondBid: 9.90, secondAsk: 10.10, wantReason: types.CarryOverQuoteRejected},
+		{name: "not sized", base: 0, secondBid: 9.99, secondAsk: 10.01, wantReason: types.CarryOverNotSized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			tb, broker := pendingTestBot(t, &config)
+			tb.risk.limits = RiskLimits{MaxPrice: config.MaxPrice}
+			signal := dueSignal(t)
+
+			if err := tb.processPendingSignal(context.Background(), signal, tt.base, date(t, "2026-11-23")); err != nil {
+				t.Fatalf("first run: processPendingSignal() error = %v", err)
+			}
+			if signal.CarryOver == nil || signal.CarryOver.Reason != tt.wantReason {
+				t.Fatalf("first run: carry-over = %+v, want %s", signal.CarryOver, tt.wantReason)
+			}
+			firstWrite := signal.UpdatedAt
+			if firstWrite.IsZero() {
+				t.Fatalf("first run: carry-over was not written")
+			}
+
+			broker.SetQuote("AAPL", tt.secondBid, tt.secondAsk)
+			if err := tb.processPendingSignal(context.Background(), signal, tt.base, date(t, "2026-11-24")); err != nil {
+				t.Fatalf("second run: processPendingSignal() error = %v", err)
+			}
+			if !signal.UpdatedAt.Equal(firstWrite) {
+				t.Errorf("second run: signal was written again, carry-over %+v, risk rejection %+v, size %+v",
+					signal.CarryOver, signal.RiskRejection, signal.Sizing)
+			}
+		})
+	}
+}
+
+// addOrder puts an order in the given state into the simulated order book
+func addOrder(broker *SimulatedBroker, status string, filledQty, avgPrice float64) string {
+	order := &alpaca.Order{
+		ID:        uuid.New().String(),
+		Symbol:    "AAPL",
+		Side:      alpaca.Buy,
+		Status:    status,
+		FilledQty: decimal.NewFromFloat(filledQty),
+		CreatedAt: time.Now(),
+	}
+	if filledQty > 0 {
+		price := decimal.NewFromFloat(avgPrice)
+		order.FilledAvgPrice = &price
+	}
+	broker.orders[order.ID] = order
+	return order.ID
+}
+
+func TestReconcileBuyOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		filledQty  float64
+		wantStatus types.SignalStatus
+		wantShares float64
+		wantSlot   bool // The signal still holds its window slot
+		wantErr    bool
+	}{
+		{name: "filled", status: OrderStatusFilled, filledQty: 10, wantStatus: types.SignalStatusBought, wantShares: 10, wantSlot: true},
+		{name: "working without fills", status: "new", wantStatus: types.SignalStatusBuying, wantSlot: true},
+		{name: "working after a partial fill", status: OrderStatusPartiallyFilled, filledQty: 4, wantStatus: types.SignalStatusPartiallyFilled, wantSlot: true},
+		{name: "cancelled after a partial fill", status: OrderStatusCanceled, filledQty: 4, wantStatus: types.SignalStatusBought, wantShares: 4, wantSlot: true},
+		{name: "expired without fills is retried", status: OrderStatusExpired, wantStatus: types.SignalStatusPending, wantErr: true},
+		{name: "rejected fails the signal", status: OrderStatusRejected, wantStatus: types.SignalStatusFailed, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb, broker := pendingTestBot(t, &Config{})
+			tb.allocationWindow = &types.AllocationWindow{
+				WindowStartDate:      date(t, "2026-11-23"),
+				AccountValue:         3000,
+				TotalSignalsInWindow: 3,
+				RemainingBudget:      3000,
+			}
+			signal := dueSignal(t)
+			tb.takeWindowSlot(signal)
+			signal.Status = types.SignalStatusBuying
+			signal.BuyOrderID = addOrder(broker, tt.status, tt.filledQty, 10)
+
+			err := tb.reconcileBuyOrder(context.Background(), signal)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("reconcileBuyOrder() error = %v, want error %v", err, tt.wantErr)
+			}
+			if signal.Status != tt.wantStatus || signal.NumStocks != tt.wantShares {
+				t.Errorf("signal is %s with %.2f shares, want %s with %.2f", signal.Status, signal.NumStocks, tt.wantStatus, tt.wantShares)
+			}
+			if holds := tb.allocationWindow.HoldsSlot(signal); holds != tt.wantSlot {
+				t.Errorf("signal holds its window slot = %v, want %v", holds, tt.wantSlot)
+			}
+			if deployed := tb.allocationWindow.CapitalDeployed; deployed != tt.wantShares*10 {
+				t.Errorf("window deployed $%.2f, want $%.2f", deployed, tt.wantShares*10)
+			}
+			if tt.wantStatus == types.SignalStatusPending && (signal.BuyOrderID != "" || signal.BuyOrderAttempt != 1) {
+				t.Errorf("retried signal has buy order %q on attempt %d, want no order on attempt 1", signal.BuyOrderID, signal.BuyOrderAttempt)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	alpaca "github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// Range of sessions fetched around the current date
+const (
+	calendarLookbackDays  = 30
+	calendarLookaheadDays = 400
+)
+
+// SessionState describes where the current time falls relative to the trading session
+type SessionState string
+
+const (
+	SessionStatePreMarket  SessionState = "PRE_MARKET"  // Trading day, before the open
+	SessionStateOpen       SessionState = "OPEN"        // Regular session in progress
+	SessionStateAfterHours SessionState = "AFTER_HOURS" // Trading day, after the close
+	SessionStateClosed     SessionState = "CLOSED"      // Weekend or market holiday
+)
+
+// TradingSession is a single trading day with its open and close times
+type TradingSession struct {
+	Date  time.Time // Midnight UTC of the New York date, comparable with signal dates
+	Open  time.Time
+	Close time.Time
+}
+
+// TradingCalendar knows which dates are trading days and when their sessions
+// open and close. Dates are New York calendar dates stored as midnight UTC,
+// the same way signal buy and sell dates are stored.
+type TradingCalendar struct {
+	location *time.Location
+	start    time.Time
+	end      time.Time
+	sessions map[string]TradingSession
+}
+
+// calendarCache is the on-disk layout of the cached calendar
+type calendarCache struct {
+	FetchedAt time.Time            `json:"fetched_at"`
+	Start     time.Time            `json:"start"`
+	End       time.Time            `json:"end"`
+	Days      []alpaca.CalendarDay `json:"days"`
+}
+
+// loadTradingCalendar returns the trading calendar around now, from the local
+// cache when it is fresh enough and from the broker otherwise. A stale cache is
+// still used if the broker cannot be reached. A fetched calendar is only cached
+// when writeCache is set.
+func loadTradingCalendar(ctx context.Context, broker Broker, cachePath string, ttl time.Duration, now time.Time, writeCache bool) (*TradingCalendar, error) {
+	location, err := time.LoadLocation(types.MarketTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load market timezone: %w", err)
+	}
+	today := types.SessionDate(now, location)
+
+	cache, err := readCalendarCache(cachePath)
+	if err != nil {
+		log.Printf("Warning: Ignoring trading calendar cache: %v", err)
+	}
+	cacheCoversToday := cache != nil && !cache.Start.After(today) && !cache.End.Before(today)
+
+	if cacheCoversToday && now.Sub(cache.FetchedAt) < ttl {
+		return newTradingCalendar(cache, location)
+	}
+
+	start := today.AddDate(0, 0, -calendarLookbackDays)
+	end := today.AddDate(0, 0, calendarLookaheadDays)
+
+	days, err := broker.GetCalendar(ctx, start, end)
+	if err != nil {
+		if cacheCoversToday {
+			log.Printf("Warning: Failed to refresh trading calendar, using cache from %s: %v",
+				cache.FetchedAt.Format(time.RFC3339), err)
+			return newTradingCalendar(cache, location)
+		}
+		return nil, fmt.Errorf("failed to load trading calendar: %w", err)
+	}
+
+	cache = &calendarCache{
+		FetchedAt: now,
+		Start:     start,
+		End:       end,
+		Days:      days,
+	}
+	if writeCache {
+		if err := writeCalendarCache(cachePath, cache); err != nil {
+			log.Printf("Warning: Failed to cache trading calendar: %v", err)
+		}
+	}
+
+	return newTradingCalendar(cache, location)
+}
+
+// newTradingCalendar builds the calendar from the broker's calendar days
+func newTradingCalendar(cache *calendarCache, location *time.Location) (*TradingCalendar, error) {
+	calendar := &TradingCalendar{
+		location: location,
+		start:    cache.Start,
+		end:      cache.End,
+		sessions: make(map[string]TradingSession, len(cache.Days)),
+	}
+
+	for _, day := range cache.Days {
+		date, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calendar date %q: %w", day.Date, err)
+		}
+		openTime, err := time.ParseInLocation("2006-01-02 15:04", day.Date+" "+day.Open, location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid open time %q on %s: %w", day.Open, day.Date, err)
+		}
+		closeTime, err := time.ParseInLocation("2006-01-02 15:04", day.Date+" "+day.Close, location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid close time %q on %s: %w", day.Close, day.Date, err)
+		}
+
+		calendar.sessions[day.Date] = TradingSession{Date: date, Open: openTime, Close: closeTime}
+	}
+
+	return calendar, nil
+}
+
+// Today returns the New York calendar date of now
+func (c *TradingCalendar) Today(now time.Time) time.Time {
+	return types.SessionDate(now, c.location)
+}
+
+// Session returns the trading session on date, if the market opens that day
+func (c *TradingCalendar) Session(date time.Time) (TradingSession, bool) {
+	session, ok := c.sessions[date.UTC().Format("2006-01-02")]
+	return session, ok
+}
+
+// IsTradingDay reports whether the market opens on date
+func (c *TradingCalendar) IsTradingDay(date time.Time) bool {
+	_, ok := c.Session(date)
+	return ok
+}
+
+// NextTradingDay returns date if it is a trading day, otherwise the first
+// trading day after it. Dates past the end of the calendar are returned as is.
+func (c *TradingCalendar) NextTradingDay(date time.Time) time.Time {
+	start := date.UTC().Truncate(24 * time.Hour)
+	for day := start; !day.After(c.end); day = day.AddDate(0, 0, 1) {
+		if c.IsTradingDay(day) {
+			return day
+		}
+	}
+	return start
+}
+
+// SessionsBetween counts the trading sessions from from up to, but not
+// including, to. Weekdays before the start of the calendar are counted as
+// sessions, as their holidays are not known.
+func (c *TradingCalendar) SessionsBetween(from, to time.Time) int {
+	sessions := 0
+	for day := from.UTC().Truncate(24 * time.Hour); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if day.Before(c.start) {
+			if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+				sessions++
+			}
+			continue
+		}
+		if c.IsTradingDay(day) {
+			sessions++
+		}
+	}
+	return sessions
+}
+
+// State returns the session state at now
+func (c *TradingCalendar) State(now time.Time) SessionState {
+	session, ok := c.Session(c.Today(now))
+	switch {
+	case !ok:
+		return SessionStateClosed
+	case now.Before(session.Open):
+		return SessionStatePreMarket
+	case now.Before(session.Close):
+		return SessionStateOpen
+	default:
+		return SessionStateAfterHours
+	}
+}
+
+// loadCalendar loads the trading calendar and the state of the current session.
+// The dry run reads the calendar cache but does not write it.
+func (tb *TradingBot) loadCalendar(ctx context.Context) error {
+	now := time.Now()
+	ttl := time.Duration(tb.config.CalendarCacheTTLHours) * time.Hour
+
+	calendar, err := loadTradingCalendar(ctx, tb.broker, tb.config.CalendarCachePath, ttl, now, !tb.config.DryRun)
+	if err != nil {
+		return err
+	}
+
+	tb.calendar = calendar
+	tb.sessionState = calendar.State(now)
+	log.Printf("Trading session %s is %s", calendar.Today(now).Format("2006-01-02"), tb.sessionState)
+
+	if !tb.canPlaceOrders() && !tb.config.DryRun {
+		log.Println("Market is closed. Orders will not be placed this run, open orders are still reconciled.")
+		tb.notificationService.NotifyMarketClosed()
+	}
+
+	return nil
+}
+
+// canPlaceOrders reports whether new orders may be placed in the current session
+func (tb *TradingBot) canPlaceOrders() bool {
+	return !tb.config.EnforceMarketHours || tb.sessionState == SessionStateOpen
+}
+
+// rollSellDate moves a sell date that falls on a weekend or market holiday to
+// the next trading day
+func (tb *TradingBot) rollSellDate(signal *types.Signal) {
+	tradingDay := tb.calendar.NextTradingDay(signal.SellDate)
+	if tradingDay.Equal(signal.SellDate) {
+		return
+	}
+
+	log.Printf("Signal %s sell date %s is not a trading day, rolling it to %s",
+		signal.UUID, signal.SellDate.UTC().Format("2006-01-02"), tradingDay.Format("2006-01-02"))
+	signal.SellDate = tradingDay
+	signal.UpdatedAt = time.Now()
+}
+
+// readCalendarCache reads the cached calendar, returning nil if there is none
+func readCalendarCache(path string) (*calendarCache, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar cache: %w", err)
+	}
+
+	var cache calendarCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("failed to parse calendar cache %s: %w", path, err)
+	}
+	return &cache, nil
+}
+
+// writeCalendarCache writes the calendar cache through a temporary file so a
+// crash never leaves it half written
+func writeCalendarCache(path string, cache *calendarCache) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("failed to marshal calendar cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create calendar cache directory: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write calendar cache: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace calendar cache: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	alpaca "github.com/alpacahq/alpaca-trade-api-go/v2/alpaca"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// testCalendar builds a calendar from start to end (inclusive) with a session
+// on every weekday except the given holidays
+func testCalendar(t *testing.T, start, end string, holidays ...string) *TradingCalendar {
+	t.Helper()
+
+	location, err := time.LoadLocation(types.MarketTimezone)
+	if err != nil {
+		t.Fatalf("failed to load market timezone: %v", err)
+	}
+
+	closed := make(map[string]bool)
+	for _, holiday := range holidays {
+		closed[holiday] = true
+	}
+
+	cache := &calendarCache{Start: date(t, start), End: date(t, end)}
+	for day := cache.Start; !day.After(cache.End); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday || closed[key] {
+			continue
+		}
+		cache.Days = append(cache.Days, alpaca.CalendarDay{Date: key, Open: "09:30", Close: "16:00"})
+	}
+
+	calendar, err := newTradingCalendar(cache, location)
+	if err != nil {
+		t.Fatalf("failed to build calendar: %v", err)
+	}
+	return calendar
+}
+
+// date parses a YYYY-MM-DD date as midnight UTC, the way signal dates are stored
+func date(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		t.Fatalf("invalid date %q: %v", value, err)
+	}
+	return parsed
+}
+
+func TestTradingCalendarNextTradingDay(t *testing.T) {
+	// Thanksgiving 2026 is Thursday, November 26
+	calendar := testCalendar(t, "2026-11-01", "2026-12-31", "2026-11-26")
+
+	tests := []struct {
+		name string
+		date string
+		want string
+	}{
+		{name: "trading day", date: "2026-11-24", want: "2026-11-24"},
+		{name: "saturday", date: "2026-11-21", want: "2026-11-23"},
+		{name: "sunday", date: "2026-11-22", want: "2026-11-23"},
+		{name: "holiday", date: "2026-11-26", want: "2026-11-27"},
+		{name: "past the end of the calendar", date: "2027-01-02", want: "2027-01-02"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calendar.NextTradingDay(date(t, tt.date))
+			if !got.Equal(date(t, tt.want)) {
+				t.Errorf("NextTradingDay(%s) = %s, want %s", tt.date, got.Format("2006-01-02"), tt.want)
+			}
+		})
+	}
+}
+
+func TestTradingCalendarSessionsBetween(t *testing.T) {
+	calendar := testCalendar(t, "2026-11-01", "2026-12-31", "2026-11-26")
+
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want int
+	}{
+		{name: "same day", from: "2026-11-23", to: "2026-11-23", want: 0},
+		{name: "one session", from: "2026-11-23", to: "2026-11-24", want: 1},
+		{name: "over a weekend", from: "2026-11-20", to: "2026-11-23", want: 1},
+		{name: "over a holiday", from: "2026-11-23", to: "2026-11-30", want: 4},
+		{name: "weekdays before the calendar", from: "2026-10-26", to: "2026-11-03", want: 6},
+		{name: "backwards", from: "2026-11-24", to: "2026-11-23", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calendar.SessionsBetween(date(t, tt.from), date(t, tt.to))
+			if got != tt.want {
+				t.Errorf("SessionsBetween(%s, %s) = %d, want %d", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTradingCalendarToday(t *testing.T) {
+	calendar := testCalendar(t, "2026-11-01", "2026-12-31")
+
+	tests := []struct {
+		name string
+		now  string
+		want string
+	}{
+		{name: "new york afternoon", now: "2026-11-23T20:00:00Z", want: "2026-11-23"},
+		{name: "utc already on the next day", now: "2026-11-24T02:00:00Z", want: "2026-11-23"},
+		{name: "new york morning", now: "2026-11-24T14:00:00Z", want: "2026-11-24"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, tt.now)
+			if err != nil {
+				t.Fatalf("invalid time %q: %v", tt.now, err)
+			}
+			got := calendar.Today(now)
+			if !got.Equal(date(t, tt.want)) {
+				t.Errorf("Today(%s) = %s, want %s", tt.now, got.Format(time.RFC3339), tt.want)
+			}
+		})
+	}
+}
+
+func TestRollSellDate(t *testing.T) {
+	tb := &TradingBot{calendar: testCalendar(t, "2026-11-01", "2026-12-31", "2026-11-26")}
+
+	tests := []struct {
+		name        string
+		sellDate    string
+		want        string
+		wantUpdated bool
+	}{
+		{name: "trading day is kept", sellDate: "2026-11-25", want: "2026-11-25"},
+		{name: "holiday rolls to the next session", sellDate: "2026-11-26", want: "2026-11-27", wantUpdated: true},
+		{name: "weekend rolls to monday", sellDate: "2026-11-28", want: "2026-11-30", wantUpdated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signal := &types.Signal{SellDate: date(t, tt.sellDate)}
+
+			tb.rollSellDate(signal)
+
+			if !signal.SellDate.Equal(date(t, tt.want)) {
+				t.Errorf("sell date = %s, want %s", signal.SellDate.Format("2006-01-02"), tt.want)
+			}
+			if updated := !signal.UpdatedAt.IsZero(); updated != tt.wantUpdated {
+				t.Errorf("updated = %v, want %v", updated, tt.wantUpdated)
+			}
+		})
+	}
+}
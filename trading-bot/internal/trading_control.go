@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// loadTradingControl reads the trading kill switch. Without a control item the
+// bot trades normally.
+func (tb *TradingBot) loadTradingControl(ctx context.Context) error {
+	control, err := tb.dbService.LoadTradingControl(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load trading control: %w", err)
+	}
+	if control == nil || control.Mode == "" {
+		control = &types.TradingControl{Mode: types.TradingModeNormal}
+	}
+	tb.tradingControl = control
+
+	if control.Mode != types.TradingModeNormal {
+		log.Printf("Trading mode is %s, set by %s at %s: %s",
+			control.Mode, control.UpdatedBy, control.UpdatedAt.Format(time.RFC3339), control.Reason)
+	}
+	return nil
+}
+
+// tradingMode returns the mode the kill switch was in when the run started
+func (tb *TradingBot) tradingMode() types.TradingMode {
+	if tb.tradingControl == nil {
+		return types.TradingModeNormal
+	}
+	return tb.tradingControl.Mode
+}
+
+// tradingModeNote describes who set the current trading mode and why
+func (tb *TradingBot) tradingModeNote() string {
+	control := tb.tradingControl
+	note := fmt.Sprintf("Trading mode is %s", tb.tradingMode())
+	if control != nil && control.UpdatedBy != "" {
+		note += fmt.Sprintf(", set by %s", control.UpdatedBy)
+	}
+	if control != nil && control.Reason != "" {
+		note += ": " + control.Reason
+	}
+	return note
+}
+
+// buysHalted returns why no buys may be placed this run, or an empty string
+func (tb *TradingBot) buysHalted() string {
+	switch {
+	case !tb.tradingMode().AllowsBuys():
+		return tb.tradingModeNote()
+	case tb.circuitBreaker == nil:
+		return "circuit breaker could not be evaluated this run"
+	case tb.circuitBreaker.Tripped:
+		return fmt.Sprintf("circuit breaker tripped at %s: %s",
+			tb.circuitBreaker.TrippedAt.Format(time.RFC3339), tb.circuitBreaker.Reason)
+	default:
+		return ""
+	}
+}
@@ -1,20 +1,120 @@
 package internal
 
+// Broker modes
+const (
+	BrokerModeAlpaca    = "alpaca"
+	BrokerModeSimulated = "simulated"
+)
+
+// Position sizing strategies
+const (
+	SizingStrategyFixedCount    = "fixed_count"
+	SizingStrategyEquityPercent = "equity_percent"
+	SizingStrategyVolatility    = "volatility"
+	SizingStrategyKelly         = "kelly"
+	SizingStrategyConviction    = "conviction"
+)
+
+// Policies for a due buy that finds every slot of the allocation window taken
+const (
+	WindowFullPolicyReject = "reject" // Expire the signal
+	WindowFullPolicyQueue  = "queue"  // Keep the signal pending until the next window
+	WindowFullPolicyShrink = "shrink" // Buy anyway with a smaller allocation
+)
+
+// Execution modes, how orders are priced
+const (
+	ExecutionModeMarket          = "market"           // Market orders
+	ExecutionModeMarketableLimit = "marketable_limit" // Limit orders at the quote plus at most MaxSlippageBps
+)
+
+// Keys of the signal ranking, which orders due signals for funding
+const (
+	RankByPriority   = "priority"   // Higher priority first
+	RankByConviction = "conviction" // Higher conviction first
+	RankByCreated    = "created"    // Added earlier first
+	RankByBuyDate    = "buy_date"   // Earlier buy date first
+
+	DefaultSignalRanking = RankByPriority + "," + RankByConviction + "," + RankByCreated
+)
+
 // Config holds the application configuration
 type Config struct {
 	AlpacaAPIKey    string
 	AlpacaSecretKey string
 	IsPaperTrading  bool
 
-	// DynamoDB configuration
-	DynamoDBRegion string
-	TableName      string
+	// Broker configuration
+	BrokerMode             string  // "alpaca" (default) or "simulated"
+	SimulatedStartingCash  float64 // Starting cash for the simulated broker
+	SimulatedPriceFeedPath string  // CSV of daily bars for the simulated broker
+
+	// Storage configuration
+	StoreBackend            string // "dynamodb" (default), "memory" or "file"
+	StoreFilePath           string // JSON file used by the file backend
+	DynamoDBRegion          string
+	TableName               string
+	DynamoDBEndpoint        string // Endpoint override, e.g. DynamoDB Local
+	DynamoDBAccessKeyID     string // Static credentials, used instead of the default chain when set
+	DynamoDBSecretAccessKey string
+
+	// Dry run
+	DryRun         bool   // Compute and report the plan of a run without placing orders or writing data
+	PlanOutputPath string // File the dry run plan is written to as JSON, in addition to stdout
 
 	// Trading configuration
 	MaxSignalsPerWindow     int
 	WindowDurationDays      int
+	WindowFullPolicy        string // One of the WindowFullPolicy constants, "queue" by default
+	SignalRanking           string // Comma separated RankBy keys, the first one that differs decides
 	DefaultAllocationAmount float64
+	OrderFillTimeoutSeconds int // How long to wait for an order to fill before checking again on the next run
+	RunLockTTLSeconds       int // Lease duration of the run lock, renewed while the run is in progress
+	MaxBuyAttempts          int // Failed buy attempts after which a pending signal expires, 0 retries forever
+	MaxPendingDays          int // Trading sessions past its buy date after which a pending signal expires, 0 waits forever
+
+	// Position sizing
+	SizingStrategy          string  // One of the SizingStrategy constants, "fixed_count" by default
+	SizingEquityPercent     float64 // equity_percent: percentage of equity per signal
+	SizingRiskPercent       float64 // volatility: percentage of equity risked per signal
+	SizingATRPeriod         int     // volatility: days in the average true range
+	SizingATRMultiplier     float64 // volatility: stop distance in ATRs
+	SizingKellyFraction     float64 // kelly: share of the full Kelly bet
+	SizingKellyLookbackDays int     // kelly: days of trade history the win rate is taken from
+	SizingKellyMinTrades    int     // kelly: trades needed before Kelly sizing is used
+
+	// Trading calendar
+	CalendarCachePath     string // Local file the broker's trading calendar is cached in
+	CalendarCacheTTLHours int    // How long the cached calendar is used before it is fetched again
+	EnforceMarketHours    bool   // Only place orders while the regular session is open
+
+	// Trade history
+	TradeHistoryRetentionDays int // Days archived trades are kept before DynamoDB TTL removes them, 0 keeps them forever
+
+	// Pre-trade risk limits, 0 disables a limit
+	MaxTickerEquityPercent  float64
+	MaxGrossExposurePercent float64
+	MinCashReserve          float64
+	MaxNewOrdersPerRun      int
+	MinPrice                float64
+	MaxPrice                float64
+
+	// Order execution
+	ExecutionMode      string  // One of the ExecutionMode constants, "market" by default
+	MaxSlippageBps     float64 // marketable_limit: basis points the limit may be beyond the ask or bid
+	QuoteMaxSpreadBps  float64 // Widest bid/ask spread an order is placed on, 200 by default, 0 disables the check
+	QuoteMaxAgeSeconds int     // Oldest quote an order is placed on, 300 by default, 0 disables the check
+
+	// Loss circuit breaker, 0 disables a threshold
+	MaxDailyLossPercent float64 // Loss from the previous close that halts new buys
+	MaxDrawdownPercent  float64 // Loss from the peak equity that halts new buys
+
+	// Order approval
+	RequireApproval         bool // Only place orders a human approved in Discord
+	ApprovalDeadlineMinutes int  // How long an approval request stays open, 0 until the close of the next trading session
 
 	// Discord notifications
-	DiscordWebhookURL string
+	DiscordWebhookURL        string
+	DiscordBotToken          string // Bot token used to post approval requests with buttons
+	DiscordApprovalChannelID string // Channel approval requests are posted to
 }
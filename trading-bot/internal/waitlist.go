@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vignesh-goutham/artemis/pkg/types"
+)
+
+// updateWaitlist promotes waitlisted signals to PENDING, oldest first, while
+// the allocation window has free slots, and expires those whose sell date has
+// been reached. Each change is saved right away; a signal whose change cannot
+// be saved stays waitlisted for this run. When save is false, as in a dry run,
+// signals are only changed in memory and nothing is announced. It returns the
+// new status of every signal it changed.
+func (tb *TradingBot) updateWaitlist(ctx context.Context, currentDate time.Time, save bool) map[uuid.UUID]types.SignalStatus {
+	capacity := types.NewWindowCapacity(tb.allocationWindow, tb.signals, tb.config.MaxSignalsPerWindow, currentDate)
+	free := capacity.Free()
+	changed := make(map[uuid.UUID]types.SignalStatus)
+
+	for _, signal := range types.Waitlist(tb.signals) {
+		original := *signal
+
+		sellDate := tb.calendar.NextTradingDay(signal.SellDate)
+		expired := !currentDate.Before(sellDate)
+
+		var err error
+		switch {
+		case expired:
+			err = signal.Transition(types.SignalStatusExpired,
+				fmt.Sprintf("Sell date %s was reached before a slot freed up", sellDate.Format("2006-01-02")))
+		case free > 0:
+			err = signal.Promote(currentDate, fmt.Sprintf("Promoted from the waitlist, the allocation window has %d free slots", free))
+		default:
+			continue
+		}
+		if err != nil {
+			log.Printf("Warning: Could not update waitlisted signal %s: %v", signal.UUID, err)
+			continue
+		}
+
+		if save {
+			if expired {
+				err = tb.dbService.TransitionSignal(ctx, signal, types.SignalStatusWaitlisted)
+			} else {
+				// Reserve the slot against concurrent admissions from the Discord bot
+				err = tb.dbService.AdmitSignal(ctx, signal, types.SignalStatusWaitlisted, tb.allocationWindow)
+			}
+			if err != nil {
+				log.Printf("Warning: Failed to save waitlisted signal %s, leaving it waitlisted: %v", signal.UUID, err)
+				*signal = original
+				continue
+			}
+		}
+
+		changed[signal.UUID] = signal.Status
+		if expired {
+			log.Printf("Expired waitlisted signal %s for %s: %s", signal.UUID, signal.Ticker, signal.StatusReason)
+			if save {
+				tb.notificationService.NotifySignalExpired(signal.Ticker, signal.UUID.String(), signal.StatusReason)
+			}
+			continue
+		}
+
+		free--
+		log.Printf("Promoted waitlisted signal %s for %s", signal.UUID, signal.Ticker)
+		if save {
+			tb.notificationService.NotifySignalPromoted(signal.Ticker, signal.UUID.String(), signal.SellDate)
+		}
+	}
+
+	return changed
+}
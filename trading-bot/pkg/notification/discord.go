@@ -6,18 +6,48 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// maxPlanLines caps the signal lines of a plan summary to stay within Discord's message size
+const maxPlanLines = 25
+
+// discordAPIBaseURL is the Discord REST API used to post messages as the bot
+const discordAPIBaseURL = "https://discord.com/api/v10"
+
+// Discord component types and button styles used by approval requests
+const (
+	componentTypeActionRow = 1
+	componentTypeButton    = 2
+	buttonStyleSuccess     = 3
+	buttonStyleDanger      = 4
+)
+
 // DiscordNotificationService handles sending notifications to Discord
 type DiscordNotificationService struct {
 	webhookURL string
 	enabled    bool
+
+	// Approval requests are posted as the bot when both are set, since only
+	// application-owned webhooks may carry buttons
+	botToken          string
+	approvalChannelID string
 }
 
 // DiscordWebhookPayload represents the payload sent to Discord webhook
 type DiscordWebhookPayload struct {
-	Content string `json:"content"`
+	Content    string             `json:"content"`
+	Components []DiscordComponent `json:"components,omitempty"`
+}
+
+// DiscordComponent represents a message component such as a button
+type DiscordComponent struct {
+	Type       int                `json:"type"`
+	Style      int                `json:"style,omitempty"`
+	Label      string             `json:"label,omitempty"`
+	CustomID   string             `json:"custom_id,omitempty"`
+	Components []DiscordComponent `json:"components,omitempty"`
 }
 
 // NewDiscordNotificationService creates a new Discord notification service
@@ -57,6 +87,56 @@ func (d *DiscordNotificationService) sendNotification(message string) error {
 	return nil
 }
 
+// SetApprovalChannel posts approval requests to channelID as the bot instead of through the webhook
+func (d *DiscordNotificationService) SetApprovalChannel(botToken, channelID string) {
+	d.botToken = botToken
+	d.approvalChannelID = channelID
+}
+
+// sendComponentMessage sends a message with components, as the bot when an
+// approval channel is set and through the webhook otherwise
+func (d *DiscordNotificationService) sendComponentMessage(payload DiscordWebhookPayload) error {
+	if d.botToken == "" || d.approvalChannelID == "" {
+		if !d.enabled {
+			log.Println("Discord notifications disabled (no webhook URL)")
+			return nil
+		}
+		return d.post(d.webhookURL, "", payload, http.StatusNoContent)
+	}
+
+	url := fmt.Sprintf("%s/channels/%s/messages", discordAPIBaseURL, d.approvalChannelID)
+	return d.post(url, "Bot "+d.botToken, payload, http.StatusOK)
+}
+
+// post sends a JSON payload and checks the response status
+func (d *DiscordNotificationService) post(url, authorization string, payload DiscordWebhookPayload, expectedStatus int) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Discord payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create Discord request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Discord message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatus {
+		return fmt.Errorf("Discord returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
 // NotifySignalBought sends a notification when a signal is bought
 func (d *DiscordNotificationService) NotifySignalBought(ticker string, shares float64, price float64, buyDate, sellDate time.Time) error {
 	message := fmt.Sprintf("🛒 **Signal Bought**\n"+
@@ -125,16 +205,22 @@ func (d *DiscordNotificationService) NotifyBotStart() error {
 	return d.sendNotification(message)
 }
 
-// NotifyBotComplete sends a notification when the bot completes its run
-func (d *DiscordNotificationService) NotifyBotComplete(processedSignals int, errors int, accountValue float64, cashBalance float64, totalSignals int) error {
+// NotifyBotComplete sends a notification when the bot completes its run,
+// listing the buys a risk rule blocked
+func (d *DiscordNotificationService) NotifyBotComplete(processedSignals int, errors int, accountValue float64, cashBalance float64, totalSignals int, riskRejections []string) error {
 	message := fmt.Sprintf("✅ **Bot Run Complete**\n"+
 		"Signals Processed: %d\n"+
 		"Errors: %d\n"+
 		"Account Value: $%.2f\n"+
 		"Cash Balance: $%.2f\n"+
-		"Active Signals: %d\n@everyone",
+		"Active Signals: %d",
 		processedSignals, errors, accountValue, cashBalance, totalSignals)
 
+	if len(riskRejections) > 0 {
+		message += fmt.Sprintf("\nBlocked by Risk Rules: %d\n%s", len(riskRejections), strings.Join(riskRejections, "\n"))
+	}
+	message += "\n@everyone"
+
 	return d.sendNotification(message)
 }
 
@@ -143,3 +229,97 @@ func (d *DiscordNotificationService) NotifyMarketClosed() error {
 	message := "🏛️ Market Closed\nTrading bot detected that the market is currently closed\n@everyone"
 	return d.sendNotification(message)
 }
+
+// NotifyRunSkipped sends a notification when a run exits without trading
+func (d *DiscordNotificationService) NotifyRunSkipped(reason string) error {
+	message := fmt.Sprintf("⏭️ **Bot Run Skipped**\n%s", reason)
+	return d.sendNotification(message)
+}
+
+// NotifySignalFailed sends a notification when a signal can no longer be traded
+func (d *DiscordNotificationService) NotifySignalFailed(ticker string, signalUUID string, reason string) error {
+	message := fmt.Sprintf("❌ **Signal Failed**\n"+
+		"**%s** (%s)\n"+
+		"Reason: %s",
+		ticker, signalUUID, reason)
+
+	return d.sendNotification(message)
+}
+
+// NotifySignalExpired sends a notification when a pending signal expires without being bought
+func (d *DiscordNotificationService) NotifySignalExpired(ticker string, signalUUID string, reason string) error {
+	message := fmt.Sprintf("⌛ **Signal Expired**\n"+
+		"**%s** (%s) will not be bought\n"+
+		"Reason: %s",
+		ticker, signalUUID, reason)
+
+	return d.sendNotification(message)
+}
+
+// NotifySignalPromoted sends a notification when a waitlisted signal got a slot in the allocation window
+func (d *DiscordNotificationService) NotifySignalPromoted(ticker string, signalUUID string, sellDate time.Time) error {
+	message := fmt.Sprintf("⏫ **Signal Promoted**\n"+
+		"**%s** (%s) left the waitlist and will be bought from today\n"+
+		"Sell Date: %s",
+		ticker, signalUUID, sellDate.Format("2006-01-02"))
+
+	return d.sendNotification(message)
+}
+
+// NotifyCircuitBreakerTripped sends a notification when the loss circuit breaker halts new buys
+func (d *DiscordNotificationService) NotifyCircuitBreakerTripped(rule string, reason string, equity, peakEquity, previousCloseEquity float64) error {
+	message := fmt.Sprintf("🛑 **Circuit Breaker Tripped**\n"+
+		"New buys are halted until the breaker is cleared, due sells continue\n"+
+		"Rule: %s\n"+
+		"Reason: %s\n"+
+		"Equity: $%.2f | Peak: $%.2f | Previous Close: $%.2f\n"+
+		"@everyone",
+		rule, reason, equity, peakEquity, previousCloseEquity)
+
+	return d.sendNotification(message)
+}
+
+// NotifyRunPlan sends the summary of a dry run plan
+func (d *DiscordNotificationService) NotifyRunPlan(sessionDate string, sessionState string, allocationPerSignal float64, buys, sells, expiries, skipped int, lines []string) error {
+	message := fmt.Sprintf("📝 **Dry Run Plan** (no orders placed)\n"+
+		"Session: %s (%s)\n"+
+		"Allocation Per Signal: $%.2f\n"+
+		"Buys: %d | Sells: %d | Expiries: %d | Skipped: %d",
+		sessionDate, sessionState, allocationPerSignal, buys, sells, expiries, skipped)
+
+	if len(lines) > maxPlanLines {
+		remaining := len(lines) - maxPlanLines
+		lines = append(lines[:maxPlanLines:maxPlanLines], fmt.Sprintf("...and %d more", remaining))
+	}
+	if len(lines) > 0 {
+		message += "\n" + strings.Join(lines, "\n")
+	}
+
+	return d.sendNotification(message)
+}
+
+// NotifyApprovalRequest posts a planned order with Approve and Reject buttons
+func (d *DiscordNotificationService) NotifyApprovalRequest(side string, ticker string, signalUUID string, quantity float64, price float64, deadline time.Time, approveCustomID, rejectCustomID string) error {
+	message := fmt.Sprintf("🔔 **Approval Needed: %s %s**\n"+
+		"Shares: %.4f\n"+
+		"Quote: $%.2f\n"+
+		"Estimated Value: $%.2f\n"+
+		"Signal: %s\n"+
+		"Decide by: <t:%d:f>",
+		side, ticker, quantity, price, quantity*price, signalUUID, deadline.Unix())
+
+	payload := DiscordWebhookPayload{
+		Content: message,
+		Components: []DiscordComponent{
+			{
+				Type: componentTypeActionRow,
+				Components: []DiscordComponent{
+					{Type: componentTypeButton, Style: buttonStyleSuccess, Label: "Approve", CustomID: approveCustomID},
+					{Type: componentTypeButton, Style: buttonStyleDanger, Label: "Reject", CustomID: rejectCustomID},
+				},
+			},
+		},
+	}
+
+	return d.sendComponentMessage(payload)
+}